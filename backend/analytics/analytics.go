@@ -0,0 +1,520 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analytics exports planning data as flat, denormalized tables in
+// newline-delimited JSON (NDJSON), for loading into a data warehouse.
+//
+// Each table has one JSON object per line, with the schema given by the
+// corresponding Row type below. Rows are keyed by the columns marked as keys;
+// these are stable for a given team and period, but note that buckets and
+// objectives have no IDs of their own, so they are keyed by position.
+//
+// An incremental export has all the rows of each team and period changed since
+// the watermark, so a team or period's existing rows should be replaced. The
+// deletions table lists the teams and periods which have gone since the watermark,
+// because they were deleted or renamed, so their rows should be removed first.
+// Deleted teams and periods are only listed until they are purged, so incremental
+// exports need to be run more often than the deletion grace period.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"sort"
+	"time"
+)
+
+// Table names
+const (
+	TableTeams       = "teams"
+	TablePeriods     = "periods"
+	TablePeople      = "people"
+	TableBuckets     = "buckets"
+	TableObjectives  = "objectives"
+	TableAssignments = "assignments"
+	TableTags        = "tags"
+	TableGroups      = "groups"
+	TableDeletions   = "deletions"
+)
+
+// Tables lists all the exported tables
+var Tables = []string{
+	TableTeams, TablePeriods, TablePeople, TableBuckets,
+	TableObjectives, TableAssignments, TableTags, TableGroups, TableDeletions,
+}
+
+// TeamRow is the schema of the teams table. Key: TeamID.
+type TeamRow struct {
+	TeamID         string    `json:"teamId"`
+	DisplayName    string    `json:"displayName"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+}
+
+// PeriodRow is the schema of the periods table. Key: TeamID, PeriodID.
+type PeriodRow struct {
+	TeamID                 string    `json:"teamId"`
+	PeriodID               string    `json:"periodId"`
+	DisplayName            string    `json:"displayName"`
+	Unit                   string    `json:"unit"`
+	UnitAbbrev             string    `json:"unitAbbrev"`
+	NotesURL               string    `json:"notesURL"`
+	MaxCommittedPercentage float64   `json:"maxCommittedPercentage"`
+	StartDate              string    `json:"startDate"`
+	EndDate                string    `json:"endDate"`
+	TotalAvailability      float64   `json:"totalAvailability"`
+	LastUpdateTime         time.Time `json:"lastUpdateTime"`
+}
+
+// PersonRow is the schema of the people table. Key: TeamID, PeriodID, PersonID.
+type PersonRow struct {
+	TeamID       string  `json:"teamId"`
+	PeriodID     string  `json:"periodId"`
+	PersonID     string  `json:"personId"`
+	DisplayName  string  `json:"displayName"`
+	Location     string  `json:"location"`
+	Availability float64 `json:"availability"`
+	Assigned     float64 `json:"assigned"`
+}
+
+// BucketRow is the schema of the buckets table. Key: TeamID, PeriodID, BucketIndex.
+type BucketRow struct {
+	TeamID               string  `json:"teamId"`
+	PeriodID             string  `json:"periodId"`
+	BucketIndex          int     `json:"bucketIndex"`
+	DisplayName          string  `json:"displayName"`
+	AllocationType       string  `json:"allocationType"`
+	AllocationPercentage float64 `json:"allocationPercentage"`
+	AllocationAbsolute   float64 `json:"allocationAbsolute"`
+}
+
+// ObjectiveRow is the schema of the objectives table. Key: TeamID, PeriodID, BucketIndex, ObjectiveIndex.
+// ObjectiveIndex is the objective's stack rank within its bucket.
+type ObjectiveRow struct {
+	TeamID           string  `json:"teamId"`
+	PeriodID         string  `json:"periodId"`
+	BucketIndex      int     `json:"bucketIndex"`
+	ObjectiveIndex   int     `json:"objectiveIndex"`
+	BucketName       string  `json:"bucketName"`
+	Name             string  `json:"name"`
+	ResourceEstimate float64 `json:"resourceEstimate"`
+	CommitmentType   string  `json:"commitmentType"`
+	Notes            string  `json:"notes"`
+	BlockID          string  `json:"blockID"`
+	Assigned         float64 `json:"assigned"`
+}
+
+// AssignmentRow is the schema of the assignments table. Key: TeamID, PeriodID, BucketIndex, ObjectiveIndex, PersonID.
+type AssignmentRow struct {
+	TeamID         string  `json:"teamId"`
+	PeriodID       string  `json:"periodId"`
+	BucketIndex    int     `json:"bucketIndex"`
+	ObjectiveIndex int     `json:"objectiveIndex"`
+	ObjectiveName  string  `json:"objectiveName"`
+	PersonID       string  `json:"personId"`
+	Commitment     float64 `json:"commitment"`
+}
+
+// TagRow is the schema of the tags table. Key: TeamID, PeriodID, BucketIndex, ObjectiveIndex, Tag.
+type TagRow struct {
+	TeamID         string `json:"teamId"`
+	PeriodID       string `json:"periodId"`
+	BucketIndex    int    `json:"bucketIndex"`
+	ObjectiveIndex int    `json:"objectiveIndex"`
+	Tag            string `json:"tag"`
+}
+
+// GroupRow is the schema of the groups table. Key: TeamID, PeriodID, BucketIndex, ObjectiveIndex, GroupType.
+type GroupRow struct {
+	TeamID         string `json:"teamId"`
+	PeriodID       string `json:"periodId"`
+	BucketIndex    int    `json:"bucketIndex"`
+	ObjectiveIndex int    `json:"objectiveIndex"`
+	GroupType      string `json:"groupType"`
+	GroupName      string `json:"groupName"`
+}
+
+// Reasons for deletions
+const (
+	ReasonDeleted = "deleted"
+	ReasonRenamed = "renamed"
+)
+
+// DeletionRow is the schema of the deletions table. If PeriodID is empty, the whole team
+// has gone, with all its periods. Reason is ReasonDeleted or ReasonRenamed; renamed teams
+// and periods are exported in full under their NewID. Key: TeamID, PeriodID.
+type DeletionRow struct {
+	TeamID    string    `json:"teamId"`
+	PeriodID  string    `json:"periodId"`
+	Reason    string    `json:"reason"`
+	NewID     string    `json:"newId"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Options controls what is included in an export.
+type Options struct {
+	// Since is the watermark for an incremental export: if set, only teams and periods
+	// changed, restored or renamed after this time are included, along with all the
+	// periods of the teams which are. Teams and periods with no recorded update time
+	// were last changed before update times were recorded, so are only included in
+	// full exports.
+	Since time.Time
+	// TeamFilter, if set, restricts the export to the teams for which it returns true.
+	TeamFilter func(team models.Team) bool
+}
+
+func changedSince(lastUpdate, since time.Time) bool {
+	return since.IsZero() || lastUpdate.After(since)
+}
+
+// itemKey identifies a team, or a period if periodID is not empty
+type itemKey struct {
+	teamID, periodID string
+}
+
+type tableEncoders map[string]*json.Encoder
+
+func (e tableEncoders) write(table string, row interface{}) error {
+	if enc, ok := e[table]; ok {
+		return enc.Encode(row)
+	}
+	return nil
+}
+
+// Export writes the selected tables. The writers map is keyed by table name;
+// tables with no writer are skipped. Teams and periods are written in ID order.
+func Export(ctx context.Context, store storage.StorageService, options Options, writers map[string]io.Writer) error {
+	encoders := make(tableEncoders)
+	for table, w := range writers {
+		encoders[table] = json.NewEncoder(w)
+	}
+
+	teams, err := store.GetAllTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve teams: %v", err)
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].ID < teams[j].ID })
+	renamed, err := store.GetRenamedItems(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve renamed teams and periods: %v", err)
+	}
+	// Teams and periods renamed since the watermark are exported in full under their new IDs.
+	// The rows under their old IDs are deleted, so anything since created there is exported too.
+	renamedItems := make(map[itemKey]bool)
+	for _, item := range renamed {
+		if changedSince(item.Timestamp, options.Since) {
+			renamedItems[itemKey{item.TeamID, item.PeriodID}] = true
+			if item.PeriodID == "" {
+				renamedItems[itemKey{item.NewID, ""}] = true
+			} else {
+				renamedItems[itemKey{item.TeamID, item.NewID}] = true
+			}
+		}
+	}
+
+	for _, team := range teams {
+		if options.TeamFilter != nil && !options.TeamFilter(team) {
+			continue
+		}
+		teamChanged := changedSince(team.LastUpdateTime, options.Since) || renamedItems[itemKey{team.ID, ""}]
+		if teamChanged {
+			err := encoders.write(TableTeams, TeamRow{
+				TeamID:         team.ID,
+				DisplayName:    team.DisplayName,
+				LastUpdateTime: team.LastUpdateTime,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !needsPeriods(encoders) {
+			continue
+		}
+
+		periods, found, err := store.GetAllPeriods(ctx, team.ID)
+		if err != nil {
+			return fmt.Errorf("could not retrieve periods for team '%s': %v", team.ID, err)
+		}
+		if !found {
+			continue
+		}
+		sort.Slice(periods, func(i, j int) bool { return periods[i].ID < periods[j].ID })
+		for _, period := range periods {
+			// A team is changed when it's restored or renamed, so its periods' rows may have been deleted
+			if !teamChanged && !changedSince(period.LastUpdateTime, options.Since) && !renamedItems[itemKey{team.ID, period.ID}] {
+				continue
+			}
+			if err := exportPeriod(encoders, team.ID, &period); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := encoders[TableDeletions]; ok {
+		return exportDeletions(ctx, store, options, teams, renamed, encoders)
+	}
+	return nil
+}
+
+func needsPeriods(encoders tableEncoders) bool {
+	for table := range encoders {
+		if table != TableTeams && table != TableDeletions {
+			return true
+		}
+	}
+	return false
+}
+
+// exportDeletions writes the teams and periods deleted or renamed since the watermark,
+// in the order they went
+func exportDeletions(ctx context.Context, store storage.StorageService, options Options, teams []models.Team, renamed []models.RenamedItem, encoders tableEncoders) error {
+	deleted, err := store.GetDeletedItems(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve deleted teams and periods: %v", err)
+	}
+	teamsByID := make(map[string]models.Team)
+	for _, team := range teams {
+		teamsByID[team.ID] = team
+	}
+	teamAliases := make(map[string]string)
+	for _, item := range renamed {
+		if item.PeriodID == "" {
+			teamAliases[item.TeamID] = item.NewID
+		}
+	}
+	// findTeam finds the team which a row belongs to, if it still exists, following any renames
+	findTeam := func(teamID string) (models.Team, bool) {
+		for range len(teamAliases) + 1 {
+			if team, ok := teamsByID[teamID]; ok {
+				return team, true
+			}
+			newID, ok := teamAliases[teamID]
+			if !ok {
+				break
+			}
+			teamID = newID
+		}
+		return models.Team{}, false
+	}
+
+	var rows []DeletionRow
+	for _, item := range deleted {
+		if !changedSince(item.Timestamp, options.Since) {
+			continue
+		}
+		// Deleted teams can't change, so their permissions are as they were when they were deleted
+		team, found := models.Team{ID: item.TeamID, Permissions: item.Permissions}, true
+		if item.PeriodID != "" {
+			team, found = findTeam(item.TeamID)
+		}
+		if found && (options.TeamFilter == nil || options.TeamFilter(team)) {
+			rows = append(rows, DeletionRow{TeamID: item.TeamID, PeriodID: item.PeriodID, Reason: ReasonDeleted, Timestamp: item.Timestamp})
+		}
+	}
+	for _, item := range renamed {
+		if !changedSince(item.Timestamp, options.Since) {
+			continue
+		}
+		team, found := findTeam(item.TeamID)
+		if found && (options.TeamFilter == nil || options.TeamFilter(team)) {
+			rows = append(rows, DeletionRow{TeamID: item.TeamID, PeriodID: item.PeriodID, Reason: ReasonRenamed, NewID: item.NewID, Timestamp: item.Timestamp})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Timestamp.Before(rows[j].Timestamp) })
+	for _, row := range rows {
+		if err := encoders.write(TableDeletions, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportPeriod(encoders tableEncoders, teamID string, period *models.Period) error {
+	assignedByPerson := make(map[string]float64)
+	totalAvailability := 0.0
+	for _, person := range period.People {
+		totalAvailability += person.Availability
+	}
+
+	for b, bucket := range period.Buckets {
+		err := encoders.write(TableBuckets, BucketRow{
+			TeamID:               teamID,
+			PeriodID:             period.ID,
+			BucketIndex:          b,
+			DisplayName:          bucket.DisplayName,
+			AllocationType:       bucketAllocationType(bucket),
+			AllocationPercentage: bucket.AllocationPercentage,
+			AllocationAbsolute:   bucket.AllocationAbsolute,
+		})
+		if err != nil {
+			return err
+		}
+		for o, objective := range bucket.Objectives {
+			assigned := 0.0
+			for _, assignment := range objective.Assignments {
+				assigned += assignment.Commitment
+				assignedByPerson[assignment.PersonID] += assignment.Commitment
+				err := encoders.write(TableAssignments, AssignmentRow{
+					TeamID:         teamID,
+					PeriodID:       period.ID,
+					BucketIndex:    b,
+					ObjectiveIndex: o,
+					ObjectiveName:  objective.Name,
+					PersonID:       assignment.PersonID,
+					Commitment:     assignment.Commitment,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			commitmentType := objective.CommitmentType
+			if commitmentType == "" {
+				commitmentType = models.CommitmentTypeAspirational
+			}
+			err := encoders.write(TableObjectives, ObjectiveRow{
+				TeamID:           teamID,
+				PeriodID:         period.ID,
+				BucketIndex:      b,
+				ObjectiveIndex:   o,
+				BucketName:       bucket.DisplayName,
+				Name:             objective.Name,
+				ResourceEstimate: objective.ResourceEstimate,
+				CommitmentType:   commitmentType,
+				Notes:            objective.Notes,
+				BlockID:          objective.BlockID,
+				Assigned:         assigned,
+			})
+			if err != nil {
+				return err
+			}
+			for _, tag := range objective.Tags {
+				err := encoders.write(TableTags, TagRow{
+					TeamID:         teamID,
+					PeriodID:       period.ID,
+					BucketIndex:    b,
+					ObjectiveIndex: o,
+					Tag:            tag.Name,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			for _, group := range objective.Groups {
+				err := encoders.write(TableGroups, GroupRow{
+					TeamID:         teamID,
+					PeriodID:       period.ID,
+					BucketIndex:    b,
+					ObjectiveIndex: o,
+					GroupType:      group.GroupType,
+					GroupName:      group.GroupName,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, person := range period.People {
+		err := encoders.write(TablePeople, PersonRow{
+			TeamID:       teamID,
+			PeriodID:     period.ID,
+			PersonID:     person.ID,
+			DisplayName:  person.DisplayName,
+			Location:     person.Location,
+			Availability: person.Availability,
+			Assigned:     assignedByPerson[person.ID],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoders.write(TablePeriods, PeriodRow{
+		TeamID:                 teamID,
+		PeriodID:               period.ID,
+		DisplayName:            period.DisplayName,
+		Unit:                   period.Unit,
+		UnitAbbrev:             period.UnitAbbrev,
+		NotesURL:               period.NotesURL,
+		MaxCommittedPercentage: period.MaxCommittedPercentage,
+		StartDate:              period.StartDate,
+		EndDate:                period.EndDate,
+		TotalAvailability:      totalAvailability,
+		LastUpdateTime:         period.LastUpdateTime,
+	})
+}
+
+func bucketAllocationType(bucket models.Bucket) string {
+	if bucket.AllocationType == "" {
+		return models.AllocationTypePercentage
+	}
+	return bucket.AllocationType
+}
+
+// WatermarkFile is the name of the file written by WriteFiles which records the
+// watermark to pass as Options.Since for the next incremental export.
+const WatermarkFile = "watermark.txt"
+
+// WriteFiles exports all tables to files named <table>.ndjson in dir,
+// and records the time the export started in WatermarkFile.
+func WriteFiles(ctx context.Context, store storage.StorageService, dir string, options Options) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	watermark := time.Now().UTC()
+	writers := make(map[string]io.Writer)
+	var files []*os.File
+	// closeFiles closes each file once, and returns the first error
+	closeFiles := func() error {
+		var firstErr error
+		for _, f := range files {
+			if err := f.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	for _, table := range Tables {
+		f, err := os.Create(filepath.Join(dir, table+".ndjson"))
+		if err != nil {
+			closeFiles()
+			return err
+		}
+		files = append(files, f)
+		writers[table] = f
+	}
+	if err := Export(ctx, store, options, writers); err != nil {
+		closeFiles()
+		return err
+	}
+	if err := closeFiles(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, WatermarkFile), []byte(watermark.Format(time.RFC3339Nano)+"\n"), 0644)
+}
+
+// IsTable indicates whether a given name is one of the exported tables.
+func IsTable(name string) bool {
+	for _, table := range Tables {
+		if table == name {
+			return true
+		}
+	}
+	return false
+}
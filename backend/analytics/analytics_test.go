@@ -0,0 +1,215 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"peoplemath/in_memory_storage"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func makeTestStore(t *testing.T) storage.StorageService {
+	store := in_memory_storage.MakeInMemStore("google.com")
+	ctx := context.Background()
+	period, _, _ := store.GetPeriod(ctx, "team1", "2019q1")
+	period.LastUpdateTime = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.UpdatePeriod(ctx, "team1", period); err != nil {
+		t.Fatalf("UpdatePeriod returned error: %v", err)
+	}
+	return store
+}
+
+func onlyTeam(teamID string) func(team models.Team) bool {
+	return func(team models.Team) bool { return team.ID == teamID }
+}
+
+func exportTables(t *testing.T, store storage.StorageService, options Options) map[string][]string {
+	ctx := context.Background()
+
+	builders := make(map[string]*strings.Builder)
+	writers := make(map[string]io.Writer)
+	for _, table := range Tables {
+		builders[table] = &strings.Builder{}
+		writers[table] = builders[table]
+	}
+	if err := Export(ctx, store, options, writers); err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+	result := make(map[string][]string)
+	for table, b := range builders {
+		for _, line := range strings.Split(b.String(), "\n") {
+			if line != "" {
+				result[table] = append(result[table], line)
+			}
+		}
+	}
+	return result
+}
+
+func TestExport(t *testing.T) {
+	tables := exportTables(t, makeTestStore(t), Options{TeamFilter: onlyTeam("team2")})
+	if diff := cmp.Diff([]string{`{"teamId":"team2","displayName":"Team team2","lastUpdateTime":"0001-01-01T00:00:00Z"}`}, tables[TableTeams]); diff != "" {
+		t.Errorf("Unexpected teams table (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{
+		`{"teamId":"team2","periodId":"2018q4","bucketIndex":0,"objectiveIndex":1,"tag":"tag1"}`,
+		`{"teamId":"team2","periodId":"2018q4","bucketIndex":0,"objectiveIndex":1,"tag":"tag2"}`,
+		`{"teamId":"team2","periodId":"2018q4","bucketIndex":1,"objectiveIndex":2,"tag":"tag2"}`,
+	}, tables[TableTags]); diff != "" {
+		t.Errorf("Unexpected tags table (-want +got):\n%s", diff)
+	}
+	if len(tables[TablePeriods]) != 1 || !strings.Contains(tables[TablePeriods][0], `"totalAvailability":28`) {
+		t.Errorf("Expected one period with total availability, found %v", tables[TablePeriods])
+	}
+
+	var objective ObjectiveRow
+	if err := json.Unmarshal([]byte(tables[TableObjectives][0]), &objective); err != nil {
+		t.Fatalf("Could not parse objective row: %v", err)
+	}
+	expected := ObjectiveRow{
+		TeamID:           "team2",
+		PeriodID:         "2018q4",
+		BucketName:       "First bucket",
+		Name:             "First objective",
+		ResourceEstimate: 10,
+		CommitmentType:   models.CommitmentTypeCommitted,
+		Assigned:         10,
+	}
+	if diff := cmp.Diff(expected, objective, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().String() == ".Notes" || p.Last().String() == ".BlockID"
+	}, cmp.Ignore())); diff != "" {
+		t.Errorf("Unexpected objective row (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportIsStable(t *testing.T) {
+	store := makeTestStore(t)
+	first := exportTables(t, store, Options{})
+	second := exportTables(t, store, Options{})
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Errorf("Export differs between runs (-first +second):\n%s", diff)
+	}
+}
+
+func TestIncrementalExport(t *testing.T) {
+	store := makeTestStore(t)
+	tables := exportTables(t, store, Options{Since: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if len(tables[TableTeams]) != 0 {
+		t.Errorf("Expected unchanged team to be excluded, found %v", tables[TableTeams])
+	}
+	if len(tables[TablePeriods]) != 1 || !strings.Contains(tables[TablePeriods][0], `"periodId":"2019q1"`) {
+		t.Errorf("Expected only the changed period, found %v", tables[TablePeriods])
+	}
+	for _, row := range tables[TableAssignments] {
+		if !strings.Contains(row, `"periodId":"2019q1"`) {
+			t.Errorf("Expected only assignments of the changed period, found %s", row)
+		}
+	}
+
+	tables = exportTables(t, store, Options{Since: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)})
+	for _, table := range Tables {
+		if len(tables[table]) != 0 {
+			t.Errorf("Expected no %s to be exported, found %v", table, tables[table])
+		}
+	}
+}
+
+func TestIncrementalExportDeletions(t *testing.T) {
+	store := makeTestStore(t)
+	ctx := context.Background()
+	since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return since.Add(time.Duration(hours) * time.Hour) }
+	for _, err := range []error{
+		store.DeletePeriod(ctx, "team1", "2020q3", models.Deletion{Timestamp: at(-1)}),
+		store.DeletePeriod(ctx, "team1", "2018q4", models.Deletion{Timestamp: at(1)}),
+		store.RenamePeriod(ctx, "team1", "2019q1", "2019q1b", models.Alias{NewID: "2019q1b", Timestamp: at(2)}),
+		store.RenameTeam(ctx, "team2", "team3", models.Alias{NewID: "team3", Timestamp: at(3)}),
+		store.RenameTeam(ctx, "team3", "team4", models.Alias{NewID: "team4", Timestamp: at(4)}),
+	} {
+		if err != nil {
+			t.Fatalf("Could not change store: %v", err)
+		}
+	}
+
+	tables := exportTables(t, store, Options{Since: since})
+	if diff := cmp.Diff([]string{
+		`{"teamId":"team1","periodId":"2018q4","reason":"deleted","newId":"","timestamp":"2026-06-01T01:00:00Z"}`,
+		`{"teamId":"team1","periodId":"2019q1","reason":"renamed","newId":"2019q1b","timestamp":"2026-06-01T02:00:00Z"}`,
+		`{"teamId":"team2","periodId":"","reason":"renamed","newId":"team3","timestamp":"2026-06-01T03:00:00Z"}`,
+		`{"teamId":"team3","periodId":"","reason":"renamed","newId":"team4","timestamp":"2026-06-01T04:00:00Z"}`,
+	}, tables[TableDeletions]); diff != "" {
+		t.Errorf("Unexpected deletions table (-want +got):\n%s", diff)
+	}
+	// The renamed team and period are exported in full under their new IDs
+	if len(tables[TableTeams]) != 1 || !strings.Contains(tables[TableTeams][0], `"teamId":"team4"`) {
+		t.Errorf("Expected only the renamed team, found %v", tables[TableTeams])
+	}
+	var periods []string
+	for _, line := range tables[TablePeriods] {
+		var row PeriodRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("Could not parse period row: %v", err)
+		}
+		periods = append(periods, row.TeamID+"/"+row.PeriodID)
+	}
+	if diff := cmp.Diff([]string{"team1/2019q1b", "team4/2018q4"}, periods); diff != "" {
+		t.Errorf("Unexpected periods (-want +got):\n%s", diff)
+	}
+
+	// Deletions are filtered by the team they belonged to, which is found after any renames
+	tables = exportTables(t, store, Options{Since: since, TeamFilter: onlyTeam("team4")})
+	if len(tables[TableDeletions]) != 2 || !strings.Contains(tables[TableDeletions][0], `"teamId":"team2"`) {
+		t.Errorf("Expected only the renames of team4, found %v", tables[TableDeletions])
+	}
+}
+
+func TestExportWithFilter(t *testing.T) {
+	tables := exportTables(t, makeTestStore(t), Options{TeamFilter: onlyTeam("nonexistent")})
+	for _, table := range Tables {
+		if len(tables[table]) != 0 {
+			t.Errorf("Expected no %s to be exported, found %v", table, tables[table])
+		}
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := in_memory_storage.MakeInMemStore("google.com")
+	if err := WriteFiles(context.Background(), store, dir, Options{}); err != nil {
+		t.Fatalf("WriteFiles returned error: %v", err)
+	}
+	for _, table := range Tables {
+		if _, err := os.Stat(filepath.Join(dir, table+".ndjson")); err != nil {
+			t.Errorf("Expected file for table %s: %v", table, err)
+		}
+	}
+	watermark, err := os.ReadFile(filepath.Join(dir, WatermarkFile))
+	if err != nil {
+		t.Fatalf("Could not read watermark: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, strings.TrimSpace(string(watermark))); err != nil {
+		t.Errorf("Could not parse watermark %q: %v", string(watermark), err)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive writes a static, browsable HTML copy of the planning data,
+// which can be served from any web server without the application running.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/report"
+	"peoplemath/storage"
+	"sort"
+	"strings"
+)
+
+// Options controls what is written to the archive.
+type Options struct {
+	// TeamFilter, if set, restricts the archive to the teams for which it returns true.
+	TeamFilter func(team models.Team) bool
+}
+
+// ReadableBy returns a team filter which selects the teams a given user is allowed to read.
+func ReadableBy(a auth.Auth, user models.User) func(team models.Team) bool {
+	return func(team models.Team) bool {
+		return a.CanActOnTeam(user, team, auth.ActionRead)
+	}
+}
+
+type link struct {
+	Text string
+	URL  string
+}
+
+type personAssignment struct {
+	Bucket     string
+	Objective  string
+	Markdown   bool
+	Commitment float64
+}
+
+type personPeriod struct {
+	Period       link
+	Unit         string
+	Availability float64
+	Assignments  []personAssignment
+}
+
+type personPage struct {
+	Breadcrumbs []link
+	ID          string
+	Name        string
+	Periods     []personPeriod
+}
+
+type teamPage struct {
+	Breadcrumbs []link
+	Name        string
+	Periods     []link
+	People      []link
+}
+
+type indexPage struct {
+	Teams []link
+}
+
+// fileName maps an ID onto a string which is safe to use both as a file name
+// and as a relative URL, escaping anything other than letters, digits and '-'.
+func fileName(id string) string {
+	var b strings.Builder
+	for _, c := range []byte(id) {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func teamName(team models.Team) string {
+	if team.DisplayName != "" {
+		return team.DisplayName
+	}
+	return team.ID
+}
+
+func periodName(period models.Period) string {
+	if period.DisplayName != "" {
+		return period.DisplayName
+	}
+	return period.ID
+}
+
+func writeFile(path string, render func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := render(f); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+	return f.Close()
+}
+
+// WriteSite writes a static HTML site to dir, with an index of teams, a page per team
+// listing its periods and people, a page per period and a page per person per team.
+// Periods within each team are cross-linked to the pages of the people working on them.
+func WriteSite(ctx context.Context, store storage.StorageService, dir string, options Options) error {
+	teams, err := store.GetAllTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve teams: %v", err)
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].ID < teams[j].ID })
+
+	index := indexPage{}
+	for _, team := range teams {
+		if options.TeamFilter != nil && !options.TeamFilter(team) {
+			continue
+		}
+		if err := writeTeam(ctx, store, dir, team); err != nil {
+			return err
+		}
+		index.Teams = append(index.Teams, link{Text: teamName(team), URL: "teams/" + fileName(team.ID) + "/index.html"})
+	}
+	log.Printf("Archived %d teams to %s", len(index.Teams), dir)
+	return writeFile(filepath.Join(dir, "index.html"), func(w io.Writer) error {
+		return indexTemplate.Execute(w, index)
+	})
+}
+
+func writeTeam(ctx context.Context, store storage.StorageService, dir string, team models.Team) error {
+	periods, found, err := store.GetAllPeriods(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("could not retrieve periods for team '%s': %v", team.ID, err)
+	}
+	if !found {
+		periods = nil
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].ID < periods[j].ID })
+
+	teamDir := filepath.Join(dir, "teams", fileName(team.ID))
+	page := teamPage{
+		Breadcrumbs: []link{{Text: "All teams", URL: "../../index.html"}},
+		Name:        teamName(team),
+	}
+	people := make(map[string]*personPage)
+	personURL := func(personID string) string {
+		return "../people/" + fileName(personID) + ".html"
+	}
+
+	for _, period := range periods {
+		periodFile := fileName(period.ID) + ".html"
+		err := writeFile(filepath.Join(teamDir, "periods", periodFile), func(w io.Writer) error {
+			return report.RenderHTMLWithOptions(w, team, &period, report.HTMLOptions{
+				Breadcrumbs: []report.Link{
+					{Text: "All teams", URL: "../../../index.html"},
+					{Text: teamName(team), URL: "../index.html"},
+				},
+				PersonURL: personURL,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		periodLink := link{Text: periodName(period), URL: "../periods/" + periodFile}
+		page.Periods = append(page.Periods, link{Text: periodLink.Text, URL: "periods/" + periodFile})
+		addPeriodToPeople(people, team, period, periodLink)
+	}
+
+	personIDs := make([]string, 0, len(people))
+	for id := range people {
+		personIDs = append(personIDs, id)
+	}
+	sort.Strings(personIDs)
+	for _, id := range personIDs {
+		person := people[id]
+		page.People = append(page.People, link{Text: person.Name, URL: "people/" + fileName(id) + ".html"})
+		err := writeFile(filepath.Join(teamDir, "people", fileName(id)+".html"), func(w io.Writer) error {
+			return personTemplate.Execute(w, person)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFile(filepath.Join(teamDir, "index.html"), func(w io.Writer) error {
+		return teamTemplate.Execute(w, page)
+	})
+}
+
+func addPeriodToPeople(people map[string]*personPage, team models.Team, period models.Period, periodLink link) {
+	unit := period.UnitAbbrev
+	if unit == "" {
+		unit = period.Unit
+	}
+	entries := make(map[string]*personPeriod)
+	for _, p := range period.People {
+		person, ok := people[p.ID]
+		if !ok {
+			person = &personPage{
+				Breadcrumbs: []link{
+					{Text: "All teams", URL: "../../../index.html"},
+					{Text: teamName(team), URL: "../index.html"},
+				},
+				ID: p.ID,
+			}
+			people[p.ID] = person
+		}
+		// Use the name from the latest period in which the person appears
+		person.Name = p.DisplayName
+		if person.Name == "" {
+			person.Name = p.ID
+		}
+		person.Periods = append(person.Periods, personPeriod{Period: periodLink, Unit: unit, Availability: p.Availability})
+		entries[p.ID] = &person.Periods[len(person.Periods)-1]
+	}
+	for _, bucket := range period.Buckets {
+		for _, objective := range bucket.Objectives {
+			for _, a := range objective.Assignments {
+				if entry, ok := entries[a.PersonID]; ok {
+					entry.Assignments = append(entry.Assignments, personAssignment{
+						Bucket:     bucket.DisplayName,
+						Objective:  objective.Name,
+						Markdown:   objective.DisplayOptions.EnableMarkdown,
+						Commitment: a.Commitment,
+					})
+				}
+			}
+		}
+	}
+}
+
+const pageHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+td.number { text-align: right; }
+</style>
+</head>
+<body>
+`
+
+const breadcrumbs = `{{- if .Breadcrumbs}}
+<nav>{{range $i, $l := .Breadcrumbs}}{{if $i}} &gt; {{end}}<a href="{{$l.URL}}">{{$l.Text}}</a>{{end}}</nav>
+{{- end}}
+`
+
+func makeTemplate(name, body string) *template.Template {
+	t := template.New(name).Funcs(template.FuncMap{
+		"number":     report.FormatNumber,
+		"renderText": report.RenderText,
+	})
+	template.Must(t.New("header").Parse(pageHeader))
+	template.Must(t.New("breadcrumbs").Parse(breadcrumbs))
+	return template.Must(t.Parse(body))
+}
+
+var indexTemplate = makeTemplate("index", `{{template "header" "Teams"}}<h1>Teams</h1>
+<ul>
+{{- range .Teams}}
+<li><a href="{{.URL}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`)
+
+var teamTemplate = makeTemplate("team", `{{template "header" .Name}}{{template "breadcrumbs" .}}<h1>{{.Name}}</h1>
+<h2>Periods</h2>
+<ul>
+{{- range .Periods}}
+<li><a href="{{.URL}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+<h2>People</h2>
+<ul>
+{{- range .People}}
+<li><a href="{{.URL}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`)
+
+var personTemplate = makeTemplate("person", `{{template "header" .Name}}{{template "breadcrumbs" .}}<h1>{{.Name}}</h1>
+{{- range .Periods}}
+<h2><a href="{{.Period.URL}}">{{.Period.Text}}</a></h2>
+<p>Available: {{number .Availability}} {{.Unit}}</p>
+{{- if .Assignments}}
+<table>
+<tr><th>Bucket</th><th>Objective</th><th>Assigned ({{.Unit}})</th></tr>
+{{- range .Assignments}}
+<tr><td>{{.Bucket}}</td><td>{{renderText .Objective .Markdown}}</td><td class="number">{{number .Commitment}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`)
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"peoplemath/auth"
+	"peoplemath/in_memory_storage"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestWriteSite(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.MakeScrubbingWrapper(in_memory_storage.MakeInMemStore("google.com"))
+	if err := WriteSite(context.Background(), store, dir, Options{}); err != nil {
+		t.Fatalf("WriteSite returned error: %v", err)
+	}
+
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if !strings.Contains(index, `<a href="teams/team1/index.html">Team team1</a>`) {
+		t.Errorf("Expected link to team1 in index, found:\n%s", index)
+	}
+
+	team := readFile(t, filepath.Join(dir, "teams", "team1", "index.html"))
+	for _, expected := range []string{
+		`<a href="periods/2018q4.html">2018Q4</a>`,
+		`<a href="people/alice.html">Alice Atkins</a>`,
+	} {
+		if !strings.Contains(team, expected) {
+			t.Errorf("Expected %q in team page, found:\n%s", expected, team)
+		}
+	}
+
+	period := readFile(t, filepath.Join(dir, "teams", "team1", "periods", "2018q4.html"))
+	if !strings.Contains(period, `<a href="../people/alice.html">Alice Atkins</a> (5)`) {
+		t.Errorf("Expected link to person page in period page, found:\n%s", period)
+	}
+
+	person := readFile(t, filepath.Join(dir, "teams", "team1", "people", "alice.html"))
+	for _, expected := range []string{
+		`<a href="../periods/2018q4.html">2018Q4</a>`,
+		`<a href="../periods/2019q1.html">2019Q1</a>`,
+		"<td>First bucket</td><td>First objective</td>",
+	} {
+		if !strings.Contains(person, expected) {
+			t.Errorf("Expected %q in person page, found:\n%s", expected, person)
+		}
+	}
+}
+
+func TestWriteSiteWithFilter(t *testing.T) {
+	dir := t.TempDir()
+	inMemStore := in_memory_storage.MakeInMemStore("google.com")
+	inMemStore.AddAuthTestUsersAndTeam()
+	store := storage.MakeScrubbingWrapper(inMemStore)
+	options := Options{TeamFilter: ReadableBy(auth.OfflineAuth{}, models.User{Email: "userc@domain.com", Domain: "domain.com"})}
+	if err := WriteSite(context.Background(), store, dir, options); err != nil {
+		t.Fatalf("WriteSite returned error: %v", err)
+	}
+
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if !strings.Contains(index, "teams/teamAuthTest/index.html") {
+		t.Errorf("Expected readable team in index, found:\n%s", index)
+	}
+	if strings.Contains(index, "team1") {
+		t.Errorf("Expected unreadable team to be excluded from index, found:\n%s", index)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "teams", "team1")); !os.IsNotExist(err) {
+		t.Errorf("Expected no output for unreadable team, found: %v", err)
+	}
+}
+
+func TestReadableBy(t *testing.T) {
+	writeOnly := models.Permission{Allow: []models.UserMatcher{{Type: models.UserMatcherTypeEmail, ID: "editor@domain.com"}}}
+	team := models.Team{ID: "team", Permissions: models.TeamPermissions{Write: writeOnly}}
+	// Editors and deployment admins can read a team even if they aren't in its read list
+	for _, user := range []models.User{
+		{Email: "editor@domain.com", Domain: "domain.com"},
+		{Email: "admin@domain.com", Domain: "domain.com", IsAdmin: true},
+	} {
+		if !ReadableBy(auth.OfflineAuth{}, user)(team) {
+			t.Errorf("Expected %s to be able to read the team", user.Email)
+		}
+	}
+	if ReadableBy(auth.OfflineAuth{}, models.User{Email: "other@domain.com", Domain: "domain.com"})(team) {
+		t.Error("Expected other@domain.com not to be able to read the team")
+	}
+}
+
+func TestFileName(t *testing.T) {
+	for id, expected := range map[string]string{
+		"2019q1":    "2019q1",
+		"my-team":   "my-team",
+		"..":        "_2e_2e",
+		"a/b":       "a_2fb",
+		"with_u":    "with_5fu",
+		"":          "_",
+		"team name": "team_20name",
+	} {
+		if got := fileName(id); got != expected {
+			t.Errorf("fileName(%q) = %q, expected %q", id, got, expected)
+		}
+	}
+}
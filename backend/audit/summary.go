@@ -0,0 +1,287 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit summarizes changes to teams and periods for the audit log
+package audit
+
+import (
+	"fmt"
+	"peoplemath/models"
+	"reflect"
+	"strconv"
+)
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// summary accumulates descriptions of changes
+type summary []string
+
+func (s *summary) add(format string, args ...interface{}) {
+	*s = append(*s, fmt.Sprintf(format, args...))
+}
+
+func (s *summary) field(name, before, after string) {
+	if before != after {
+		s.add("Changed %s from '%s' to '%s'", name, before, after)
+	}
+}
+
+func (s *summary) number(name string, before, after float64) {
+	if before != after {
+		s.add("Changed %s from %s to %s", name, formatNumber(before), formatNumber(after))
+	}
+}
+
+// SummarizeTeamChange describes the differences between two versions of a team.
+// before is nil for a new team.
+func SummarizeTeamChange(before *models.Team, after *models.Team) []string {
+	s := summary{}
+	if before == nil {
+		s.add("Created team '%s'", after.ID)
+		return s
+	}
+	s.field("display name", before.DisplayName, after.DisplayName)
+	if !reflect.DeepEqual(before.Permissions, after.Permissions) {
+		s.add("Changed permissions")
+	}
+	return s
+}
+
+// SummarizePeriodChange describes the differences between two versions of a period.
+// before is nil for a new period.
+func SummarizePeriodChange(before *models.Period, after *models.Period) []string {
+	s := summary{}
+	if before == nil {
+		s.add("Created period '%s'", after.ID)
+		return s
+	}
+	s.field("display name", before.DisplayName, after.DisplayName)
+	s.field("unit", before.Unit, after.Unit)
+	s.field("unit abbreviation", before.UnitAbbrev, after.UnitAbbrev)
+	s.field("notes URL", before.NotesURL, after.NotesURL)
+	s.number("maximum committed percentage", before.MaxCommittedPercentage, after.MaxCommittedPercentage)
+	s.field("start date", before.StartDate, after.StartDate)
+	s.field("end date", before.EndDate, after.EndDate)
+	s.field("state", before.LifecycleState(), after.LifecycleState())
+	if !reflect.DeepEqual(before.SecondaryUnits, after.SecondaryUnits) {
+		s.add("Changed secondary units")
+	}
+	s.people(before.People, after.People)
+	s.buckets(before.Buckets, after.Buckets)
+	s.review(before.Review, after.Review)
+	return s
+}
+
+// SummarizeTemplatesChange describes the differences between two versions of a team's templates
+func SummarizeTemplatesChange(before, after []models.PeriodTemplate) []string {
+	s := summary{}
+	beforeByID := make(map[string]models.PeriodTemplate)
+	for _, t := range before {
+		beforeByID[t.ID] = t
+	}
+	afterIDs := make(map[string]bool)
+	for _, t := range after {
+		afterIDs[t.ID] = true
+		if old, ok := beforeByID[t.ID]; !ok {
+			s.add("Added template '%s'", t.ID)
+		} else if !reflect.DeepEqual(old, t) {
+			s.add("Changed template '%s'", t.ID)
+		}
+	}
+	for _, t := range before {
+		if !afterIDs[t.ID] {
+			s.add("Removed template '%s'", t.ID)
+		}
+	}
+	return s
+}
+
+// SummarizeSettingsChange describes the differences between two versions of the settings
+func SummarizeSettingsChange(before, after *models.Settings) []string {
+	s := summary{}
+	s.field("improve URL", before.ImproveURL, after.ImproveURL)
+	for _, p := range []struct {
+		name          string
+		before, after models.Permission
+	}{
+		{"team list read", before.GeneralPermissions.ReadTeamList, after.GeneralPermissions.ReadTeamList},
+		{"add team", before.GeneralPermissions.AddTeam, after.GeneralPermissions.AddTeam},
+		{"administer", before.GeneralPermissions.Administer, after.GeneralPermissions.Administer},
+		{"deployment admin", before.Admins, after.Admins},
+	} {
+		// Loaded settings have empty lists where saved ones may have nil
+		if !reflect.DeepEqual(p.before, p.after) && (len(p.before.Allow) > 0 || len(p.after.Allow) > 0) {
+			s.add("Changed %s permissions", p.name)
+		}
+	}
+	s = append(s, SummarizeTemplatesChange(before.PeriodTemplates, after.PeriodTemplates)...)
+	if !reflect.DeepEqual(before.GetBackupRetention(), after.GetBackupRetention()) {
+		s.add("Changed backup retention")
+	}
+	if !reflect.DeepEqual(before.GetVersionCompaction(), after.GetVersionCompaction()) {
+		s.add("Changed version compaction")
+	}
+	s.number("deletion grace period in days", float64(before.DeletionGraceDays), float64(after.DeletionGraceDays))
+	return s
+}
+
+func (s *summary) people(before, after []models.Person) {
+	beforeByID := make(map[string]models.Person)
+	for _, p := range before {
+		beforeByID[p.ID] = p
+	}
+	afterIDs := make(map[string]bool)
+	for _, p := range after {
+		afterIDs[p.ID] = true
+		old, ok := beforeByID[p.ID]
+		if !ok {
+			s.add("Added person '%s'", p.ID)
+			continue
+		}
+		s.field("display name of person '"+p.ID+"'", old.DisplayName, p.DisplayName)
+		s.field("location of person '"+p.ID+"'", old.Location, p.Location)
+		s.number("availability of person '"+p.ID+"'", old.Availability, p.Availability)
+	}
+	for _, p := range before {
+		if !afterIDs[p.ID] {
+			s.add("Removed person '%s'", p.ID)
+		}
+	}
+}
+
+// objectiveLocation identifies an objective by name and the bucket it is in
+type objectiveLocation struct {
+	bucket    string
+	objective models.Objective
+}
+
+func objectivesByName(buckets []models.Bucket) map[string]objectiveLocation {
+	result := make(map[string]objectiveLocation)
+	for _, b := range buckets {
+		for _, o := range b.Objectives {
+			if _, dup := result[o.Name]; !dup {
+				result[o.Name] = objectiveLocation{bucket: b.DisplayName, objective: o}
+			}
+		}
+	}
+	return result
+}
+
+func (s *summary) buckets(before, after []models.Bucket) {
+	beforeByName := make(map[string]models.Bucket)
+	for _, b := range before {
+		beforeByName[b.DisplayName] = b
+	}
+	afterNames := make(map[string]bool)
+	for _, b := range after {
+		afterNames[b.DisplayName] = true
+		old, ok := beforeByName[b.DisplayName]
+		if !ok {
+			s.add("Added bucket '%s'", b.DisplayName)
+			continue
+		}
+		s.field("allocation type of bucket '"+b.DisplayName+"'", old.AllocationType, b.AllocationType)
+		s.number("allocation percentage of bucket '"+b.DisplayName+"'", old.AllocationPercentage, b.AllocationPercentage)
+		s.number("allocation of bucket '"+b.DisplayName+"'", old.AllocationAbsolute, b.AllocationAbsolute)
+	}
+	for _, b := range before {
+		if !afterNames[b.DisplayName] {
+			s.add("Removed bucket '%s'", b.DisplayName)
+		}
+	}
+
+	// Objectives are matched by name, so that moves between buckets can be recognized
+	beforeObjectives := objectivesByName(before)
+	afterObjectives := objectivesByName(after)
+	for _, b := range after {
+		for _, o := range b.Objectives {
+			loc := afterObjectives[o.Name]
+			if loc.bucket != b.DisplayName {
+				// Duplicate name, already handled
+				continue
+			}
+			old, ok := beforeObjectives[o.Name]
+			if !ok {
+				s.add("Added objective '%s' to bucket '%s'", o.Name, b.DisplayName)
+				continue
+			}
+			if old.bucket != b.DisplayName {
+				s.add("Moved objective '%s' from bucket '%s' to '%s'", o.Name, old.bucket, b.DisplayName)
+			}
+			s.objective(old.objective, o)
+		}
+	}
+	for _, b := range before {
+		for _, o := range b.Objectives {
+			if _, ok := afterObjectives[o.Name]; !ok {
+				s.add("Removed objective '%s' from bucket '%s'", o.Name, b.DisplayName)
+			}
+		}
+	}
+}
+
+func (s *summary) objective(before, after models.Objective) {
+	name := "objective '" + after.Name + "'"
+	s.number("resource estimate of "+name, before.ResourceEstimate, after.ResourceEstimate)
+	s.field("commitment type of "+name, before.CommitmentType, after.CommitmentType)
+	if before.Notes != after.Notes {
+		s.add("Changed notes of %s", name)
+	}
+	if !reflect.DeepEqual(before.Groups, after.Groups) || !reflect.DeepEqual(before.Tags, after.Tags) {
+		s.add("Changed groups or tags of %s", name)
+	}
+	beforeCommitments := make(map[string]float64)
+	for _, a := range before.Assignments {
+		beforeCommitments[a.PersonID] += a.Commitment
+	}
+	afterCommitments := make(map[string]float64)
+	for _, a := range after.Assignments {
+		afterCommitments[a.PersonID] += a.Commitment
+	}
+	for _, a := range after.Assignments {
+		old, ok := beforeCommitments[a.PersonID]
+		if !ok {
+			s.add("Assigned '%s' to %s (%s)", a.PersonID, name, formatNumber(afterCommitments[a.PersonID]))
+		} else if old != afterCommitments[a.PersonID] {
+			s.add("Changed assignment of '%s' to %s from %s to %s", a.PersonID, name, formatNumber(old), formatNumber(afterCommitments[a.PersonID]))
+		}
+		// Only report each person once
+		beforeCommitments[a.PersonID] = afterCommitments[a.PersonID]
+	}
+	for _, a := range before.Assignments {
+		if _, ok := afterCommitments[a.PersonID]; !ok {
+			s.add("Unassigned '%s' from %s", a.PersonID, name)
+			afterCommitments[a.PersonID] = 0
+		}
+	}
+}
+
+func (s *summary) review(before, after *models.Review) {
+	if after == nil || (before != nil && len(before.Events) == len(after.Events)) {
+		return
+	}
+	start := 0
+	if before != nil {
+		start = len(before.Events)
+	}
+	for _, event := range after.Events[start:] {
+		if event.Comment != "" {
+			s.add("Review %s: %s", event.Type, event.Comment)
+		} else {
+			s.add("Review %s", event.Type)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"peoplemath/models"
+	"reflect"
+	"testing"
+)
+
+func makePeriod() *models.Period {
+	return &models.Period{
+		ID:          "2026q1",
+		DisplayName: "2026 Q1",
+		People: []models.Person{
+			{ID: "alice", Availability: 10},
+			{ID: "bob", Availability: 10},
+		},
+		Buckets: []models.Bucket{
+			{
+				DisplayName:          "First",
+				AllocationPercentage: 60,
+				Objectives: []models.Objective{
+					{Name: "Launch", ResourceEstimate: 8, Assignments: []models.Assignment{{PersonID: "alice", Commitment: 5}}},
+					{Name: "Docs", ResourceEstimate: 2},
+				},
+			},
+			{
+				DisplayName:          "Second",
+				AllocationPercentage: 40,
+				Objectives: []models.Objective{
+					{Name: "Support", ResourceEstimate: 4, Assignments: []models.Assignment{{PersonID: "bob", Commitment: 4}}},
+				},
+			},
+		},
+	}
+}
+
+func TestSummarizePeriodChange(t *testing.T) {
+	before := makePeriod()
+	if summary := SummarizePeriodChange(before, makePeriod()); len(summary) != 0 {
+		t.Errorf("Expected no changes, found %v", summary)
+	}
+
+	after := makePeriod()
+	after.DisplayName = "First quarter"
+	after.People = append(after.People[1:], models.Person{ID: "carol", Availability: 5})
+	after.Buckets[0].Objectives[0].ResourceEstimate = 10
+	after.Buckets[0].Objectives[0].Assignments = []models.Assignment{{PersonID: "alice", Commitment: 3}, {PersonID: "carol", Commitment: 5}}
+	after.Buckets[1].Objectives = append(after.Buckets[1].Objectives, after.Buckets[0].Objectives[1])
+	after.Buckets[0].Objectives = after.Buckets[0].Objectives[:1]
+	after.Buckets[1].Objectives[0].Assignments = nil
+	after.Buckets[1].Objectives[0].Name = "Operations"
+
+	expected := []string{
+		"Changed display name from '2026 Q1' to 'First quarter'",
+		"Added person 'carol'",
+		"Removed person 'alice'",
+		"Changed resource estimate of objective 'Launch' from 8 to 10",
+		"Changed assignment of 'alice' to objective 'Launch' from 5 to 3",
+		"Assigned 'carol' to objective 'Launch' (5)",
+		"Added objective 'Operations' to bucket 'Second'",
+		"Moved objective 'Docs' from bucket 'First' to 'Second'",
+		"Removed objective 'Support' from bucket 'Second'",
+	}
+	if summary := SummarizePeriodChange(before, after); !reflect.DeepEqual(expected, summary) {
+		t.Errorf("Expected summary %q, found %q", expected, summary)
+	}
+}
+
+func TestSummarizeNew(t *testing.T) {
+	if summary := SummarizePeriodChange(nil, makePeriod()); !reflect.DeepEqual([]string{"Created period '2026q1'"}, summary) {
+		t.Errorf("Unexpected summary of new period %v", summary)
+	}
+	if summary := SummarizeTeamChange(nil, &models.Team{ID: "t"}); !reflect.DeepEqual([]string{"Created team 't'"}, summary) {
+		t.Errorf("Unexpected summary of new team %v", summary)
+	}
+}
+
+func TestSummarizeTemplatesChange(t *testing.T) {
+	before := []models.PeriodTemplate{{ID: "a"}, {ID: "b", Unit: "weeks"}}
+	after := []models.PeriodTemplate{{ID: "b", Unit: "days"}, {ID: "c"}}
+	expected := []string{"Changed template 'b'", "Added template 'c'", "Removed template 'a'"}
+	if summary := SummarizeTemplatesChange(before, after); !reflect.DeepEqual(expected, summary) {
+		t.Errorf("Expected summary %q, found %q", expected, summary)
+	}
+}
+
+func TestSummarizeSettingsChange(t *testing.T) {
+	before := &models.Settings{ImproveURL: "https://example.com/a"}
+	after := &models.Settings{
+		ImproveURL:         "https://example.com/b",
+		GeneralPermissions: models.GeneralPermissions{Administer: models.Permission{Allow: []models.UserMatcher{{Type: "Email", ID: "a@b.com"}}}},
+		PeriodTemplates:    []models.PeriodTemplate{{ID: "t"}},
+		BackupRetention:    &models.DefaultBackupRetention,
+		DeletionGraceDays:  7,
+	}
+	expected := []string{
+		"Changed improve URL from 'https://example.com/a' to 'https://example.com/b'",
+		"Changed administer permissions",
+		"Added template 't'",
+		"Changed deletion grace period in days from 0 to 7",
+	}
+	if summary := SummarizeSettingsChange(before, after); !reflect.DeepEqual(expected, summary) {
+		t.Errorf("Expected summary %q, found %q", expected, summary)
+	}
+}
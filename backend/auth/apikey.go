@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/models"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader is the header which scripts send their API key in
+const APIKeyHeader = "X-API-Key"
+
+// APIKeySource looks up API keys by their ID
+type APIKeySource interface {
+	GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error)
+}
+
+// NewAPIKeySecret generates a random secret for an API key, and returns it with the hash to store.
+// The key sent to scripts is made by APIKeyToken.
+func NewAPIKeySecret() (secret, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("could not generate API key secret: %v", err)
+	}
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	return secret, hashAPIKeySecret(secret), nil
+}
+
+// APIKeyToken combines an API key's ID and secret into the value scripts send in the APIKeyHeader
+func APIKeyToken(keyID, secret string) string {
+	return keyID + "." + secret
+}
+
+// The secret is random and long, so a fast hash is enough to stop a copy of the storage
+// being used to authenticate
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuth is a wrapper for any Auth implementation which also accepts API keys, sent in
+// the APIKeyHeader. Requests without one are authenticated by the wrapped Auth. API keys
+// can read or write only the teams they were created for, and can't do anything else.
+type APIKeyAuth struct {
+	Auth
+	Keys        APIKeySource
+	AuthTimeout time.Duration
+}
+
+// requestVerifier is implemented by an Auth which has to check where every request came from,
+// such as one behind an authenticating proxy. API keys are only accepted from requests which
+// pass the check, so that they can't be used to get around it.
+type requestVerifier interface {
+	verifyRequest(r *http.Request) error
+}
+
+func (auth *APIKeyAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	authenticateUser := auth.Auth.Authenticate(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(APIKeyHeader)
+		if token == "" {
+			authenticateUser(w, r)
+			return
+		}
+		if verifier, ok := auth.Auth.(requestVerifier); ok {
+			if err := verifier.verifyRequest(r); err != nil {
+				log.Printf("API key request refused: %v", err)
+				http.Error(w, "API key authentication failed", http.StatusUnauthorized)
+				return
+			}
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), auth.AuthTimeout)
+		defer cancel()
+		key, err := auth.verify(ctx, token)
+		if err != nil {
+			log.Printf("API key authentication failed: %v", err)
+			http.Error(w, "API key authentication failed", http.StatusUnauthorized)
+			return
+		}
+		key.SecretHash = ""
+		user := models.User{APIKey: &key}
+
+		ctxWithUser := context.WithValue(r.Context(), ContextKey("user"), user)
+		next(w, r.WithContext(ctxWithUser))
+	}
+}
+
+func (auth *APIKeyAuth) verify(ctx context.Context, token string) (models.APIKey, error) {
+	keyID, secret, ok := strings.Cut(token, ".")
+	if !ok {
+		return models.APIKey{}, fmt.Errorf("malformed API key")
+	}
+	key, found, err := auth.Keys.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("could not look up API key '%s': %v", keyID, err)
+	}
+	if !found {
+		return models.APIKey{}, fmt.Errorf("no API key '%s'", keyID)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.SecretHash)) != 1 {
+		return models.APIKey{}, fmt.Errorf("wrong secret for API key '%s'", key.Name)
+	}
+	return key, nil
+}
+
+func (auth *APIKeyAuth) CanActOnTeam(user models.User, team models.Team, action string) bool {
+	if user.APIKey == nil {
+		return auth.Auth.CanActOnTeam(user, team, action)
+	}
+	access := user.APIKey.TeamAccess(team.ID)
+	switch action {
+	case ActionRead:
+		return access == models.APIKeyAccessRead || access == models.APIKeyAccessWrite
+	case ActionWrite:
+		return access == models.APIKeyAccessWrite
+	default:
+		return false
+	}
+}
+
+func (auth *APIKeyAuth) CanActOnTeamList(user models.User, generalPermissions models.GeneralPermissions, action string) bool {
+	if user.APIKey == nil {
+		return auth.Auth.CanActOnTeamList(user, generalPermissions, action)
+	}
+	return false
+}
+
+func (auth *APIKeyAuth) IsPermitted(user models.User, allow []models.UserMatcher) bool {
+	if user.APIKey == nil {
+		return auth.Auth.IsPermitted(user, allow)
+	}
+	return false
+}
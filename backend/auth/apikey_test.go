@@ -0,0 +1,119 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"peoplemath/models"
+	"testing"
+	"time"
+)
+
+type apiKeySourceStub map[string]models.APIKey
+
+func (s apiKeySourceStub) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error) {
+	key, found := s[keyID]
+	return key, found, nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	secret, hash, err := NewAPIKeySecret()
+	if err != nil {
+		t.Fatalf("Could not generate secret: %v", err)
+	}
+	key := models.APIKey{ID: "k1", Name: "bot", SecretHash: hash, Teams: []models.APIKeyTeam{
+		{TeamID: "writable", Access: models.APIKeyAccessWrite},
+		{TeamID: "readable", Access: models.APIKeyAccessRead},
+	}}
+	// Users who sign in are checked by the wrapped Auth, which here lets nobody do anything
+	keyAuth := &APIKeyAuth{Auth: &OIDCAuth{}, Keys: apiKeySourceStub{"k1": key}, AuthTimeout: time.Second}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/team/writable", nil)
+	req.Header.Set(APIKeyHeader, APIKeyToken("k1", secret))
+	status, user := serve(keyAuth, req)
+	if status != http.StatusOK {
+		t.Fatalf("Expected the API key to be accepted, got status %d", status)
+	}
+	if user.Identity() != "apikey:bot" || user.APIKey.SecretHash != "" {
+		t.Errorf("Unexpected API key user %+v", user)
+	}
+	for _, tc := range []struct {
+		teamID, action string
+		allowed        bool
+	}{
+		{"writable", ActionRead, true},
+		{"writable", ActionWrite, true},
+		{"writable", ActionAdmin, false},
+		{"writable", ActionChangePermissions, false},
+		{"readable", ActionRead, true},
+		{"readable", ActionWrite, false},
+		{"other", ActionRead, false},
+	} {
+		if allowed := keyAuth.CanActOnTeam(user, models.Team{ID: tc.teamID}, tc.action); allowed != tc.allowed {
+			t.Errorf("Expected CanActOnTeam(%s, %s) to be %v, got %v", tc.teamID, tc.action, tc.allowed, allowed)
+		}
+	}
+	everyone := []models.UserMatcher{{Type: models.UserMatcherTypeDomain, ID: ""}}
+	if keyAuth.IsPermitted(user, everyone) || keyAuth.CanActOnTeamList(user, models.GeneralPermissions{}, ActionRead) {
+		t.Error("Expected an API key to have no permissions beyond its teams")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/team/writable", nil)
+	req.Header.Set(APIKeyHeader, APIKeyToken("k1", "wrong"))
+	if status, _ := serve(keyAuth, req); status != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong secret to be refused, got status %d", status)
+	}
+	// Requests without an API key are authenticated by the wrapped Auth
+	req = httptest.NewRequest(http.MethodGet, "/api/team/writable", nil)
+	if status, _ := serve(keyAuth, req); status != http.StatusUnauthorized {
+		t.Errorf("Expected a request without a key to be passed to the wrapped Auth, got status %d", status)
+	}
+	if keyAuth.CanActOnTeam(models.User{Email: "alice@example.com"}, models.Team{ID: "writable"}, ActionRead) {
+		t.Error("Expected a signed in user to be checked by the wrapped Auth")
+	}
+}
+
+func TestAPIKeyAuthBehindProxy(t *testing.T) {
+	secret, hash, err := NewAPIKeySecret()
+	if err != nil {
+		t.Fatalf("Could not generate secret: %v", err)
+	}
+	key := models.APIKey{ID: "k1", Name: "bot", SecretHash: hash}
+	proxyAuth, err := MakeProxyAuth(ProxyConfig{
+		Header:         iapHeader,
+		HeaderPrefix:   iapPrefix,
+		TrustedProxies: []string{"10.0.0.0/8"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Could not make proxy auth: %v", err)
+	}
+	keyAuth := &APIKeyAuth{Auth: proxyAuth, Keys: apiKeySourceStub{"k1": key}, AuthTimeout: time.Second}
+	headers := map[string]string{iapHeader: iapPrefix + "bot@example.com", APIKeyHeader: APIKeyToken("k1", secret)}
+
+	status, user := serve(keyAuth, proxyRequest("10.1.2.3:4567", headers))
+	if status != http.StatusOK || user.Identity() != "apikey:bot" {
+		t.Errorf("Expected the API key to be accepted through the proxy, got status %d and user %+v", status, user)
+	}
+	// Going to the server directly, a valid key isn't enough
+	if status, _ := serve(keyAuth, proxyRequest("192.0.2.8:80", headers)); status != http.StatusUnauthorized {
+		t.Errorf("Expected an API key request bypassing the proxy to be refused, got status %d", status)
+	}
+	delete(headers, iapHeader)
+	if status, _ := serve(keyAuth, proxyRequest("10.1.2.3:4567", headers)); status != http.StatusUnauthorized {
+		t.Errorf("Expected an API key request with no proxy identity to be refused, got status %d", status)
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2020 Google LLC
+// Copyright 2020, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -16,9 +16,12 @@ package auth
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"peoplemath/models"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Auth is the abstraction for permissions functionality
@@ -32,13 +35,74 @@ type Auth interface {
 	// CanActOnTeamList indicates whether an authenticated user is allowed to perform
 	// a given action on the team list
 	CanActOnTeamList(user models.User, generalPermissions models.GeneralPermissions, action string) bool
+	// IsPermitted indicates whether an authenticated user matches any of a list of
+	// user matchers, such as the reviewers of a period
+	IsPermitted(user models.User, allow []models.UserMatcher) bool
 }
 
 const (
 	ActionRead  = "read"
 	ActionWrite = "write"
+	ActionAdmin = "admin"
+	// Changing a team's permissions, which only its owners may do
+	ActionChangePermissions = "changePermissions"
 )
 
+// SettingsSource provides the settings, which list the deployment admins
+type SettingsSource interface {
+	GetSettings(ctx context.Context) (models.Settings, error)
+}
+
+// CachedSettings is a SettingsSource which keeps the settings it reads for the TTL, so that
+// checking whether each authenticated user is a deployment admin doesn't read them every time
+type CachedSettings struct {
+	Source SettingsSource
+	TTL    time.Duration
+
+	mu       sync.Mutex
+	settings models.Settings
+	expiry   time.Time
+}
+
+func (c *CachedSettings) GetSettings(ctx context.Context) (models.Settings, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expiry) {
+		return c.settings, nil
+	}
+	settings, err := c.Source.GetSettings(ctx)
+	if err != nil {
+		return settings, err
+	}
+	c.settings, c.expiry = settings, time.Now().Add(c.TTL)
+	return settings, nil
+}
+
+// isDeploymentAdmin checks whether a newly authenticated user is one of the deployment admins.
+// If the settings can't be retrieved, the user is treated as not being one.
+func isDeploymentAdmin(ctx context.Context, settings SettingsSource, user models.User) bool {
+	if settings == nil {
+		return false
+	}
+	s, err := settings.GetSettings(ctx)
+	if err != nil {
+		log.Printf("WARNING: Could not retrieve settings to check whether '%s' is an admin: %v", user.Email, err)
+		return false
+	}
+	return user.IsPermitted(s.Admins.Allow)
+}
+
+// adminOverride checks whether a deployment admin can read or write something, or fix a team's
+// permissions, only because they are an admin, and logs each time this happens so that overrides
+// can be reviewed
+func adminOverride(user models.User, action, description string) bool {
+	if !user.IsAdmin || (action != ActionRead && action != ActionWrite && action != ActionChangePermissions) {
+		return false
+	}
+	log.Printf("ADMIN OVERRIDE: '%s' allowed to %s %s as a deployment admin", user.Email, action, description)
+	return true
+}
+
 // ContextKey is a context key type to avoid collisions between packages using contexts
 type ContextKey string
 
@@ -60,6 +124,23 @@ func (auth NoAuth) CanActOnTeamList(user models.User, generalPermissions models.
 	return true
 }
 
+func (auth NoAuth) IsPermitted(user models.User, allow []models.UserMatcher) bool {
+	return true
+}
+
+// OfflineAuth is an Auth implementation for tools which check permissions without serving
+// requests, such as the archiver. It has the permissions checks of the Authorizer, but
+// authenticates nobody.
+type OfflineAuth struct {
+	Authorizer
+}
+
+func (auth OfflineAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Authentication is not available", http.StatusUnauthorized)
+	}
+}
+
 func getDomain(email string) string {
 	emailParts := strings.Split(email, "@")
 	return emailParts[len(emailParts)-1]
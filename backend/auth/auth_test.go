@@ -0,0 +1,61 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"peoplemath/models"
+	"testing"
+	"time"
+)
+
+type settingsSourceStub struct {
+	settings models.Settings
+	err      error
+	reads    int
+}
+
+func (s *settingsSourceStub) GetSettings(ctx context.Context) (models.Settings, error) {
+	s.reads++
+	return s.settings, s.err
+}
+
+func TestCachedSettings(t *testing.T) {
+	ctx := context.Background()
+	source := &settingsSourceStub{err: fmt.Errorf("unavailable")}
+	cache := &CachedSettings{Source: source, TTL: time.Hour}
+	if _, err := cache.GetSettings(ctx); err == nil {
+		t.Error("Expected the error reading the settings")
+	}
+
+	// Errors aren't cached
+	source.err = nil
+	source.settings.ImproveURL = "https://example.com/first"
+	for i := 0; i < 3; i++ {
+		if settings, err := cache.GetSettings(ctx); err != nil || settings.ImproveURL != "https://example.com/first" {
+			t.Errorf("Expected the first settings, found %v, %v", settings, err)
+		}
+	}
+	if source.reads != 2 {
+		t.Errorf("Expected the settings to be read once and then cached, found %d reads", source.reads)
+	}
+
+	source.settings.ImproveURL = "https://example.com/second"
+	cache.expiry = time.Now()
+	if settings, _ := cache.GetSettings(ctx); settings.ImproveURL != "https://example.com/second" {
+		t.Errorf("Expected the settings to be read again once expired, found %v", settings)
+	}
+}
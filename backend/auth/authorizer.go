@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"log"
+	"peoplemath/models"
+)
+
+// Role is the level of access a user has on a team. Each role includes
+// everything the roles below it allow.
+type Role int
+
+const (
+	RoleNone Role = iota
+	// Can view the team and all of its periods
+	RoleViewer
+	// Can also make changes to the team and its periods
+	RoleEditor
+	// Can also perform elevated actions, and change the team's permissions
+	RoleOwner
+)
+
+func (role Role) String() string {
+	switch role {
+	case RoleViewer:
+		return "viewer"
+	case RoleEditor:
+		return "editor"
+	case RoleOwner:
+		return "owner"
+	default:
+		return "none"
+	}
+}
+
+// teamActionRoles is the role needed for each action on a team
+var teamActionRoles = map[string]Role{
+	ActionRead:              RoleViewer,
+	ActionWrite:             RoleEditor,
+	ActionAdmin:             RoleOwner,
+	ActionChangePermissions: RoleOwner,
+}
+
+// Authorizer performs the permissions checks of the Auth interface, separately from
+// authenticating the user. Any Auth implementation can embed it to get them.
+// The zero value is ready to use.
+type Authorizer struct{}
+
+// TeamRole returns the highest role which the team's permissions give the user.
+// The read, write and admin permissions make the user a viewer, editor and owner respectively.
+func (a Authorizer) TeamRole(user models.User, team models.Team) Role {
+	switch {
+	case user.IsPermitted(team.Permissions.Admin.Allow):
+		return RoleOwner
+	case user.IsPermitted(team.Permissions.Write.Allow):
+		return RoleEditor
+	case user.IsPermitted(team.Permissions.Read.Allow):
+		return RoleViewer
+	default:
+		return RoleNone
+	}
+}
+
+func (a Authorizer) CanActOnTeam(user models.User, team models.Team, action string) bool {
+	required, ok := teamActionRoles[action]
+	if !ok {
+		log.Printf("WARNING: Denying unknown action '%s' on team '%s'", action, team.ID)
+		return false
+	}
+	role := a.TeamRole(user, team)
+	// Teams created before there were owners have no admin permission, so their editors
+	// can change the permissions, to appoint some
+	if action == ActionChangePermissions && len(team.Permissions.Admin.Allow) == 0 && role == RoleEditor {
+		return true
+	}
+	return role >= required || adminOverride(user, action, fmt.Sprintf("team '%s'", team.ID))
+}
+
+func (a Authorizer) CanActOnTeamList(user models.User, generalPermissions models.GeneralPermissions, action string) bool {
+	var permissions []models.UserMatcher
+	switch action {
+	case ActionRead:
+		permissions = generalPermissions.ReadTeamList.Allow
+	case ActionWrite:
+		permissions = generalPermissions.AddTeam.Allow
+	case ActionAdmin:
+		permissions = generalPermissions.Administer.Allow
+	default:
+		log.Printf("WARNING: Denying unknown action '%s' on the team list", action)
+		return false
+	}
+	return user.IsPermitted(permissions) || adminOverride(user, action, "the team list")
+}
+
+func (a Authorizer) IsPermitted(user models.User, allow []models.UserMatcher) bool {
+	return user.IsPermitted(allow)
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"peoplemath/models"
+	"testing"
+)
+
+func emailPermission(email string) models.Permission {
+	return models.Permission{Allow: []models.UserMatcher{{Type: models.UserMatcherTypeEmail, ID: email}}}
+}
+
+func TestAuthorizerRoles(t *testing.T) {
+	team := models.Team{ID: "team", Permissions: models.TeamPermissions{
+		Read:  emailPermission("viewer@example.com"),
+		Write: emailPermission("editor@example.com"),
+		Admin: emailPermission("owner@example.com"),
+	}}
+	var a Authorizer
+	for email, expected := range map[string]struct {
+		role    Role
+		allowed map[string]bool
+	}{
+		"viewer@example.com": {RoleViewer, map[string]bool{ActionRead: true}},
+		"editor@example.com": {RoleEditor, map[string]bool{ActionRead: true, ActionWrite: true}},
+		"owner@example.com":  {RoleOwner, map[string]bool{ActionRead: true, ActionWrite: true, ActionAdmin: true, ActionChangePermissions: true}},
+		"other@example.com":  {RoleNone, map[string]bool{}},
+	} {
+		user := models.User{Email: email, Domain: "example.com"}
+		if role := a.TeamRole(user, team); role != expected.role {
+			t.Errorf("Expected %s to be %v, got %v", email, expected.role, role)
+		}
+		for _, action := range []string{ActionRead, ActionWrite, ActionAdmin, ActionChangePermissions, "unknown"} {
+			if allowed := a.CanActOnTeam(user, team, action); allowed != expected.allowed[action] {
+				t.Errorf("Expected CanActOnTeam(%s, %s) to be %v, got %v", email, action, expected.allowed[action], allowed)
+			}
+		}
+	}
+}
+
+func TestAuthorizerTeamWithoutOwners(t *testing.T) {
+	// As saved before the admin permission existed
+	team := models.Team{ID: "team", Permissions: models.TeamPermissions{
+		Read:  emailPermission("viewer@example.com"),
+		Write: emailPermission("editor@example.com"),
+	}}
+	var a Authorizer
+	editor := models.User{Email: "editor@example.com", Domain: "example.com"}
+	if !a.CanActOnTeam(editor, team, ActionChangePermissions) {
+		t.Error("Expected an editor to be able to appoint owners of a team which has none")
+	}
+	if a.CanActOnTeam(editor, team, ActionAdmin) {
+		t.Error("Expected an editor of a team without owners to have no other owner permissions")
+	}
+	viewer := models.User{Email: "viewer@example.com", Domain: "example.com"}
+	if a.CanActOnTeam(viewer, team, ActionChangePermissions) {
+		t.Error("Expected a viewer to be unable to change the permissions of a team without owners")
+	}
+}
+
+func TestAuthorizerDeniesUnknownActions(t *testing.T) {
+	var a Authorizer
+	// Even deployment admins are denied actions the authorizer doesn't know about
+	user := models.User{Email: "admin@example.com", Domain: "example.com", IsAdmin: true}
+	everyone := models.Permission{Allow: []models.UserMatcher{{Type: models.UserMatcherTypeDomain, ID: "example.com"}}}
+	team := models.Team{ID: "team", Permissions: models.TeamPermissions{Read: everyone, Write: everyone, Admin: everyone}}
+	if a.CanActOnTeam(user, team, "delete") {
+		t.Error("Expected an unknown action on a team to be denied")
+	}
+	general := models.GeneralPermissions{ReadTeamList: everyone, AddTeam: everyone, Administer: everyone}
+	if a.CanActOnTeamList(user, general, "delete") {
+		t.Error("Expected an unknown action on the team list to be denied")
+	}
+	if !a.CanActOnTeamList(user, general, ActionAdmin) {
+		t.Error("Expected a permitted action on the team list to be allowed")
+	}
+}
+
+func TestAuthorizerAdminOverride(t *testing.T) {
+	var a Authorizer
+	user := models.User{Email: "admin@example.com", Domain: "example.com", IsAdmin: true}
+	team := models.Team{ID: "team"}
+	for action, expected := range map[string]bool{
+		ActionRead:              true,
+		ActionWrite:             true,
+		ActionChangePermissions: true,
+		ActionAdmin:             false,
+	} {
+		if allowed := a.CanActOnTeam(user, team, action); allowed != expected {
+			t.Errorf("Expected a deployment admin's CanActOnTeam(%s) to be %v, got %v", action, expected, allowed)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2020 Google LLC
+// Copyright 2020, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -27,8 +27,11 @@ import (
 
 // FirebaseAuth is an Auth implementation which uses Firebase for authentication.
 type FirebaseAuth struct {
+	Authorizer
 	FirebaseClient firebaseAuthClient
 	AuthTimeout    time.Duration
+	// Where to find the deployment admins. If this is nil, there are none.
+	Settings SettingsSource
 }
 
 type firebaseAuthClient interface {
@@ -59,45 +62,9 @@ func (auth *FirebaseAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			Email:  userEmail,
 			Domain: getDomain(userEmail),
 		}
+		user.IsAdmin = isDeploymentAdmin(ctx, auth.Settings, user)
 
 		ctxWithUser := context.WithValue(r.Context(), ContextKey("user"), user)
 		next(w, r.WithContext(ctxWithUser))
 	}
 }
-
-// TODO(#81) The below code is likely to be the same for any Auth implementation which
-// actually wants to perform permissions checks. Move it into a separate type.
-
-func getTeamAllowedUsers(team models.Team, action string) []models.UserMatcher {
-	var permissions []models.UserMatcher
-	if action == ActionRead {
-		permissions = team.Permissions.Read.Allow
-	} else if action == ActionWrite {
-		permissions = team.Permissions.Write.Allow
-	} else {
-		log.Fatalf("The permission action passed (%v) is not implemented.", action)
-	}
-	return permissions
-}
-
-func getGeneralAllowedUsers(generalPermissions models.GeneralPermissions, action string) []models.UserMatcher {
-	var permissions []models.UserMatcher
-	if action == ActionRead {
-		permissions = generalPermissions.ReadTeamList.Allow
-	} else if action == ActionWrite {
-		permissions = generalPermissions.AddTeam.Allow
-	} else {
-		log.Fatalf("The permission action passed (%v) is not implemented.", action)
-	}
-	return permissions
-}
-
-func (auth *FirebaseAuth) CanActOnTeam(user models.User, team models.Team, action string) bool {
-	permissions := getTeamAllowedUsers(team, action)
-	return user.IsPermitted(permissions)
-}
-
-func (auth *FirebaseAuth) CanActOnTeamList(user models.User, generalPermissions models.GeneralPermissions, action string) bool {
-	permissions := getGeneralAllowedUsers(generalPermissions, action)
-	return user.IsPermitted(permissions)
-}
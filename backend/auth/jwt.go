@@ -0,0 +1,82 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// The signing algorithms accepted for JWTs. The key set decides which key is used,
+// so symmetric algorithms, which would let a public key be used as a secret, are not allowed.
+var jwtSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// loadKeys loads a JSON Web Key Set from exactly one of a URL and a local file.
+// A key set from a URL is refreshed periodically, and when a token is signed with a key it doesn't contain.
+func loadKeys(jwksURL, jwksFile string) (jwt.Keyfunc, error) {
+	var jwks *keyfunc.JWKS
+	var err error
+	switch {
+	case jwksFile != "" && jwksURL != "":
+		return nil, fmt.Errorf("only one of a JWKS URL and a JWKS file can be given")
+	case jwksFile != "":
+		var contents []byte
+		contents, err = os.ReadFile(jwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read JWKS file: %v", err)
+		}
+		jwks, err = keyfunc.NewJSON(contents)
+	case jwksURL != "":
+		jwks, err = keyfunc.Get(jwksURL, keyfunc.Options{
+			RefreshInterval:   time.Hour,
+			RefreshRateLimit:  5 * time.Minute,
+			RefreshTimeout:    10 * time.Second,
+			RefreshUnknownKID: true,
+			RefreshErrorHandler: func(err error) {
+				log.Printf("WARNING: Could not refresh JWKS from %s: %v", jwksURL, err)
+			},
+		})
+	default:
+		return nil, fmt.Errorf("a JWKS URL or file is needed")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load JWKS: %v", err)
+	}
+	return jwks.Keyfunc, nil
+}
+
+// verifyToken checks a JWT's signature, issuer, audience and expiry, and returns its claims
+func verifyToken(tokenString string, keys jwt.Keyfunc, issuer, audience string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(jwtSigningMethods))
+	if _, err := parser.ParseWithClaims(tokenString, claims, keys); err != nil {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, fmt.Errorf("token has the wrong issuer %v", claims["iss"])
+	}
+	if !claims.VerifyAudience(audience, true) {
+		return nil, fmt.Errorf("token has the wrong audience %v", claims["aud"])
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("token has no expiry time")
+	}
+	return claims, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/models"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// OIDCConfig configures how OIDCAuth validates tokens and maps their claims to users
+type OIDCConfig struct {
+	// The expected "iss" claim
+	Issuer string
+	// The expected "aud" claim, usually the client ID
+	Audience string
+	// Where to fetch the JSON Web Key Set from. It is refreshed periodically, and when
+	// a token is signed with a key it doesn't contain.
+	JWKSURL string
+	// A local file to read the JSON Web Key Set from instead, e.g. for offline tests
+	JWKSFile string
+	// The claim holding the user's email address; "email" if empty
+	EmailClaim string
+	// The claim holding the user's domain. If empty, the domain of the email address is used.
+	DomainClaim string
+	// The claim holding the user's groups, for "Group" user matchers. If empty, users have no groups.
+	GroupsClaim string
+}
+
+// OIDCAuth is an Auth implementation which authenticates users with bearer JWTs
+// issued by an OpenID Connect identity provider.
+type OIDCAuth struct {
+	Authorizer
+	Config OIDCConfig
+	// Where to find the deployment admins. If this is nil, there are none.
+	Settings SettingsSource
+	keys     jwt.Keyfunc
+}
+
+// MakeOIDCAuth loads the JSON Web Key Set for the configuration and returns the Auth implementation
+func MakeOIDCAuth(config OIDCConfig, settings SettingsSource) (*OIDCAuth, error) {
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, fmt.Errorf("an issuer and audience are needed for OIDC authentication")
+	}
+	keys, err := loadKeys(config.JWKSURL, config.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	if config.EmailClaim == "" {
+		config.EmailClaim = "email"
+	}
+	return &OIDCAuth{Config: config, Settings: settings, keys: keys}, nil
+}
+
+func (auth *OIDCAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		user, err := auth.verify(tokenString)
+		if err != nil {
+			w.Header().Add("Authorization", "WWW-Authenticate: Bearer")
+			log.Printf("User authentication failed: %v", err)
+			http.Error(w, "User authentication failed", http.StatusUnauthorized)
+			return
+		}
+		user.IsAdmin = isDeploymentAdmin(r.Context(), auth.Settings, user)
+
+		ctxWithUser := context.WithValue(r.Context(), ContextKey("user"), user)
+		next(w, r.WithContext(ctxWithUser))
+	}
+}
+
+// verify checks the token's signature, issuer, audience and expiry, and maps its claims to a user
+func (auth *OIDCAuth) verify(tokenString string) (models.User, error) {
+	claims, err := verifyToken(tokenString, auth.keys, auth.Config.Issuer, auth.Config.Audience)
+	if err != nil {
+		return models.User{}, err
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return models.User{}, fmt.Errorf("email address is not verified")
+	}
+	return userFromClaims(claims, auth.Config.EmailClaim, auth.Config.DomainClaim, auth.Config.GroupsClaim)
+}
+
+// userFromClaims maps the configured claims of a verified token to a user
+func userFromClaims(claims map[string]interface{}, emailClaim, domainClaim, groupsClaim string) (models.User, error) {
+	email, _ := claims[emailClaim].(string)
+	if email == "" {
+		return models.User{}, fmt.Errorf("token has no '%s' claim", emailClaim)
+	}
+	user := models.User{Email: email, Domain: getDomain(email)}
+	if domainClaim != "" {
+		domain, _ := claims[domainClaim].(string)
+		if domain == "" {
+			return models.User{}, fmt.Errorf("token has no '%s' claim", domainClaim)
+		}
+		user.Domain = domain
+	}
+	if groupsClaim != "" {
+		// Identity providers send either a list of groups or a single one
+		switch groups := claims[groupsClaim].(type) {
+		case []interface{}:
+			for _, group := range groups {
+				if g, ok := group.(string); ok {
+					user.Groups = append(user.Groups, g)
+				}
+			}
+		case string:
+			user.Groups = []string{groups}
+		}
+	}
+	return user, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"peoplemath/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testAudience = "peoplemath"
+)
+
+// writeJWKS writes a JSON Web Key Set containing the public key to a temporary file
+func writeJWKS(key *rsa.PrivateKey, kid string, t *testing.T) string {
+	jwks := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	contents, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("Could not marshal JWKS: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("Could not write JWKS: %v", err)
+	}
+	return path
+}
+
+func signToken(key *rsa.PrivateKey, kid string, claims jwt.MapClaims, t *testing.T) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Could not sign token: %v", err)
+	}
+	return signed
+}
+
+// serve passes the request through the authentication middleware and returns the status and the authenticated user
+func serve(a Auth, req *http.Request) (int, models.User) {
+	var user models.User
+	handler := a.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		user = r.Context().Value(ContextKey("user")).(models.User)
+	})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec.Code, user
+}
+
+// authenticate makes a request with the token and returns the status and the authenticated user
+func authenticate(a Auth, token string) (int, models.User) {
+	req := httptest.NewRequest(http.MethodGet, "/api/team/", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	return serve(a, req)
+}
+
+func TestOIDCAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	oidcAuth, err := MakeOIDCAuth(OIDCConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		JWKSFile:    writeJWKS(key, "key1", t),
+		GroupsClaim: "groups",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Could not make OIDC auth: %v", err)
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    testIssuer,
+			"aud":    testAudience,
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"email":  "alice@example.com",
+			"groups": []string{"planners", "leads"},
+		}
+	}
+	status, user := authenticate(oidcAuth, signToken(key, "key1", validClaims(), t))
+	if status != http.StatusOK {
+		t.Fatalf("Expected a valid token to be accepted, got status %d", status)
+	}
+	expected := models.User{Email: "alice@example.com", Domain: "example.com", Groups: []string{"planners", "leads"}}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected user %+v, got %+v", expected, user)
+	}
+	if !oidcAuth.IsPermitted(user, []models.UserMatcher{{Type: models.UserMatcherTypeGroup, ID: "Planners"}}) {
+		t.Error("Expected the user to match a group from the token")
+	}
+
+	for name, modify := range map[string]func(jwt.MapClaims){
+		"wrong issuer":       func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience":     func(c jwt.MapClaims) { c["aud"] = "another-app" },
+		"expired":            func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":          func(c jwt.MapClaims) { delete(c, "exp") },
+		"no email":           func(c jwt.MapClaims) { delete(c, "email") },
+		"unverified address": func(c jwt.MapClaims) { c["email_verified"] = false },
+	} {
+		claims := validClaims()
+		modify(claims)
+		if status, _ := authenticate(oidcAuth, signToken(key, "key1", claims, t)); status != http.StatusUnauthorized {
+			t.Errorf("Expected a token with %s to be rejected, got status %d", name, status)
+		}
+	}
+	if status, _ := authenticate(oidcAuth, signToken(otherKey, "key1", validClaims(), t)); status != http.StatusUnauthorized {
+		t.Errorf("Expected a token signed with another key to be rejected, got status %d", status)
+	}
+	if status, _ := authenticate(oidcAuth, "not-a-token"); status != http.StatusUnauthorized {
+		t.Errorf("Expected garbage to be rejected, got status %d", status)
+	}
+}
+
+func TestOIDCClaimMapping(t *testing.T) {
+	claims := map[string]interface{}{
+		"upn":    "bob@corp.example.com",
+		"tenant": "example.com",
+		"role":   "planners",
+	}
+	user, err := userFromClaims(claims, "upn", "tenant", "role")
+	if err != nil {
+		t.Fatalf("Could not map claims: %v", err)
+	}
+	expected := models.User{Email: "bob@corp.example.com", Domain: "example.com", Groups: []string{"planners"}}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected user %+v, got %+v", expected, user)
+	}
+	if _, err := userFromClaims(claims, "upn", "missing", ""); err == nil {
+		t.Error("Expected a missing domain claim to be an error")
+	}
+}
+
+func TestMakeOIDCAuthNeedsConfiguration(t *testing.T) {
+	for name, config := range map[string]OIDCConfig{
+		"no issuer":    {Audience: testAudience, JWKSFile: "jwks.json"},
+		"no audience":  {Issuer: testIssuer, JWKSFile: "jwks.json"},
+		"no JWKS":      {Issuer: testIssuer, Audience: testAudience},
+		"missing file": {Issuer: testIssuer, Audience: testAudience, JWKSFile: filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if _, err := MakeOIDCAuth(config, nil); err == nil {
+			t.Errorf("Expected MakeOIDCAuth with %s to fail", name)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"peoplemath/models"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ProxyConfig configures how ProxyAuth trusts the identity passed on by an authenticating proxy.
+// At least one of an assertion header and trusted proxy addresses is needed, so that
+// requests which bypass the proxy, and set the identity header themselves, are refused.
+type ProxyConfig struct {
+	// The header holding the user's email address, e.g. X-Goog-Authenticated-User-Email for IAP
+	Header string
+	// A prefix to remove from the header, e.g. "accounts.google.com:" for IAP
+	HeaderPrefix string
+	// The header holding a signed JWT assertion of the user's identity, e.g. X-Goog-IAP-JWT-Assertion.
+	// If this is set, every request must have a valid assertion for the same email address.
+	AssertionHeader string
+	// The expected "iss" claim of the assertion
+	Issuer string
+	// The expected "aud" claim of the assertion
+	Audience string
+	// Where to fetch the JSON Web Key Set which signs assertions from
+	JWKSURL string
+	// A local file to read the JSON Web Key Set from instead, e.g. for offline tests
+	JWKSFile string
+	// IP addresses or CIDR ranges of the proxy. If this is set, requests from anywhere else are refused.
+	TrustedProxies []string
+}
+
+// ProxyAuth is an Auth implementation which trusts the user identity in a header injected by
+// an authenticating reverse proxy, such as Identity-Aware Proxy.
+type ProxyAuth struct {
+	Authorizer
+	Config ProxyConfig
+	// Where to find the deployment admins. If this is nil, there are none.
+	Settings       SettingsSource
+	keys           jwt.Keyfunc
+	trustedProxies []*net.IPNet
+}
+
+// MakeProxyAuth loads the keys and trusted proxies for the configuration and returns the Auth implementation
+func MakeProxyAuth(config ProxyConfig, settings SettingsSource) (*ProxyAuth, error) {
+	if config.Header == "" {
+		return nil, fmt.Errorf("an identity header is needed for proxy authentication")
+	}
+	if config.AssertionHeader == "" && len(config.TrustedProxies) == 0 {
+		return nil, fmt.Errorf("an assertion header or trusted proxy addresses are needed, or anyone could set the identity header")
+	}
+	auth := &ProxyAuth{Config: config, Settings: settings}
+	if config.AssertionHeader != "" {
+		if config.Issuer == "" || config.Audience == "" {
+			return nil, fmt.Errorf("an issuer and audience are needed to verify assertions")
+		}
+		keys, err := loadKeys(config.JWKSURL, config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.keys = keys
+	}
+	for _, proxy := range config.TrustedProxies {
+		network, err := parseIPNet(proxy)
+		if err != nil {
+			return nil, err
+		}
+		auth.trustedProxies = append(auth.trustedProxies, network)
+	}
+	return auth, nil
+}
+
+// parseIPNet parses a CIDR range, or a single IP address as a range containing only it
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range '%s': %v", s, err)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy address '%s'", s)
+	}
+	bits := 8 * len(ip.To16())
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func (auth *ProxyAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := auth.identify(r)
+		if err != nil {
+			log.Printf("Proxy authentication failed: %v", err)
+			http.Error(w, "User authentication failed", http.StatusUnauthorized)
+			return
+		}
+		user.IsAdmin = isDeploymentAdmin(r.Context(), auth.Settings, user)
+
+		ctxWithUser := context.WithValue(r.Context(), ContextKey("user"), user)
+		next(w, r.WithContext(ctxWithUser))
+	}
+}
+
+// identify checks that the request came through the proxy, and returns the user it identified
+func (auth *ProxyAuth) identify(r *http.Request) (models.User, error) {
+	if len(auth.trustedProxies) > 0 && !auth.isFromTrustedProxy(r.RemoteAddr) {
+		return models.User{}, fmt.Errorf("request from %s did not come through a trusted proxy", r.RemoteAddr)
+	}
+	email := strings.TrimPrefix(r.Header.Get(auth.Config.Header), auth.Config.HeaderPrefix)
+	if email == "" {
+		return models.User{}, fmt.Errorf("request has no %s header", auth.Config.Header)
+	}
+	if auth.Config.AssertionHeader != "" {
+		assertion := r.Header.Get(auth.Config.AssertionHeader)
+		if assertion == "" {
+			return models.User{}, fmt.Errorf("request has no %s header", auth.Config.AssertionHeader)
+		}
+		claims, err := verifyToken(assertion, auth.keys, auth.Config.Issuer, auth.Config.Audience)
+		if err != nil {
+			return models.User{}, fmt.Errorf("invalid assertion: %v", err)
+		}
+		if asserted, _ := claims["email"].(string); !strings.EqualFold(asserted, email) {
+			return models.User{}, fmt.Errorf("assertion is for '%s', not '%s'", asserted, email)
+		}
+	}
+	return models.User{Email: email, Domain: getDomain(email)}, nil
+}
+
+// verifyRequest checks that a request sent with an API key came through the proxy like any other
+func (auth *ProxyAuth) verifyRequest(r *http.Request) error {
+	_, err := auth.identify(r)
+	return err
+}
+
+func (auth *ProxyAuth) isFromTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range auth.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
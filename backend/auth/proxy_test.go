@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"peoplemath/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	iapHeader          = "X-Goog-Authenticated-User-Email"
+	iapPrefix          = "accounts.google.com:"
+	iapAssertionHeader = "X-Goog-IAP-JWT-Assertion"
+	iapIssuer          = "https://cloud.google.com/iap"
+	iapAudience        = "/projects/1/apps/peoplemath"
+)
+
+func proxyRequest(remoteAddr string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/team/", nil)
+	req.RemoteAddr = remoteAddr
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
+	return req
+}
+
+func TestProxyAuthTrustedProxies(t *testing.T) {
+	proxyAuth, err := MakeProxyAuth(ProxyConfig{
+		Header:         iapHeader,
+		HeaderPrefix:   iapPrefix,
+		TrustedProxies: []string{"10.0.0.0/8", "192.0.2.7"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Could not make proxy auth: %v", err)
+	}
+	identity := map[string]string{iapHeader: iapPrefix + "alice@example.com"}
+
+	status, user := serve(proxyAuth, proxyRequest("10.1.2.3:4567", identity))
+	if status != http.StatusOK {
+		t.Fatalf("Expected a request from the proxy to be accepted, got status %d", status)
+	}
+	expected := models.User{Email: "alice@example.com", Domain: "example.com"}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected user %+v, got %+v", expected, user)
+	}
+	if status, _ := serve(proxyAuth, proxyRequest("192.0.2.7:80", identity)); status != http.StatusOK {
+		t.Errorf("Expected a request from a single trusted address to be accepted, got status %d", status)
+	}
+	if status, _ := serve(proxyAuth, proxyRequest("192.0.2.8:80", identity)); status != http.StatusUnauthorized {
+		t.Errorf("Expected a request bypassing the proxy to be refused, got status %d", status)
+	}
+	if status, _ := serve(proxyAuth, proxyRequest("10.1.2.3:4567", nil)); status != http.StatusUnauthorized {
+		t.Errorf("Expected a request with no identity to be refused, got status %d", status)
+	}
+}
+
+func TestProxyAuthAssertion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	proxyAuth, err := MakeProxyAuth(ProxyConfig{
+		Header:          iapHeader,
+		HeaderPrefix:    iapPrefix,
+		AssertionHeader: iapAssertionHeader,
+		Issuer:          iapIssuer,
+		Audience:        iapAudience,
+		JWKSFile:        writeJWKS(key, "iap", t),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Could not make proxy auth: %v", err)
+	}
+	assertion := func(signingKey *rsa.PrivateKey, email string) string {
+		return signToken(signingKey, "iap", jwt.MapClaims{
+			"iss":   iapIssuer,
+			"aud":   iapAudience,
+			"exp":   time.Now().Add(10 * time.Minute).Unix(),
+			"email": email,
+		}, t)
+	}
+	request := func(email, assertion string) *http.Request {
+		headers := map[string]string{iapHeader: iapPrefix + email}
+		if assertion != "" {
+			headers[iapAssertionHeader] = assertion
+		}
+		return proxyRequest("203.0.113.1:1234", headers)
+	}
+
+	status, user := serve(proxyAuth, request("alice@example.com", assertion(key, "alice@example.com")))
+	if status != http.StatusOK {
+		t.Fatalf("Expected a request with a valid assertion to be accepted, got status %d", status)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("Expected user alice@example.com, got %s", user.Email)
+	}
+	for name, req := range map[string]*http.Request{
+		"no assertion":                  request("alice@example.com", ""),
+		"assertion for another user":    request("mallory@example.com", assertion(key, "alice@example.com")),
+		"assertion signed with another": request("alice@example.com", assertion(otherKey, "alice@example.com")),
+	} {
+		if status, _ := serve(proxyAuth, req); status != http.StatusUnauthorized {
+			t.Errorf("Expected a request with %s to be refused, got status %d", name, status)
+		}
+	}
+}
+
+func TestMakeProxyAuthRefusesSpoofableConfiguration(t *testing.T) {
+	for name, config := range map[string]ProxyConfig{
+		"no header":                   {TrustedProxies: []string{"10.0.0.1"}},
+		"no assertion or proxies":     {Header: iapHeader},
+		"assertion with no audience":  {Header: iapHeader, AssertionHeader: iapAssertionHeader, Issuer: iapIssuer, JWKSFile: "jwks.json"},
+		"assertion with no JWKS":      {Header: iapHeader, AssertionHeader: iapAssertionHeader, Issuer: iapIssuer, Audience: iapAudience},
+		"invalid trusted proxy":       {Header: iapHeader, TrustedProxies: []string{"proxy.example.com"}},
+		"invalid trusted proxy range": {Header: iapHeader, TrustedProxies: []string{"10.0.0.0/99"}},
+	} {
+		if _, err := MakeProxyAuth(config, nil); err == nil {
+			t.Errorf("Expected MakeProxyAuth with %s to fail", name)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"peoplemath/analytics"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// WatermarkHeader is the response header giving the watermark to use for the next incremental export
+const WatermarkHeader = "X-Export-Watermark"
+
+func (s *Server) handleGetAnalyticsExport(w http.ResponseWriter, r *http.Request) {
+	table := mux.Vars(r)["table"]
+	if !analytics.IsTable(table) {
+		http.Error(w, fmt.Sprintf("Unknown table '%s'", table), http.StatusNotFound)
+		return
+	}
+	options := analytics.Options{}
+	if since := r.URL.Query().Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Could not parse since parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+		options.Since = t
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve settings: %v", err)
+		http.Error(w, "Could not retrieve due to internal server error", http.StatusInternalServerError)
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeamList(user, settings.GeneralPermissions, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view the team list.", http.StatusForbidden)
+		return
+	}
+	options.TeamFilter = func(team models.Team) bool {
+		return s.auth.CanActOnTeam(user, team, auth.ActionRead)
+	}
+
+	// Take the watermark before reading, so that changes made during the export are picked up next time
+	watermark := time.Now().UTC()
+	var b bytes.Buffer
+	err = analytics.Export(ctx, s.store, options, map[string]io.Writer{table: &b})
+	if err != nil {
+		log.Printf("Could not export table '%s': error: %s", table, err)
+		http.Error(w, fmt.Sprintf("Could not export table '%s' (see server log)", table), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(WatermarkHeader, watermark.Format(time.RFC3339Nano))
+	w.Write(b.Bytes())
+}
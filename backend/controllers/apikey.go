@@ -0,0 +1,150 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// API keys are managed by the users who administer the settings, and their creation
+// and revocation are recorded in the settings audit log.
+
+func (s *Server) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getAdministrableSettings(w, r); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	keys, err := s.store.GetAPIKeys(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve API keys: %v", err)
+		http.Error(w, "Could not retrieve API keys (see server log)", http.StatusInternalServerError)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(keys)
+}
+
+// handlePostAPIKey creates an API key. The response is the only time the key is sent,
+// as only a hash of it is stored.
+func (s *Server) handlePostAPIKey(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getAdministrableSettings(w, r); !ok {
+		return
+	}
+	request := models.CreateAPIKeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid API key: %s", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	for _, team := range request.Teams {
+		_, found, err := s.store.GetTeam(ctx, team.TeamID)
+		if err != nil {
+			log.Printf("Could not retrieve team '%s': %v", team.TeamID, err)
+			http.Error(w, "Could not retrieve team (see server log)", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("No such team '%s'", team.TeamID), http.StatusBadRequest)
+			return
+		}
+	}
+	existing, err := s.store.GetAPIKeys(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve API keys: %v", err)
+		http.Error(w, "Could not retrieve API keys (see server log)", http.StatusInternalServerError)
+		return
+	}
+	for _, key := range existing {
+		if key.Name == request.Name {
+			http.Error(w, fmt.Sprintf("There is already an API key named '%s'", request.Name), http.StatusConflict)
+			return
+		}
+	}
+
+	secret, hash, err := auth.NewAPIKeySecret()
+	if err != nil {
+		log.Printf("Could not create API key: %v", err)
+		http.Error(w, "Could not create API key (see server log)", http.StatusInternalServerError)
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	key := models.APIKey{
+		ID:           uuid.New().String(),
+		Name:         request.Name,
+		Teams:        request.Teams,
+		CreatedBy:    user.Identity(),
+		CreationTime: time.Now(),
+		SecretHash:   hash,
+	}
+	if err := s.store.CreateAPIKey(ctx, key); err != nil {
+		log.Printf("Could not create API key: %v", err)
+		http.Error(w, "Could not create API key (see server log)", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Created API key '%s'", key.Name)
+	var access []string
+	for _, team := range key.Teams {
+		access = append(access, fmt.Sprintf("%s access to team '%s'", team.Access, team.TeamID))
+	}
+	s.recordAudit(ctx, r, "", "", []string{fmt.Sprintf("Created API key '%s' with %s", key.Name, strings.Join(access, ", "))})
+
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(models.CreatedAPIKey{APIKey: key, Key: auth.APIKeyToken(key.ID, secret)})
+}
+
+func (s *Server) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getAdministrableSettings(w, r); !ok {
+		return
+	}
+	keyID := mux.Vars(r)["keyID"]
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	key, found, err := s.store.GetAPIKey(ctx, keyID)
+	if err != nil {
+		log.Printf("Could not retrieve API key '%s': %v", keyID, err)
+		http.Error(w, "Could not retrieve API key (see server log)", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, fmt.Sprintf("No such API key '%s'", keyID), http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteAPIKey(ctx, keyID); err != nil {
+		log.Printf("Could not revoke API key '%s': %v", keyID, err)
+		http.Error(w, "Could not revoke API key (see server log)", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Revoked API key '%s'", key.Name)
+	s.recordAudit(ctx, r, "", "", []string{fmt.Sprintf("Revoked API key '%s'", key.Name)})
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Reads with ?asOf= return the version which was current at a past time. Permission to
+// read is always checked against the current version of the team, not the one at that time.
+
+// parseAsOf reads the optional asOf parameter. If it is present but invalid, it writes
+// an error response and returns false.
+func parseAsOf(w http.ResponseWriter, r *http.Request) (asOf time.Time, present bool, ok bool) {
+	asOfStr := r.URL.Query().Get("asOf")
+	if asOfStr == "" {
+		return time.Time{}, false, true
+	}
+	asOf, err := time.Parse(time.RFC3339, asOfStr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid asOf time '%s', expected RFC 3339 format", asOfStr), http.StatusBadRequest)
+		return time.Time{}, true, false
+	}
+	return asOf, true, true
+}
+
+// periodAsOf returns the version of a period which was current at the given time.
+// If there was none, it writes an error response and returns false.
+func (s *Server) periodAsOf(w http.ResponseWriter, r *http.Request, teamID string, period *models.Period, asOf time.Time) (*models.Period, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	backups, _, err := s.store.GetPeriodBackups(ctx, teamID, period.ID)
+	if err != nil {
+		log.Printf("Could not retrieve backups of period '%s' for team '%s': error: %s", period.ID, teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve backups of period '%s' for team '%s' (see server log)", period.ID, teamID), http.StatusInternalServerError)
+		return nil, false
+	}
+	result, found := models.PeriodAsOf(period, backups.Backups, asOf)
+	if !found {
+		http.Error(w, fmt.Sprintf("No version of period '%s' for team '%s' is available as of %s", period.ID, teamID, asOf.Format(time.RFC3339)), http.StatusNotFound)
+		return nil, false
+	}
+	return result, true
+}
+
+// teamAsOf returns the version of a team, including its permissions, which was current
+// at the given time. If there was none, it writes an error response and returns false.
+func (s *Server) teamAsOf(w http.ResponseWriter, r *http.Request, team models.Team, asOf time.Time) (models.Team, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	backups, _, err := s.store.GetTeamBackups(ctx, team.ID)
+	if err != nil {
+		log.Printf("Could not retrieve backups of team '%s': error: %s", team.ID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve backups of team '%s' (see server log)", team.ID), http.StatusInternalServerError)
+		return models.Team{}, false
+	}
+	result, found := models.TeamAsOf(team, backups.Backups, asOf)
+	if !found {
+		http.Error(w, fmt.Sprintf("No version of team '%s' is available as of %s", team.ID, asOf.Format(time.RFC3339)), http.StatusNotFound)
+		return models.Team{}, false
+	}
+	return result, true
+}
+
+func (s *Server) backupTeam(ctx context.Context, team models.Team) error {
+	backups, ok, err := s.store.GetTeamBackups(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		backups = models.TeamBackups{}
+	}
+	// Team changes are rare, so unlike period backups these are never pruned
+	backups.Backups = append(backups.Backups, models.TeamBackup{
+		Timestamp: time.Now(),
+		Team:      team,
+	})
+	return s.store.UpsertTeamBackups(ctx, team.ID, backups)
+}
+
+// handleGetPeriod2 returns the latest version of a period, or the version which was
+// the latest at the time given by ?asOf=
+func (s *Server) handleGetPeriod2(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	asOf, hasAsOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	var period *models.Period2
+	var err error
+	if hasAsOf {
+		var versions []models.PeriodVersionInfo
+		versions, err = s.store2.GetPeriodVersions(ctx, teamID, periodID)
+		if err != nil {
+			writeStorage2Error(w, err, fmt.Sprintf("retrieve versions of period '%s' for team '%s'", periodID, teamID))
+			return
+		}
+		version, found := models.Period2VersionAsOf(versions, asOf)
+		if !found {
+			http.Error(w, fmt.Sprintf("No version of period '%s' for team '%s' is available as of %s", periodID, teamID, asOf.Format(time.RFC3339)), http.StatusNotFound)
+			return
+		}
+		period, err = s.store2.GetPeriodVersion(ctx, teamID, periodID, version)
+	} else {
+		period, err = s.store2.GetPeriodLatestVersion(ctx, teamID, periodID)
+	}
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(period)
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// recordAudit adds an entry to the audit log for a change made by the request.
+// Like backups, a failure here doesn't fail the change itself.
+func (s *Server) recordAudit(ctx context.Context, r *http.Request, teamID, periodID string, summary []string) {
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	entry := models.AuditEntry{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		PeriodID:  periodID,
+		User:      user.Identity(),
+		Timestamp: time.Now(),
+		Method:    r.Method,
+		Endpoint:  r.URL.Path,
+		Summary:   summary,
+	}
+	if err := s.store.AddAuditEntry(ctx, entry); err != nil {
+		log.Printf("WARNING: Could not record audit entry for %s %s by '%s': %s", r.Method, r.URL.Path, user.Identity(), err)
+	}
+}
+
+// parseAuditPageSize reads the optional pageSize parameter. If it is invalid, it writes
+// an error response and returns false.
+func parseAuditPageSize(w http.ResponseWriter, r *http.Request) (int, bool) {
+	pageSize := defaultAuditPageSize
+	if size := r.URL.Query().Get("pageSize"); size != "" {
+		var err error
+		if pageSize, err = strconv.Atoi(size); err != nil || pageSize <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid page size '%s'", size), http.StatusBadRequest)
+			return 0, false
+		}
+		pageSize = min(pageSize, maxAuditPageSize)
+	}
+	return pageSize, true
+}
+
+// handleGetAuditLog returns the audit log of a team, or of one of its periods, a page at a time.
+// With ?format=ndjson, the whole log is exported as newline-delimited JSON instead.
+func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	pageSize, ok := parseAuditPageSize(w, r)
+	if !ok {
+		return
+	}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	if periodID != "" {
+		if _, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true); !ok {
+			return
+		}
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view this team's audit log.", http.StatusForbidden)
+		return
+	}
+	s.writeAuditLog(w, r, teamID, periodID, pageSize, fmt.Sprintf("team '%s'", teamID))
+}
+
+// writeAuditLog writes a page of audit entries, or all of them with ?format=ndjson.
+// The description says whose log it is, for error messages.
+func (s *Server) writeAuditLog(w http.ResponseWriter, r *http.Request, teamID, periodID string, pageSize int, description string) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	if r.URL.Query().Get("format") == "ndjson" {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		pageToken := ""
+		for {
+			page, err := s.store.GetAuditEntries(ctx, teamID, periodID, pageToken, maxAuditPageSize)
+			if err != nil {
+				log.Printf("Could not export audit log for %s: error: %s", description, err)
+				http.Error(w, fmt.Sprintf("Could not export audit log for %s (see server log)", description), http.StatusInternalServerError)
+				return
+			}
+			for _, entry := range page.Entries {
+				enc.Encode(entry)
+			}
+			if page.NextPageToken == "" {
+				break
+			}
+			pageToken = page.NextPageToken
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write(b.Bytes())
+		return
+	}
+
+	page, err := s.store.GetAuditEntries(ctx, teamID, periodID, r.URL.Query().Get("pageToken"), pageSize)
+	if err != nil {
+		log.Printf("Could not retrieve audit log for %s: error: %s", description, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve audit log for %s (see server log)", description), http.StatusInternalServerError)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(page)
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/audit"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// getReadableBackups checks that the period exists and the user can read it,
+// and returns the team and period along with the period's backups
+func (s *Server) getReadableBackups(w http.ResponseWriter, r *http.Request) (models.Team, *models.Period, models.PeriodBackups, bool) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return team, nil, models.PeriodBackups{}, false
+	}
+	period, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true)
+	if !ok {
+		return team, nil, models.PeriodBackups{}, false
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view this team's periods.", http.StatusForbidden)
+		return team, nil, models.PeriodBackups{}, false
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	backups, _, err := s.store.GetPeriodBackups(ctx, teamID, periodID)
+	if err != nil {
+		log.Printf("Could not retrieve backups of period '%s' for team '%s': error: %s", periodID, teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve backups of period '%s' for team '%s' (see server log)", periodID, teamID), http.StatusInternalServerError)
+		return team, nil, models.PeriodBackups{}, false
+	}
+	return team, period, backups, true
+}
+
+// handleGetBackups lists a period's backups, newest first
+func (s *Server) handleGetBackups(w http.ResponseWriter, r *http.Request) {
+	_, period, backups, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	result := make([]models.BackupSummary, 0, len(backups.Backups))
+	next := period
+	for i := len(backups.Backups) - 1; i >= 0; i-- {
+		backup := &backups.Backups[i]
+		result = append(result, models.BackupSummary{
+			ID:             backup.ID(),
+			Timestamp:      backup.Timestamp,
+			LastUpdateTime: backup.Period.LastUpdateTime,
+			Summary:        audit.SummarizePeriodChange(&backup.Period, next),
+		})
+		next = &backup.Period
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(result)
+}
+
+func (s *Server) handleGetBackup(w http.ResponseWriter, r *http.Request) {
+	_, _, backups, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	backup, found := backups.FindBackup(mux.Vars(r)["backupID"])
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(backup)
+}
+
+// handleRestoreBackup makes a backup the current version of the period. This is treated
+// like any other edit: the version being replaced is itself backed up, so the restore
+// can be undone.
+func (s *Server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	backupID := vars["backupID"]
+	request := models.RestoreRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	team, savedPeriod, backups, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	backup, found := backups.FindBackup(backupID)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	period := backup.Period
+	period.ID = savedPeriod.ID
+	period.LastUpdateUUID = request.LastUpdateUUID
+	if !s.ensureNoConcurrentMod(w, r, &period, savedPeriod) || !s.ensureNoOverlap(w, r, teamID, &period) {
+		return
+	}
+	s.updatePeriod(w, r, team, savedPeriod, &period,
+		fmt.Sprintf("Restored backup '%s' from %s", backupID, backup.Timestamp.UTC().Format(time.RFC3339)))
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Deleting a team or period only hides it, and it can be restored until it is purged
+// once the grace period in the settings has passed. All of this needs admin permission
+// on the team.
+
+// writeDeletedConflict checks whether a team or period could not be created because a
+// deleted one has the same ID, and if so, writes an error response
+func writeDeletedConflict(w http.ResponseWriter, err error) bool {
+	if _, ok := err.(storage.DeletedError); ok {
+		http.Error(w, fmt.Sprintf("%s. Restore it, or choose another ID.", err), http.StatusConflict)
+		return true
+	}
+	return false
+}
+
+func (s *Server) handleDeleteTeam(w http.ResponseWriter, r *http.Request) {
+	teamID := mux.Vars(r)["teamID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionAdmin) {
+		http.Error(w, "You are not authorized to delete this team.", http.StatusForbidden)
+		return
+	}
+	err := s.store.DeleteTeam(ctx, teamID, models.Deletion{User: user.Identity(), Timestamp: time.Now()})
+	if err != nil {
+		if writeRenamingConflict(w, err) {
+			return
+		}
+		log.Printf("Could not delete team '%s': error: %s", teamID, err)
+		http.Error(w, fmt.Sprintf("Could not delete team '%s' (see server log)", teamID), http.StatusInternalServerError)
+		return
+	}
+	s.recordAudit(ctx, r, teamID, "", []string{"Deleted team"})
+}
+
+func (s *Server) handleDeletePeriod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	if _, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true); !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionAdmin) {
+		http.Error(w, "You are not authorized to delete this team's periods.", http.StatusForbidden)
+		return
+	}
+	err := s.store.DeletePeriod(ctx, teamID, periodID, models.Deletion{User: user.Identity(), Timestamp: time.Now()})
+	if err != nil {
+		if writeRenamingConflict(w, err) {
+			return
+		}
+		log.Printf("Could not delete period '%s' for team '%s': error: %s", periodID, teamID, err)
+		http.Error(w, fmt.Sprintf("Could not delete period '%s' for team '%s' (see server log)", periodID, teamID), http.StatusInternalServerError)
+		return
+	}
+	s.recordAudit(ctx, r, teamID, periodID, []string{fmt.Sprintf("Deleted period '%s'", periodID)})
+}
+
+// getDeletedItems lists the deleted items which the user can restore, with the time each will be purged.
+// If they can't be retrieved, it writes an error response and returns false.
+func (s *Server) getDeletedItems(w http.ResponseWriter, r *http.Request) ([]models.DeletedItem, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve settings: %v", err)
+		http.Error(w, "Could not retrieve due to internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	items, err := s.store.GetDeletedItems(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve deleted items: error: %s", err)
+		http.Error(w, "Could not retrieve deleted items (see server log)", http.StatusInternalServerError)
+		return nil, false
+	}
+	// Deleted teams can't change, so their permissions are as they were when they were deleted
+	deletedTeams := make(map[string]models.Team)
+	for _, item := range items {
+		if item.PeriodID == "" {
+			deletedTeams[item.TeamID] = models.Team{ID: item.TeamID, Permissions: item.Permissions}
+		}
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	result := []models.DeletedItem{}
+	for _, item := range items {
+		team, found := deletedTeams[item.TeamID]
+		if !found {
+			if team, found, err = s.store.GetTeam(ctx, item.TeamID); err != nil {
+				log.Printf("Could not retrieve team '%s': error: %s", item.TeamID, err)
+				http.Error(w, fmt.Sprintf("Could not retrieve team '%s' (see server log)", item.TeamID), http.StatusInternalServerError)
+				return nil, false
+			}
+		}
+		if found && s.auth.CanActOnTeam(user, team, auth.ActionAdmin) {
+			item.PurgeTime = item.Timestamp.Add(settings.GetDeletionGracePeriod())
+			result = append(result, item)
+		}
+	}
+	return result, true
+}
+
+// handleGetDeletedItems lists the deleted teams and periods which the user can restore, oldest deletion first
+func (s *Server) handleGetDeletedItems(w http.ResponseWriter, r *http.Request) {
+	items, ok := s.getDeletedItems(w, r)
+	if !ok {
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(items)
+}
+
+// handleRestoreDeletedItem restores a deleted team, or a deleted period if the URL includes one
+func (s *Server) handleRestoreDeletedItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	items, ok := s.getDeletedItems(w, r)
+	if !ok {
+		return
+	}
+	found := false
+	for _, item := range items {
+		found = found || (item.TeamID == teamID && item.PeriodID == periodID)
+	}
+	if !found {
+		// This includes items which the user is not authorized to restore
+		http.NotFound(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	if err := s.store.RestoreDeletedItem(ctx, teamID, periodID); err != nil {
+		log.Printf("Could not restore '%s': error: %s", storage.DeletedItemID(teamID, periodID), err)
+		http.Error(w, fmt.Sprintf("Could not restore '%s' (see server log)", storage.DeletedItemID(teamID, periodID)), http.StatusInternalServerError)
+		return
+	}
+	if err := s.touchRestoredItem(ctx, teamID, periodID); err != nil {
+		log.Printf("WARNING: Could not update the last update time of '%s': %s", storage.DeletedItemID(teamID, periodID), err)
+	}
+	summary := "Restored team"
+	if periodID != "" {
+		summary = fmt.Sprintf("Restored period '%s'", periodID)
+	}
+	s.recordAudit(ctx, r, teamID, periodID, []string{summary})
+}
+
+// touchRestoredItem updates the LastUpdateTime of a restored team or period, backing up the
+// previous version as for any other change, so that incremental analytics exports include it
+// again. Restoring a team this way also includes all of its periods.
+func (s *Server) touchRestoredItem(ctx context.Context, teamID, periodID string) error {
+	if periodID == "" {
+		team, found, err := s.store.GetTeam(ctx, teamID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("team '%s' not found", teamID)
+		}
+		previous := team
+		team.LastUpdateTime = time.Now()
+		if err := s.store.UpdateTeam(ctx, team); err != nil {
+			return err
+		}
+		return s.backupTeam(ctx, previous)
+	}
+	period, found, err := s.store.GetPeriod(ctx, teamID, periodID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("period '%s' for team '%s' not found", periodID, teamID)
+	}
+	var previous models.Period
+	err = s.store.ModifyPeriod(ctx, teamID, periodID, period.LastUpdateUUID, func(saved *models.Period) error {
+		previous = *saved
+		saved.LastUpdateTime = time.Now()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.backupPeriod(ctx, teamID, periodID, &previous)
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/diff"
+	"peoplemath/models"
+	"strings"
+)
+
+// version2Prefix marks a version from the version history of a Period2, by its ID or
+// the name of a tag, rather than a backup
+const version2Prefix = "v2:"
+
+// versionRef identifies a version of a period, written as team/period for the
+// current version, team/period@version for an older one, or team/period@v2:version
+// for one from the version history
+type versionRef struct {
+	teamID, periodID, version string
+	v2                        bool
+}
+
+func parseVersionRef(ref string) (versionRef, error) {
+	result := versionRef{}
+	path := ref
+	if i := strings.Index(ref, "@"); i >= 0 {
+		path, result.version = ref[:i], ref[i+1:]
+		result.version, result.v2 = strings.CutPrefix(result.version, version2Prefix)
+		if result.version == "" {
+			return result, fmt.Errorf("missing version after '@' in '%s'", ref)
+		}
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return result, fmt.Errorf("invalid version reference '%s', expected team/period or team/period@version", ref)
+	}
+	result.teamID, result.periodID = parts[0], parts[1]
+	return result, nil
+}
+
+// resolveVersionRef fetches the version of a period named by a reference, checking that the
+// user can read it. Versions from the version history are also returned as a Period2. If the
+// version can't be found, it writes an error response and returns false.
+func (s *Server) resolveVersionRef(w http.ResponseWriter, r *http.Request, ref string) (*models.Period, *models.Period2, bool) {
+	parsed, err := parseVersionRef(ref)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if parsed.v2 {
+		period, ok := s.findPeriodVersion2(w, r, parsed.teamID, parsed.periodID, parsed.version)
+		if !ok {
+			return nil, nil, false
+		}
+		return period.AsPeriod(), period, true
+	}
+	team, exists := s.ensureTeamExistence(w, r, parsed.teamID, true)
+	if !exists {
+		return nil, nil, false
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		http.Error(w, fmt.Sprintf("You are not authorized to view the periods of team '%s'.", parsed.teamID), http.StatusForbidden)
+		return nil, nil, false
+	}
+	period, ok := s.ensurePeriodExistence(w, r, parsed.teamID, parsed.periodID, true)
+	if !ok {
+		return nil, nil, false
+	}
+	period, ok = s.findPeriodVersion(w, r, parsed.teamID, period, parsed.version)
+	return period, nil, ok
+}
+
+// findPeriodVersion2 returns a version from the version history of a period, given its ID or
+// the name of a tag, checking that the user can read it. If the version can't be found, it
+// writes an error response and returns false.
+func (s *Server) findPeriodVersion2(w http.ResponseWriter, r *http.Request, teamID, periodID, version string) (*models.Period2, bool) {
+	if s.store2 == nil {
+		http.Error(w, "Period version history is not available with this storage backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return nil, false
+	}
+	version, ok := s.resolveTag2(w, r, teamID, periodID, version)
+	if !ok {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	period, err := s.store2.GetPeriodVersion(ctx, teamID, periodID, version)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve version '%s' of period '%s' for team '%s'", version, periodID, teamID))
+		return nil, false
+	}
+	return period, true
+}
+
+// findPeriodVersion returns a version of a period, given the ID of a backup, the name of a tag,
+// or an empty string for the current version. If the version can't be found, it writes an
+// error response and returns false.
+func (s *Server) findPeriodVersion(w http.ResponseWriter, r *http.Request, teamID string, period *models.Period, version string) (*models.Period, bool) {
+	if version == "" || version == period.LastUpdateUUID {
+		return period, true
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	backups, _, err := s.store.GetPeriodBackups(ctx, teamID, period.ID)
+	if err != nil {
+		log.Printf("Could not retrieve backups of period '%s' for team '%s': error: %s", period.ID, teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve backups of period '%s' for team '%s' (see server log)", period.ID, teamID), http.StatusInternalServerError)
+		return nil, false
+	}
+	backupID := models.ResolveTag(backups.Tags, version)
+	if backupID == period.LastUpdateUUID {
+		return period, true
+	}
+	if backup, found := backups.FindBackup(backupID); found {
+		return &backup.Period, true
+	}
+	http.Error(w, fmt.Sprintf("Version '%s' of period '%s' for team '%s' not found", version, period.ID, teamID), http.StatusNotFound)
+	return nil, false
+}
+
+// handleGetDiff compares the two versions given by the "from" and "to" parameters,
+// writing the changes as JSON, or as text if format=text is given
+func (s *Server) handleGetDiff(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		http.Error(w, fmt.Sprintf("Unknown format '%s', expected json or text", format), http.StatusBadRequest)
+		return
+	}
+	if query.Get("from") == "" || query.Get("to") == "" {
+		http.Error(w, "Both 'from' and 'to' versions must be specified", http.StatusBadRequest)
+		return
+	}
+	from, from2, ok := s.resolveVersionRef(w, r, query.Get("from"))
+	if !ok {
+		return
+	}
+	to, to2, ok := s.resolveVersionRef(w, r, query.Get("to"))
+	if !ok {
+		return
+	}
+	var changes []diff.Change
+	if from2 != nil && to2 != nil {
+		changes = diff.ComparePeriod2s(from2, to2)
+	} else {
+		changes = diff.ComparePeriods(from, to)
+	}
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, diff.Text(changes))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(changes)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/audit"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// handlePostPeriodState changes the lifecycle state of a period, provided it hasn't been edited
+// since the browser loaded it, in a transaction so that it can't undo concurrent changes
+func (s *Server) handlePostPeriodState(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	request := models.StateChangeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !models.IsValidPeriodState(request.State) {
+		http.Error(w, fmt.Sprintf("Illegal period state '%s'", request.State), http.StatusBadRequest)
+		return
+	}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	if _, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true); !ok {
+		return
+	}
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to edit this team's periods.", http.StatusForbidden)
+		return
+	}
+	canReopen := s.auth.CanActOnTeam(user, team, auth.ActionAdmin)
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	var previous, period models.Period
+	var from string
+	err := s.store.ModifyPeriod(ctx, teamID, periodID, request.LastUpdateUUID, func(saved *models.Period) error {
+		from = saved.LifecycleState()
+		if from == models.PeriodStateClosed && !canReopen {
+			return statusError{"You are not authorized to reopen this team's closed periods.", http.StatusForbidden}
+		}
+		if !models.CanTransitionPeriodState(from, request.State) {
+			return statusError{fmt.Sprintf("Cannot change period state from '%s' to '%s'", from, request.State), http.StatusBadRequest}
+		}
+		previous = *saved
+		saved.State = request.State
+		saved.StateHistory = append(append([]models.StateTransition{}, saved.StateHistory...), models.StateTransition{
+			From: from,
+			To:   request.State,
+			User: user.Identity(),
+			Time: time.Now(),
+		})
+		saved.LastUpdateUUID = uuid.New().String()
+		saved.LastUpdateTime = time.Now()
+		period = *saved
+		return nil
+	})
+	if err != nil {
+		writeModifyError(w, err, fmt.Sprintf("update period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	log.Printf("Period '%s' for team '%s' changed from %s to %s by %s", periodID, teamID, from, request.State, user.Identity())
+	if err := s.backupPeriod(ctx, teamID, periodID, &previous); err != nil {
+		log.Printf("WARNING: Could not back up period '%s' for team '%s': %s", periodID, teamID, err)
+	}
+	s.recordAudit(ctx, r, teamID, periodID, audit.SummarizePeriodChange(&previous, &period))
+	s.writePeriodUpdateResponse(w, r, &period)
+}
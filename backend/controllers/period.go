@@ -1,4 +1,4 @@
-// Copyright 2020-2021, 2023 Google LLC
+// Copyright 2020-2021, 2023, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"peoplemath/audit"
 	"peoplemath/auth"
 	"peoplemath/models"
+	"peoplemath/storage"
 	"time"
 
 	"github.com/google/uuid"
@@ -37,6 +39,9 @@ func (s *Server) ensurePeriodExistence(w http.ResponseWriter, r *http.Request, t
 		http.Error(w, fmt.Sprintf("Could not validate existence of period '%s' for team '%s' (see server log)", periodID, teamID), http.StatusInternalServerError)
 		return period, false
 	}
+	if expected && !exists && s.redirectRenamed(w, r, teamID, periodID) {
+		return period, false
+	}
 	if exists != expected {
 		statusCode := http.StatusBadRequest
 		if expected {
@@ -59,6 +64,52 @@ func (s *Server) ensureNoConcurrentMod(w http.ResponseWriter, r *http.Request, p
 	return true
 }
 
+// statusError is returned from inside a storage transaction, to abandon it and fail the
+// request with the given status, when the saved version doesn't allow the change
+type statusError struct {
+	message string
+	status  int
+}
+
+func (e statusError) Error() string {
+	return e.message
+}
+
+// writeModifyError reports the failure of a storage transaction to change a period
+func writeModifyError(w http.ResponseWriter, err error, description string) {
+	switch err := err.(type) {
+	case storage.ConcurrentModificationError, storage.TeamRenamingError:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case statusError:
+		http.Error(w, err.Error(), err.status)
+	default:
+		log.Printf("Could not %s: error: %s", description, err)
+		http.Error(w, fmt.Sprintf("Could not %s (see server log)", description), http.StatusInternalServerError)
+	}
+}
+
+// ensureNoOverlap checks that the dates of a new or updated period don't overlap any other period of the team
+func (s *Server) ensureNoOverlap(w http.ResponseWriter, r *http.Request, teamID string, period *models.Period) bool {
+	if !period.HasDates() {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	periods, _, err := s.store.GetAllPeriods(ctx, teamID)
+	if err != nil {
+		log.Printf("Could not retrieve periods for team '%s': error: %s", teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve periods for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+		return false
+	}
+	for _, other := range periods {
+		if other.ID != period.ID && period.Overlaps(&other) {
+			http.Error(w, fmt.Sprintf("Period dates overlap with period '%s' (%s to %s)", other.ID, other.StartDate, other.EndDate), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Server) handleGetAllPeriods(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamID := vars["teamID"]
@@ -72,7 +123,9 @@ func (s *Server) handleGetAllPeriods(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !found {
-		http.NotFound(w, r)
+		if !s.redirectRenamed(w, r, teamID, "") {
+			http.NotFound(w, r)
+		}
 		return
 	}
 	user := r.Context().Value(auth.ContextKey("user")).(models.User)
@@ -87,6 +140,11 @@ func (s *Server) handleGetAllPeriods(w http.ResponseWriter, r *http.Request) {
 			http.NotFound(w, r)
 			return
 		}
+		models.SortPeriods(periods)
+		for i := range periods {
+			// Periods saved before lifecycle states were introduced are drafts
+			periods[i].State = periods[i].LifecycleState()
+		}
 		enc := json.NewEncoder(w)
 		w.Header().Set("Content-Type", "application/json")
 		enc.Encode(periods)
@@ -99,6 +157,10 @@ func (s *Server) handleGetPeriod(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamID := vars["teamID"]
 	periodID := vars["periodID"]
+	asOf, hasAsOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
 	defer cancel()
 
@@ -109,7 +171,9 @@ func (s *Server) handleGetPeriod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !found {
-		http.NotFound(w, r)
+		if !s.redirectRenamed(w, r, teamID, "") {
+			http.NotFound(w, r)
+		}
 		return
 	}
 	user := r.Context().Value(auth.ContextKey("user")).(models.User)
@@ -121,7 +185,61 @@ func (s *Server) handleGetPeriod(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if !found {
+			if !s.redirectRenamed(w, r, teamID, periodID) {
+				http.NotFound(w, r)
+			}
+			return
+		}
+		if hasAsOf {
+			if period, ok = s.periodAsOf(w, r, teamID, period, asOf); !ok {
+				return
+			}
+		}
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json")
+		enc.Encode(period)
+	} else {
+		http.Error(w, "You are not authorized to view this team's periods.", http.StatusForbidden)
+	}
+}
+
+// handleGetCurrentPeriod returns the period whose dates include today, or the date given
+func (s *Server) handleGetCurrentPeriod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format(models.DateFormat)
+	} else if _, err := time.Parse(models.DateFormat, date); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", date), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	team, found, err := s.store.GetTeam(ctx, teamID)
+	if err != nil {
+		log.Printf("Could not retrieve team: %v", err)
+		http.Error(w, "Could not retrieve team", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		if !s.redirectRenamed(w, r, teamID, "") {
 			http.NotFound(w, r)
+		}
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		periods, _, err := s.store.GetAllPeriods(ctx, teamID)
+		if err != nil {
+			log.Printf("Could not retrieve periods for team '%s': error: %s", teamID, err)
+			http.Error(w, fmt.Sprintf("Could not retrieve periods for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+			return
+		}
+		period, found := models.FindPeriodForDate(periods, date)
+		if !found {
+			http.Error(w, fmt.Sprintf("No period for team '%s' includes %s", teamID, date), http.StatusNotFound)
 			return
 		}
 		enc := json.NewEncoder(w)
@@ -153,22 +271,47 @@ func (s *Server) handlePostPeriod(w http.ResponseWriter, r *http.Request) {
 	if _, ok := s.ensurePeriodExistence(w, r, teamID, period.ID, false); !ok {
 		return
 	}
-	period.LastUpdateUUID = uuid.New().String()
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to add new periods for this team.", http.StatusForbidden)
+		return
+	}
+	// Only after authorization, as the error names the overlapping period
+	if !s.ensureNoOverlap(w, r, teamID, period) {
+		return
+	}
 	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
 	defer cancel()
-
-	user := r.Context().Value(auth.ContextKey("user")).(models.User)
-	if s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
-		err := s.store.CreatePeriod(ctx, teamID, period)
+	if templateID := r.URL.Query().Get("template"); templateID != "" {
+		template, found, err := s.findTemplateForTeam(ctx, teamID, templateID)
 		if err != nil {
-			log.Printf("Could not create period for team '%s': error: %s", teamID, err)
-			http.Error(w, fmt.Sprintf("Could not create period for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+			log.Printf("Could not retrieve template '%s' for team '%s': error: %s", templateID, teamID, err)
+			http.Error(w, fmt.Sprintf("Could not retrieve template '%s' (see server log)", templateID), http.StatusInternalServerError)
 			return
 		}
-		s.writePeriodUpdateResponse(w, r, period)
-	} else {
-		http.Error(w, "You are not authorized to add new periods for this team.", http.StatusForbidden)
+		if !found {
+			http.Error(w, fmt.Sprintf("Template '%s' not found", templateID), http.StatusBadRequest)
+			return
+		}
+		applyTemplate(period, template)
+	}
+	period.State = models.PeriodStateDraft
+	period.StateHistory = nil
+	period.Review = nil
+	period.LastUpdateUUID = uuid.New().String()
+	period.LastUpdateTime = time.Now()
+
+	err := s.store.CreatePeriod(ctx, teamID, period)
+	if err != nil {
+		if writeDeletedConflict(w, err) || writeRenamingConflict(w, err) {
+			return
+		}
+		log.Printf("Could not create period for team '%s': error: %s", teamID, err)
+		http.Error(w, fmt.Sprintf("Could not create period for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+		return
 	}
+	s.recordAudit(ctx, r, teamID, period.ID, audit.SummarizePeriodChange(nil, period))
+	s.writePeriodUpdateResponse(w, r, period)
 }
 
 func (s *Server) handlePutPeriod(w http.ResponseWriter, r *http.Request) {
@@ -187,26 +330,63 @@ func (s *Server) handlePutPeriod(w http.ResponseWriter, r *http.Request) {
 	if !ok || !s.ensureNoConcurrentMod(w, r, period, savedPeriod) {
 		return
 	}
-	period.LastUpdateUUID = uuid.New().String()
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to edit this team's periods.", http.StatusForbidden)
+		return
+	}
+	// Only after authorization, as the error names the overlapping period
+	if !s.ensureNoOverlap(w, r, teamID, period) {
+		return
+	}
+	s.updatePeriod(w, r, team, savedPeriod, period)
+}
+
+// updatePeriod replaces a saved period with an edited version, backing up the saved one.
+// Any extra summary lines are added to the start of the audit entry.
+func (s *Server) updatePeriod(w http.ResponseWriter, r *http.Request, team models.Team, savedPeriod, period *models.Period, summary ...string) {
+	teamID := team.ID
+	periodID := savedPeriod.ID
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to edit this team's periods.", http.StatusForbidden)
+		return
+	}
+	canEditClosed := s.auth.CanActOnTeam(user, team, auth.ActionAdmin)
 	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
 	defer cancel()
 
-	user := r.Context().Value(auth.ContextKey("user")).(models.User)
-	if s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
-		err := s.store.UpdatePeriod(ctx, teamID, period)
-		if err != nil {
-			log.Printf("Could not update period '%s' for team '%s': error: %s", periodID, teamID, err)
-			http.Error(w, fmt.Sprintf("Could not update period '%s' for team '%s' (see server log)", periodID, teamID), http.StatusInternalServerError)
-			return
+	// The state and review may have changed since savedPeriod was read, without changing
+	// the LastUpdateUUID, so they're taken from the version in the transaction
+	var previous models.Period
+	err := s.store.ModifyPeriod(ctx, teamID, periodID, savedPeriod.LastUpdateUUID, func(saved *models.Period) error {
+		if saved.LifecycleState() == models.PeriodStateClosed && !canEditClosed {
+			return statusError{"This period is closed, and you are not authorized to edit this team's closed periods.", http.StatusForbidden}
 		}
-		err = s.backupPeriod(ctx, teamID, periodID, savedPeriod)
-		if err != nil {
-			log.Printf("WARNING: Could not back up period '%s' for team '%s': %s", periodID, teamID, err)
+		previous = *saved
+		// The lifecycle state and review can only be changed through their own endpoints
+		period.State = saved.State
+		period.StateHistory = saved.StateHistory
+		period.LastUpdateUUID = uuid.New().String()
+		period.LastUpdateTime = time.Now()
+		period.Review = saved.Review
+		if period.Review != nil {
+			// Reviewers need to look at the plan again after any edit
+			period.Review = period.Review.Invalidate(user.Identity(), period.LastUpdateUUID, period.LastUpdateTime)
 		}
-		s.writePeriodUpdateResponse(w, r, period)
-	} else {
-		http.Error(w, "You are not authorized to edit this team's periods.", http.StatusForbidden)
+		*saved = *period
+		return nil
+	})
+	if err != nil {
+		writeModifyError(w, err, fmt.Sprintf("update period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	err = s.backupPeriod(ctx, teamID, periodID, &previous)
+	if err != nil {
+		log.Printf("WARNING: Could not back up period '%s' for team '%s': %s", periodID, teamID, err)
 	}
+	s.recordAudit(ctx, r, teamID, periodID, append(summary, audit.SummarizePeriodChange(&previous, period)...))
+	s.writePeriodUpdateResponse(w, r, period)
 }
 
 func (s *Server) backupPeriod(ctx context.Context, teamID, periodID string, period *models.Period) error {
@@ -222,21 +402,11 @@ func (s *Server) backupPeriod(ctx context.Context, teamID, periodID string, peri
 		Period:    *period,
 	}
 	backups.Backups = append(backups.Backups, backup)
-	purgeOldBackups(&backups)
-	log.Printf("Backup %s %s: %d backups, oldest %s, newest %s", teamID, periodID, len(backups.Backups), backups.Backups[0].Timestamp.Format(time.RFC3339), backups.Backups[len(backups.Backups)-1].Timestamp.Format(time.RFC3339))
+	// The storage applies the retention policy to remove old backups
+	log.Printf("Backup %s %s: %d backups before pruning, oldest %s, newest %s", teamID, periodID, len(backups.Backups), backups.Backups[0].Timestamp.Format(time.RFC3339), backups.Backups[len(backups.Backups)-1].Timestamp.Format(time.RFC3339))
 	return s.store.UpsertPeriodBackups(ctx, teamID, periodID, backups)
 }
 
-const backupsToKeep = 10
-
-func purgeOldBackups(backups *models.PeriodBackups) {
-	// Just keep the last N
-	if len(backups.Backups) > backupsToKeep {
-		toRemove := len(backups.Backups) - backupsToKeep
-		backups.Backups = backups.Backups[toRemove:]
-	}
-}
-
 func readPeriodFromBody(w http.ResponseWriter, r *http.Request) (*models.Period, bool) {
 	dec := json.NewDecoder(r.Body)
 	period := models.Period{}
@@ -245,6 +415,10 @@ func readPeriodFromBody(w http.ResponseWriter, r *http.Request) (*models.Period,
 		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
 		return &period, false
 	}
+	if err := period.ValidateDates(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid period dates: %v", err), http.StatusBadRequest)
+		return &period, false
+	}
 	for _, bucket := range period.Buckets {
 		if bucket.AllocationType != "" {
 			if bucket.AllocationType != models.AllocationTypePercentage && bucket.AllocationType != models.AllocationTypeAbsolute {
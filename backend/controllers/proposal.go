@@ -0,0 +1,57 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/solver"
+
+	"github.com/gorilla/mux"
+)
+
+// handlePostProposal proposes assignments for the period in the request body.
+// The period may include unsaved changes; nothing is written to the store.
+func (s *Server) handlePostProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	period, ok := readPeriodFromBody(w, r)
+	if !ok {
+		return
+	}
+	if period.ID != periodID {
+		http.Error(w, fmt.Sprintf("Period ID '%s' in body does not match URL", period.ID), http.StatusBadRequest)
+		return
+	}
+	options := solver.Options{ClearExisting: r.URL.Query().Get("clearExisting") == "true"}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		proposal := solver.Propose(period, options)
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json")
+		enc.Encode(proposal)
+	} else {
+		http.Error(w, "You are not authorized to view this team's periods.", http.StatusForbidden)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Renaming a team or period changes its ID, and leaves an alias at the old ID so that
+// requests using it are redirected. This needs admin permission on the team.
+
+// redirectRenamed looks for an alias left behind by renaming the team, or the period if one
+// is given, and if there is one, redirects to the same route with the new ID. Only IDs
+// which come from the URL are redirected.
+func (s *Server) redirectRenamed(w http.ResponseWriter, r *http.Request, teamID, periodID string) bool {
+	vars := mux.Vars(r)
+	route := mux.CurrentRoute(r)
+	key, id := "teamID", teamID
+	if periodID != "" {
+		key, id = "periodID", periodID
+	}
+	if route == nil || vars["teamID"] != teamID || vars[key] != id {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	var alias models.Alias
+	var found bool
+	var err error
+	if periodID == "" {
+		alias, found, err = s.store.GetTeamAlias(ctx, teamID)
+	} else {
+		alias, found, err = s.store.GetPeriodAlias(ctx, teamID, periodID)
+	}
+	if err != nil {
+		log.Printf("Could not look up alias for '%s': error: %s", id, err)
+		return false
+	}
+	if !found {
+		return false
+	}
+	var pairs []string
+	for k, v := range vars {
+		if k == key {
+			v = alias.NewID
+		}
+		pairs = append(pairs, k, v)
+	}
+	target, err := route.URLPath(pairs...)
+	if err != nil {
+		log.Printf("Could not build redirect for renamed '%s': error: %s", id, err)
+		return false
+	}
+	target.RawQuery = r.URL.RawQuery
+	// 308 rather than 301, so that the method and body are kept
+	http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	return true
+}
+
+// readRenameRequest reads the new ID from the body. If it is missing or invalid, it
+// writes an error response and returns false.
+func readRenameRequest(w http.ResponseWriter, r *http.Request, oldID string) (string, bool) {
+	request := models.RenameRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return "", false
+	}
+	if err := models.ValidateID(request.NewID); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid new ID: %s", err), http.StatusBadRequest)
+		return "", false
+	}
+	if request.NewID == oldID {
+		http.Error(w, fmt.Sprintf("New ID is the same as the old ID '%s'", oldID), http.StatusBadRequest)
+		return "", false
+	}
+	return request.NewID, true
+}
+
+// isRenameConflict indicates whether a rename failed because the new ID is taken
+func isRenameConflict(err error) bool {
+	switch err.(type) {
+	case storage.TeamExistsError, storage.PeriodExistsError, storage.DeletedError, storage.TeamRenamingError:
+		return true
+	}
+	return false
+}
+
+// writeRenamingConflict writes an error response if a change failed because the team is
+// part way through being renamed, and returns whether it did
+func writeRenamingConflict(w http.ResponseWriter, err error) bool {
+	if _, ok := err.(storage.TeamRenamingError); ok {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return true
+	}
+	return false
+}
+
+// writeRenameError writes an error response for a rename which failed
+func writeRenameError(w http.ResponseWriter, err error, description string) {
+	if writeDeletedConflict(w, err) {
+		return
+	}
+	switch {
+	case isRenameConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Could not rename %s: error: %s", description, err)
+		http.Error(w, fmt.Sprintf("Could not rename %s (see server log)", description), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleRenameTeam(w http.ResponseWriter, r *http.Request) {
+	teamID := mux.Vars(r)["teamID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	newTeamID, ok := readRenameRequest(w, r, teamID)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionAdmin) {
+		http.Error(w, "You are not authorized to rename this team.", http.StatusForbidden)
+		return
+	}
+	// The version history is renamed first. If renaming the rest then fails part way, the
+	// team can be renamed again: the version history is no longer found under the old ID,
+	// and the rest of the rename carries on from where it stopped.
+	renamed2 := false
+	if s.store2 != nil {
+		err := s.store2.RenameTeam(ctx, teamID, newTeamID)
+		if _, notFound := err.(storage.TeamNotFoundError); err != nil && !notFound {
+			writeRenameError(w, err, fmt.Sprintf("team '%s' in version history", teamID))
+			return
+		}
+		renamed2 = err == nil
+	}
+	alias := models.Alias{NewID: newTeamID, User: user.Identity(), Timestamp: time.Now()}
+	if err := s.store.RenameTeam(ctx, teamID, newTeamID, alias); err != nil {
+		if renamed2 && isRenameConflict(err) {
+			// Nothing else was renamed, so the version history is put back
+			if err := s.store2.RenameTeam(ctx, newTeamID, teamID); err != nil {
+				log.Printf("WARNING: Could not undo renaming team '%s' in version history: %s", teamID, err)
+			}
+		}
+		writeRenameError(w, err, fmt.Sprintf("team '%s'", teamID))
+		return
+	}
+	log.Printf("Renamed team '%s' to '%s'", teamID, newTeamID)
+	s.recordAudit(ctx, r, newTeamID, "", []string{fmt.Sprintf("Renamed team from '%s' to '%s'", teamID, newTeamID)})
+	team.ID = newTeamID
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(team)
+}
+
+func (s *Server) handleRenamePeriod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	period, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true)
+	if !ok {
+		return
+	}
+	newPeriodID, ok := readRenameRequest(w, r, periodID)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionAdmin) {
+		http.Error(w, "You are not authorized to rename this team's periods.", http.StatusForbidden)
+		return
+	}
+	// The version history is renamed first, and put back if renaming the period fails,
+	// so that a failed rename can be retried
+	renamed2 := false
+	if s.store2 != nil {
+		err := s.store2.RenamePeriod(ctx, teamID, periodID, newPeriodID)
+		switch err.(type) {
+		case nil:
+			renamed2 = true
+		case storage.TeamNotFoundError, storage.PeriodNotFoundError:
+		default:
+			writeRenameError(w, err, fmt.Sprintf("period '%s' for team '%s' in version history", periodID, teamID))
+			return
+		}
+	}
+	alias := models.Alias{NewID: newPeriodID, User: user.Identity(), Timestamp: time.Now()}
+	if err := s.store.RenamePeriod(ctx, teamID, periodID, newPeriodID, alias); err != nil {
+		if renamed2 {
+			if err := s.store2.RenamePeriod(ctx, teamID, newPeriodID, periodID); err != nil {
+				log.Printf("WARNING: Could not undo renaming period '%s' for team '%s' in version history: %s", periodID, teamID, err)
+			}
+		}
+		writeRenameError(w, err, fmt.Sprintf("period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	log.Printf("Renamed period '%s' to '%s' for team '%s'", periodID, newPeriodID, teamID)
+	s.recordAudit(ctx, r, teamID, newPeriodID, []string{fmt.Sprintf("Renamed period from '%s' to '%s'", periodID, newPeriodID)})
+	period.ID = newPeriodID
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(period)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/report"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleGetPeriodReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = report.FormatHTML
+	}
+	if format != report.FormatHTML && format != report.FormatMarkdown {
+		http.Error(w, fmt.Sprintf("Unsupported report format '%s'", format), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	if version, ok := strings.CutPrefix(r.URL.Query().Get("version"), version2Prefix); ok {
+		period, found := s.findPeriodVersion2(w, r, teamID, periodID, version)
+		if !found {
+			return
+		}
+		team, err := s.store2.GetTeam(ctx, teamID)
+		if err != nil {
+			writeStorage2Error(w, err, fmt.Sprintf("retrieve team '%s'", teamID))
+			return
+		}
+		writeReport(w, format, team, period.AsPeriod())
+		return
+	}
+
+	team, found, err := s.store.GetTeam(ctx, teamID)
+	if err != nil {
+		log.Printf("Could not retrieve team: %v", err)
+		http.Error(w, "Could not retrieve team", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		if !s.redirectRenamed(w, r, teamID, "") {
+			http.NotFound(w, r)
+		}
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view this team's periods.", http.StatusForbidden)
+		return
+	}
+	period, found, err := s.store.GetPeriod(ctx, teamID, periodID)
+	if err != nil {
+		log.Printf("Could not retrieve period '%s' for team '%s': error: %s", periodID, teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve period '%s' for team '%s' (see server log)", periodID, teamID), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		if !s.redirectRenamed(w, r, teamID, periodID) {
+			http.NotFound(w, r)
+		}
+		return
+	}
+	period, found = s.findPeriodVersion(w, r, teamID, period, r.URL.Query().Get("version"))
+	if !found {
+		return
+	}
+
+	writeReport(w, format, team, period)
+}
+
+func writeReport(w http.ResponseWriter, format string, team models.Team, period *models.Period) {
+	// Render to a buffer first, so that a rendering failure can still produce an error status
+	var b bytes.Buffer
+	var err error
+	contentType := "text/html; charset=utf-8"
+	if format == report.FormatMarkdown {
+		contentType = "text/markdown; charset=utf-8"
+		err = report.RenderMarkdown(&b, team, period)
+	} else {
+		err = report.RenderHTML(&b, team, period)
+	}
+	if err != nil {
+		log.Printf("Could not render report for period '%s' for team '%s': error: %s", period.ID, team.ID, err)
+		http.Error(w, "Could not render report (see server log)", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(b.Bytes())
+}
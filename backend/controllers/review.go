@@ -0,0 +1,169 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/audit"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Submitting a period for review and recording reviewers' decisions don't change
+// the plan itself, so they leave the LastUpdateUUID alone, and don't interrupt
+// anyone who is editing the period. They do fail if the period has been edited
+// since it was loaded, as the review would be of the wrong version. Like any other
+// change, they update the LastUpdateTime and back up the previous version.
+
+// saveReview updates only the review of the period, provided the period hasn't been edited
+// since it was loaded, so that neither edits nor other reviewers' decisions are lost
+func (s *Server) saveReview(w http.ResponseWriter, r *http.Request, teamID string, period *models.Period, update func(review *models.Review) (*models.Review, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	var previous, updated models.Period
+	err := s.store.ModifyPeriod(ctx, teamID, period.ID, period.LastUpdateUUID, func(saved *models.Period) error {
+		previous = *saved
+		review, err := update(saved.Review)
+		if err != nil {
+			return err
+		}
+		saved.Review = review
+		saved.LastUpdateTime = time.Now()
+		updated = *saved
+		return nil
+	})
+	if err != nil {
+		writeModifyError(w, err, fmt.Sprintf("update review of period '%s' for team '%s'", period.ID, teamID))
+		return
+	}
+	log.Printf("Review of period '%s' for team '%s' is now %s", period.ID, teamID, updated.Review.Status)
+	if err := s.backupPeriod(ctx, teamID, period.ID, &previous); err != nil {
+		log.Printf("WARNING: Could not back up period '%s' for team '%s': %s", period.ID, teamID, err)
+	}
+	s.recordAudit(ctx, r, teamID, period.ID, audit.SummarizePeriodChange(&previous, &updated))
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(updated.Review)
+}
+
+func (s *Server) handlePostReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	request := models.ReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(request.Reviewers) == 0 {
+		http.Error(w, "At least one reviewer must be specified", http.StatusBadRequest)
+		return
+	}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	period, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true)
+	if !ok {
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to submit this team's periods for review.", http.StatusForbidden)
+		return
+	}
+
+	s.saveReview(w, r, teamID, period, func(saved *models.Review) (*models.Review, error) {
+		// Resubmitting starts a new review, but keeps the history of the old one
+		review := &models.Review{Reviewers: request.Reviewers}
+		if saved != nil {
+			review.Events = saved.Events
+		}
+		return review.AddEvent(models.ReviewEvent{
+			Type:    models.ReviewEventSubmitted,
+			User:    user.Identity(),
+			Version: period.LastUpdateUUID,
+			Time:    time.Now(),
+		}), nil
+	})
+}
+
+func (s *Server) handlePostReviewDecision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	decision := models.ReviewDecision{}
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !models.IsValidReviewDecision(decision.Decision) {
+		http.Error(w, fmt.Sprintf("Illegal review decision '%s'", decision.Decision), http.StatusBadRequest)
+		return
+	}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	period, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true)
+	if !ok {
+		return
+	}
+	if period.Review == nil {
+		http.Error(w, fmt.Sprintf("Period '%s' for team '%s' has not been submitted for review", periodID, teamID), http.StatusBadRequest)
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	var matched []models.UserMatcher
+	for _, reviewer := range period.Review.Reviewers {
+		if s.auth.IsPermitted(user, []models.UserMatcher{reviewer}) {
+			matched = append(matched, reviewer)
+		}
+	}
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) || len(matched) == 0 {
+		http.Error(w, "You are not a reviewer of this period.", http.StatusForbidden)
+		return
+	}
+	// Make sure the reviewer has seen the latest version of the plan
+	if decision.LastUpdateUUID != period.LastUpdateUUID {
+		msg := fmt.Sprintf("Concurrent modification: last saved UUID=%s, your last loaded UUID=%s",
+			period.LastUpdateUUID, decision.LastUpdateUUID)
+		http.Error(w, msg, http.StatusConflict)
+		return
+	}
+
+	s.saveReview(w, r, teamID, period, func(saved *models.Review) (*models.Review, error) {
+		// The period may have been resubmitted to other reviewers since it was loaded
+		if saved == nil || !slices.Equal(saved.Reviewers, period.Review.Reviewers) {
+			return nil, statusError{"The reviewers of this period have changed. Please reload it.", http.StatusConflict}
+		}
+		return saved.AddEvent(models.ReviewEvent{
+			Type:    decision.Decision,
+			User:    user.Identity(),
+			Comment: decision.Comment,
+			Version: period.LastUpdateUUID,
+			Time:    time.Now(),
+			Matched: matched,
+		}), nil
+	})
+}
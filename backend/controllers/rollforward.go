@@ -0,0 +1,146 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleRollForward(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	request := models.RollForwardRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.ID == "" {
+		http.Error(w, "New period ID must be specified", http.StatusBadRequest)
+		return
+	}
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	source, ok := s.ensurePeriodExistence(w, r, teamID, periodID, true)
+	if !ok {
+		return
+	}
+	if _, ok := s.ensurePeriodExistence(w, r, teamID, request.ID, false); !ok {
+		return
+	}
+	period := rollForward(source, request)
+	period.LastUpdateUUID = uuid.New().String()
+	period.LastUpdateTime = time.Now()
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		err := s.store.CreatePeriod(ctx, teamID, period)
+		if err != nil {
+			if writeDeletedConflict(w, err) || writeRenamingConflict(w, err) {
+				return
+			}
+			log.Printf("Could not create period for team '%s': error: %s", teamID, err)
+			http.Error(w, fmt.Sprintf("Could not create period for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+			return
+		}
+		s.recordAudit(ctx, r, teamID, period.ID, []string{fmt.Sprintf("Rolled forward period '%s' from '%s'", period.ID, periodID)})
+		s.writePeriodUpdateResponse(w, r, period)
+	} else {
+		http.Error(w, "You are not authorized to add new periods for this team.", http.StatusForbidden)
+	}
+}
+
+func isSelected(selection models.ObjectiveSelection, ref models.ObjectiveRef, objective models.Objective) bool {
+	if selection.All || (selection.Committed && objective.CommitmentType == models.CommitmentTypeCommitted) {
+		return true
+	}
+	for _, selected := range selection.Objectives {
+		if selected == ref {
+			return true
+		}
+	}
+	for _, tag := range objective.Tags {
+		for _, selected := range selection.Tags {
+			if tag.Name == selected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rollForward makes a new period with the same people, buckets and units as the source,
+// carrying over the selected objectives
+func rollForward(source *models.Period, request models.RollForwardRequest) *models.Period {
+	period := &models.Period{
+		ID:                     request.ID,
+		DisplayName:            request.DisplayName,
+		Unit:                   source.Unit,
+		UnitAbbrev:             source.UnitAbbrev,
+		NotesURL:               source.NotesURL,
+		MaxCommittedPercentage: source.MaxCommittedPercentage,
+		SecondaryUnits:         append([]models.SecondaryUnit{}, source.SecondaryUnits...),
+		People:                 append([]models.Person{}, source.People...),
+		Buckets:                []models.Bucket{},
+		State:                  models.PeriodStateDraft,
+	}
+	if period.DisplayName == "" {
+		period.DisplayName = request.ID
+	}
+	if request.ResetAvailability {
+		for i := range period.People {
+			period.People[i].Availability = request.Availability
+		}
+	}
+
+	for b, bucket := range source.Buckets {
+		newBucket := bucket
+		newBucket.Objectives = []models.Objective{}
+		for o, objective := range bucket.Objectives {
+			ref := models.ObjectiveRef{BucketIndex: b, ObjectiveIndex: o}
+			if !isSelected(request.CarryOver, ref, objective) {
+				continue
+			}
+			objective.CarriedOverFrom = &models.ObjectiveOrigin{
+				PeriodID:       source.ID,
+				BucketIndex:    b,
+				ObjectiveIndex: o,
+				ObjectiveName:  objective.Name,
+			}
+			if request.ClearAssignments {
+				objective.Assignments = []models.Assignment{}
+			} else {
+				objective.Assignments = append([]models.Assignment{}, objective.Assignments...)
+			}
+			newBucket.Objectives = append(newBucket.Objectives, objective)
+		}
+		period.Buckets = append(period.Buckets, newBucket)
+	}
+	return period
+}
@@ -1,4 +1,4 @@
-// Copyright 2020 Google LLC
+// Copyright 2020, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -28,6 +28,8 @@ type Server struct {
 	store        storage.StorageService
 	storeTimeout time.Duration
 	auth         auth.Auth
+	// Storage for the newer versioned period model, if available
+	store2 storage.StorageService2
 }
 
 // MakeServer creates a new instance of the Server
@@ -35,6 +37,12 @@ func MakeServer(store storage.StorageService, storeTimeout time.Duration, auth a
 	return Server{store: store, storeTimeout: storeTimeout, auth: auth}
 }
 
+// UseStorage2 enables the endpoints which use the newer versioned period model,
+// backed by the given store
+func (s *Server) UseStorage2(store2 storage.StorageService2) {
+	s.store2 = store2
+}
+
 // MakeHandler creates a http.Handler to deal with HTTP requests for the application.
 func (s *Server) MakeHandler() http.Handler {
 	r := mux.NewRouter()
@@ -43,11 +51,65 @@ func (s *Server) MakeHandler() http.Handler {
 	r.HandleFunc("/api/team/", s.auth.Authenticate(s.handleGetAllTeams)).Methods(http.MethodGet)
 	r.HandleFunc("/api/team/", s.auth.Authenticate(s.handlePostTeam)).Methods(http.MethodPost)
 	r.HandleFunc("/api/team/{teamID}", s.auth.Authenticate(s.handlePutTeam)).Methods(http.MethodPut)
+	r.HandleFunc("/api/team/{teamID}", s.auth.Authenticate(s.handleDeleteTeam)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/team/{teamID}/rename", s.auth.Authenticate(s.handleRenameTeam)).Methods(http.MethodPost)
+	r.HandleFunc("/api/team/{teamID}/audit", s.auth.Authenticate(s.handleGetAuditLog)).Methods(http.MethodGet)
+	r.HandleFunc("/api/team/{teamID}/template/", s.auth.Authenticate(s.handleGetTeamTemplates)).Methods(http.MethodGet)
+	r.HandleFunc("/api/team/{teamID}/template/", s.auth.Authenticate(s.handleWriteTeamTemplate)).Methods(http.MethodPost)
+	r.HandleFunc("/api/team/{teamID}/template/{templateID}", s.auth.Authenticate(s.handleGetTeamTemplates)).Methods(http.MethodGet)
+	r.HandleFunc("/api/team/{teamID}/template/{templateID}", s.auth.Authenticate(s.handleWriteTeamTemplate)).Methods(http.MethodPut, http.MethodDelete)
+
+	r.HandleFunc("/api/template/", s.auth.Authenticate(s.handleGetOrgTemplates)).Methods(http.MethodGet)
+	r.HandleFunc("/api/template/", s.auth.Authenticate(s.handleWriteOrgTemplate)).Methods(http.MethodPost)
+	r.HandleFunc("/api/template/{templateID}", s.auth.Authenticate(s.handleGetOrgTemplates)).Methods(http.MethodGet)
+	r.HandleFunc("/api/template/{templateID}", s.auth.Authenticate(s.handleWriteOrgTemplate)).Methods(http.MethodPut, http.MethodDelete)
 
+	// Must come before the route for a specific period, since "current" would match {periodID}
+	r.HandleFunc("/api/period/{teamID}/current", s.auth.Authenticate(s.handleGetCurrentPeriod)).Methods(http.MethodGet)
 	r.HandleFunc("/api/period/{teamID}/{periodID}", s.auth.Authenticate(s.handleGetPeriod)).Methods(http.MethodGet)
 	r.HandleFunc("/api/period/{teamID}/", s.auth.Authenticate(s.handleGetAllPeriods)).Methods(http.MethodGet)
 	r.HandleFunc("/api/period/{teamID}/", s.auth.Authenticate(s.handlePostPeriod)).Methods(http.MethodPost)
 	r.HandleFunc("/api/period/{teamID}/{periodID}", s.auth.Authenticate(s.handlePutPeriod)).Methods(http.MethodPut)
+	r.HandleFunc("/api/period/{teamID}/{periodID}", s.auth.Authenticate(s.handleDeletePeriod)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/backup/", s.auth.Authenticate(s.handleGetBackups)).Methods(http.MethodGet)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/backup/{backupID}", s.auth.Authenticate(s.handleGetBackup)).Methods(http.MethodGet)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/backup/{backupID}/restore", s.auth.Authenticate(s.handleRestoreBackup)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/tag/", s.auth.Authenticate(s.handleGetTags)).Methods(http.MethodGet)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/tag/", s.auth.Authenticate(s.handlePostTag)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/tag/{tagName}", s.auth.Authenticate(s.handleDeleteTag)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/audit", s.auth.Authenticate(s.handleGetAuditLog)).Methods(http.MethodGet)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/report", s.auth.Authenticate(s.handleGetPeriodReport)).Methods(http.MethodGet)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/proposal", s.auth.Authenticate(s.handlePostProposal)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/rename", s.auth.Authenticate(s.handleRenamePeriod)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/rollforward", s.auth.Authenticate(s.handleRollForward)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/state", s.auth.Authenticate(s.handlePostPeriodState)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/review", s.auth.Authenticate(s.handlePostReview)).Methods(http.MethodPost)
+	r.HandleFunc("/api/period/{teamID}/{periodID}/review/decision", s.auth.Authenticate(s.handlePostReviewDecision)).Methods(http.MethodPost)
+
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}", s.auth.Authenticate(s.handleGetPeriod2)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/version/", s.auth.Authenticate(s.handleGetPeriodVersions)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/version/{version}", s.auth.Authenticate(s.handleGetPeriodVersion)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/version/{version}/ancestry", s.auth.Authenticate(s.handleGetPeriodAncestry)).Methods(http.MethodGet)
+
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/tag/", s.auth.Authenticate(s.handleGetPeriodTags2)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/tag/", s.auth.Authenticate(s.handlePostPeriodTag2)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/period/{teamID}/{periodID}/tag/{tagName}", s.auth.Authenticate(s.handleDeletePeriodTag2)).Methods(http.MethodDelete)
+
+	r.HandleFunc("/api/deleted/", s.auth.Authenticate(s.handleGetDeletedItems)).Methods(http.MethodGet)
+	r.HandleFunc("/api/deleted/{teamID}/restore", s.auth.Authenticate(s.handleRestoreDeletedItem)).Methods(http.MethodPost)
+	r.HandleFunc("/api/deleted/{teamID}/{periodID}/restore", s.auth.Authenticate(s.handleRestoreDeletedItem)).Methods(http.MethodPost)
+
+	r.HandleFunc("/api/settings", s.auth.Authenticate(s.handleGetSettings)).Methods(http.MethodGet)
+	r.HandleFunc("/api/settings", s.auth.Authenticate(s.handlePutSettings)).Methods(http.MethodPut)
+	r.HandleFunc("/api/settings/audit", s.auth.Authenticate(s.handleGetSettingsAuditLog)).Methods(http.MethodGet)
+
+	r.HandleFunc("/api/apikey/", s.auth.Authenticate(s.handleGetAPIKeys)).Methods(http.MethodGet)
+	r.HandleFunc("/api/apikey/", s.auth.Authenticate(s.handlePostAPIKey)).Methods(http.MethodPost)
+	r.HandleFunc("/api/apikey/{keyID}", s.auth.Authenticate(s.handleDeleteAPIKey)).Methods(http.MethodDelete)
+
+	r.HandleFunc("/api/diff", s.auth.Authenticate(s.handleGetDiff)).Methods(http.MethodGet)
+
+	r.HandleFunc("/api/export/analytics/{table}", s.auth.Authenticate(s.handleGetAnalyticsExport)).Methods(http.MethodGet)
 
 	r.HandleFunc("/improve", s.handleImprove).Methods(http.MethodGet)
 
@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/audit"
+	"peoplemath/auth"
+	"peoplemath/models"
+)
+
+// The settings can be viewed and changed by users with the administer permission in the
+// settings themselves. Changes are recorded in an audit log of their own.
+
+// getAdministrableSettings returns the settings if the user can administer them.
+// Otherwise it writes an error response and returns false.
+func (s *Server) getAdministrableSettings(w http.ResponseWriter, r *http.Request) (models.Settings, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve settings: %v", err)
+		http.Error(w, "Could not retrieve due to internal server error", http.StatusInternalServerError)
+		return settings, false
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeamList(user, settings.GeneralPermissions, auth.ActionAdmin) {
+		http.Error(w, "You are not authorized to administer the settings.", http.StatusForbidden)
+		return settings, false
+	}
+	return settings, true
+}
+
+func writeSettings(w http.ResponseWriter, settings models.Settings) {
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(settings)
+}
+
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.getAdministrableSettings(w, r)
+	if !ok {
+		return
+	}
+	writeSettings(w, settings)
+}
+
+// handlePutSettings replaces all of the settings. Changes which would stop the user from
+// administering the settings are rejected, so that they can't lock themselves out.
+func (s *Server) handlePutSettings(w http.ResponseWriter, r *http.Request) {
+	previous, ok := s.getAdministrableSettings(w, r)
+	if !ok {
+		return
+	}
+	settings := models.Settings{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid settings: %s", err), http.StatusBadRequest)
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeamList(user, settings.GeneralPermissions, auth.ActionAdmin) {
+		http.Error(w, "These settings would remove your own permission to administer them.", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	if err := s.store.UpdateSettings(ctx, settings); err != nil {
+		log.Printf("Could not save settings: error: %s", err)
+		http.Error(w, "Could not save settings (see server log)", http.StatusInternalServerError)
+		return
+	}
+	if s.store2 != nil {
+		if err := s.store2.UpdateSettings(ctx, settings); err != nil {
+			log.Printf("WARNING: Could not save settings for version history: %s", err)
+		}
+	}
+	log.Printf("Updated settings")
+	s.recordAudit(ctx, r, "", "", audit.SummarizeSettingsChange(&previous, &settings))
+	writeSettings(w, settings)
+}
+
+func (s *Server) handleGetSettingsAuditLog(w http.ResponseWriter, r *http.Request) {
+	pageSize, ok := parseAuditPageSize(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := s.getAdministrableSettings(w, r); !ok {
+		return
+	}
+	s.writeAuditLog(w, r, "", "", pageSize, "settings")
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Tags on versions of a period are stored with the period's backups, so that the
+// storage can keep tagged backups when pruning.
+
+func (s *Server) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	_, _, backups, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	if backups.Tags == nil {
+		backups.Tags = []models.VersionTag{}
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(backups.Tags)
+}
+
+// saveTags stores a change to a period's tags, made by update to the saved tags in a transaction,
+// so that neither other changes to the tags nor new backups are lost. The update also returns
+// the summary for the audit log. On failure, it writes an error response and returns false.
+func (s *Server) saveTags(w http.ResponseWriter, r *http.Request, teamID, periodID string, update func(backups models.PeriodBackups) ([]models.VersionTag, string, error)) ([]models.VersionTag, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	var tags []models.VersionTag
+	var summary string
+	err := s.store.UpdatePeriodTags(ctx, teamID, periodID, func(backups models.PeriodBackups) ([]models.VersionTag, error) {
+		var err error
+		tags, summary, err = update(backups)
+		return tags, err
+	})
+	if err != nil {
+		writeModifyError(w, err, fmt.Sprintf("save tags of period '%s' for team '%s'", periodID, teamID))
+		return nil, false
+	}
+	s.recordAudit(ctx, r, teamID, periodID, []string{summary})
+	return tags, true
+}
+
+// handlePostTag tags the current version of a period, or one of its backups
+func (s *Server) handlePostTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	request := models.TagRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateTagName(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	team, period, _, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to tag this team's periods.", http.StatusForbidden)
+		return
+	}
+	version := request.Version
+	if version == "" {
+		version = period.LastUpdateUUID
+		if version == "" {
+			http.Error(w, "The current version of this period cannot be tagged until it has been saved", http.StatusBadRequest)
+			return
+		}
+	}
+	tag := models.VersionTag{
+		Name:      request.Name,
+		Version:   version,
+		User:      user.Identity(),
+		Timestamp: time.Now(),
+	}
+	_, ok = s.saveTags(w, r, teamID, periodID, func(backups models.PeriodBackups) ([]models.VersionTag, string, error) {
+		if _, found := backups.FindBackup(version); !found && version != period.LastUpdateUUID {
+			return nil, "", statusError{fmt.Sprintf("Version '%s' of period '%s' for team '%s' not found", version, periodID, teamID), http.StatusNotFound}
+		}
+		if models.FindTag(backups.Tags, request.Name) >= 0 {
+			return nil, "", statusError{fmt.Sprintf("Tag '%s' already exists", request.Name), http.StatusConflict}
+		}
+		tags := append(backups.Tags[:len(backups.Tags):len(backups.Tags)], tag)
+		return tags, fmt.Sprintf("Tagged version '%s' as '%s'", version, tag.Name), nil
+	})
+	if !ok {
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(tag)
+}
+
+func (s *Server) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	tagName := vars["tagName"]
+	team, _, _, ok := s.getReadableBackups(w, r)
+	if !ok {
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to delete this team's tags.", http.StatusForbidden)
+		return
+	}
+	tags, ok := s.saveTags(w, r, teamID, periodID, func(backups models.PeriodBackups) ([]models.VersionTag, string, error) {
+		i := models.FindTag(backups.Tags, tagName)
+		if i < 0 {
+			return nil, "", statusError{fmt.Sprintf("Tag '%s' not found", tagName), http.StatusNotFound}
+		}
+		version := backups.Tags[i].Version
+		tags := append(backups.Tags[:i:i], backups.Tags[i+1:]...)
+		return tags, fmt.Sprintf("Deleted tag '%s' of version '%s'", tagName, version), nil
+	})
+	if !ok {
+		return
+	}
+	if tags == nil {
+		tags = []models.VersionTag{}
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(tags)
+}
@@ -1,4 +1,4 @@
-// Copyright 2020 Google LLC
+// Copyright 2020, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"peoplemath/audit"
 	"peoplemath/auth"
 	"peoplemath/models"
 	"reflect"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -36,6 +38,9 @@ func (s *Server) ensureTeamExistence(w http.ResponseWriter, r *http.Request, tea
 		http.Error(w, fmt.Sprintf("Could not validate existence of team '%s' (see server log)", teamID), http.StatusInternalServerError)
 		return models.Team{}, false
 	}
+	if expected && !exists && s.redirectRenamed(w, r, teamID, "") {
+		return models.Team{}, false
+	}
 	if exists != expected {
 		statusCode := http.StatusBadRequest
 		if expected {
@@ -78,6 +83,10 @@ func (s *Server) handleGetAllTeams(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetTeam(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	teamID := vars["teamID"]
+	asOf, hasAsOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
 	defer cancel()
 	team, found, err := s.store.GetTeam(ctx, teamID)
@@ -87,12 +96,19 @@ func (s *Server) handleGetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !found {
-		http.NotFound(w, r)
+		if !s.redirectRenamed(w, r, teamID, "") {
+			http.NotFound(w, r)
+		}
 		return
 	}
 
 	user := r.Context().Value(auth.ContextKey("user")).(models.User)
 	if s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		if hasAsOf {
+			if team, ok = s.teamAsOf(w, r, team, asOf); !ok {
+				return
+			}
+		}
 		enc := json.NewEncoder(w)
 		w.Header().Set("Content-Type", "application/json")
 		enc.Encode(team)
@@ -124,14 +140,20 @@ func (s *Server) handlePostTeam(w http.ResponseWriter, r *http.Request) {
 		if reflect.DeepEqual(team.Permissions, models.TeamPermissions{}) {
 			team.Permissions.Read = permissions.ReadTeamList
 			team.Permissions.Write = permissions.AddTeam
+			team.Permissions.Admin = permissions.AddTeam
 		}
+		team.LastUpdateTime = time.Now()
 
 		err := s.store.CreateTeam(ctx, team)
 		if err != nil {
+			if writeDeletedConflict(w, err) {
+				return
+			}
 			log.Printf("Could not create team: error: %s", err)
 			http.Error(w, "Could not create team (see server log)", http.StatusInternalServerError)
 			return
 		}
+		s.recordAudit(ctx, r, team.ID, "", audit.SummarizeTeamChange(nil, &team))
 	} else {
 		http.Error(w, "You are not authorized to create a new team.", http.StatusForbidden)
 	}
@@ -151,12 +173,22 @@ func (s *Server) handlePutTeam(w http.ResponseWriter, r *http.Request) {
 
 	user := r.Context().Value(auth.ContextKey("user")).(models.User)
 	if s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		if !reflect.DeepEqual(updatedTeam.Permissions, team.Permissions) && !s.auth.CanActOnTeam(user, team, auth.ActionChangePermissions) {
+			http.Error(w, "Only the owners of this team can change its permissions.", http.StatusForbidden)
+			return
+		}
+		updatedTeam.LastUpdateTime = time.Now()
 		err := s.store.UpdateTeam(ctx, updatedTeam)
 		if err != nil {
 			log.Printf("Could not update team: error: %s", err)
 			http.Error(w, "Could not update team (see server log)", http.StatusInternalServerError)
 			return
 		}
+		err = s.backupTeam(ctx, team)
+		if err != nil {
+			log.Printf("WARNING: Could not back up team '%s': %s", team.ID, err)
+		}
+		s.recordAudit(ctx, r, updatedTeam.ID, "", audit.SummarizeTeamChange(&team, &updatedTeam))
 	} else {
 		http.Error(w, "You are not authorized to edit this team.", http.StatusForbidden)
 	}
@@ -0,0 +1,260 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/audit"
+	"peoplemath/auth"
+	"peoplemath/models"
+
+	"github.com/gorilla/mux"
+)
+
+// Period templates exist at two levels: per team, stored alongside the team, and
+// org-wide, stored in the settings. Team templates take precedence when a period is
+// created from a template ID which exists at both levels.
+
+func findTemplate(templates []models.PeriodTemplate, templateID string) int {
+	for i, t := range templates {
+		if t.ID == templateID {
+			return i
+		}
+	}
+	return -1
+}
+
+// changeTemplates applies the create, update or delete in the request to a list of templates.
+// If the change is not possible, it writes an error response and returns false.
+func changeTemplates(w http.ResponseWriter, r *http.Request, templates []models.PeriodTemplate) ([]models.PeriodTemplate, bool) {
+	templateID := mux.Vars(r)["templateID"]
+	if r.Method == http.MethodDelete {
+		i := findTemplate(templates, templateID)
+		if i < 0 {
+			http.NotFound(w, r)
+			return templates, false
+		}
+		return append(templates[:i:i], templates[i+1:]...), true
+	}
+
+	template := models.PeriodTemplate{}
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return templates, false
+	}
+	if template.ID == "" {
+		http.Error(w, "Template ID must be specified", http.StatusBadRequest)
+		return templates, false
+	}
+	i := findTemplate(templates, template.ID)
+	if r.Method == http.MethodPost {
+		if i >= 0 {
+			http.Error(w, fmt.Sprintf("Template '%s' already exists", template.ID), http.StatusBadRequest)
+			return templates, false
+		}
+		return append(templates, template), true
+	}
+	if template.ID != templateID {
+		http.Error(w, fmt.Sprintf("Template ID '%s' in body does not match URL", template.ID), http.StatusBadRequest)
+		return templates, false
+	}
+	if i < 0 {
+		http.NotFound(w, r)
+		return templates, false
+	}
+	result := append([]models.PeriodTemplate{}, templates...)
+	result[i] = template
+	return result, true
+}
+
+func writeTemplates(w http.ResponseWriter, templates []models.PeriodTemplate) {
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(models.PeriodTemplates{Templates: templates})
+}
+
+func writeTemplate(w http.ResponseWriter, r *http.Request, templates []models.PeriodTemplate) {
+	i := findTemplate(templates, mux.Vars(r)["templateID"])
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(templates[i])
+}
+
+func (s *Server) getTeamTemplates(ctx context.Context, w http.ResponseWriter, teamID string) ([]models.PeriodTemplate, bool) {
+	templates, _, err := s.store.GetTeamTemplates(ctx, teamID)
+	if err != nil {
+		log.Printf("Could not retrieve templates for team '%s': error: %s", teamID, err)
+		http.Error(w, fmt.Sprintf("Could not retrieve templates for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+		return nil, false
+	}
+	return templates.Templates, true
+}
+
+func (s *Server) handleGetTeamTemplates(w http.ResponseWriter, r *http.Request) {
+	teamID := mux.Vars(r)["teamID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view this team's templates.", http.StatusForbidden)
+		return
+	}
+	templates, ok := s.getTeamTemplates(ctx, w, teamID)
+	if !ok {
+		return
+	}
+	if _, single := mux.Vars(r)["templateID"]; single {
+		writeTemplate(w, r, templates)
+	} else {
+		writeTemplates(w, templates)
+	}
+}
+
+func (s *Server) handleWriteTeamTemplate(w http.ResponseWriter, r *http.Request) {
+	teamID := mux.Vars(r)["teamID"]
+	team, exists := s.ensureTeamExistence(w, r, teamID, true)
+	if !exists {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, auth.ActionWrite) {
+		http.Error(w, "You are not authorized to edit this team's templates.", http.StatusForbidden)
+		return
+	}
+	templates, ok := s.getTeamTemplates(ctx, w, teamID)
+	if !ok {
+		return
+	}
+	previous := templates
+	templates, ok = changeTemplates(w, r, templates)
+	if !ok {
+		return
+	}
+	err := s.store.UpsertTeamTemplates(ctx, teamID, models.PeriodTemplates{Templates: templates})
+	if err != nil {
+		if writeRenamingConflict(w, err) {
+			return
+		}
+		log.Printf("Could not save templates for team '%s': error: %s", teamID, err)
+		http.Error(w, fmt.Sprintf("Could not save templates for team '%s' (see server log)", teamID), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Updated templates for team '%s'", teamID)
+	s.recordAudit(ctx, r, teamID, "", audit.SummarizeTemplatesChange(previous, templates))
+	writeTemplates(w, templates)
+}
+
+func (s *Server) handleGetOrgTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		log.Printf("Could not retrieve settings: %v", err)
+		http.Error(w, "Could not retrieve due to internal server error", http.StatusInternalServerError)
+		return
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeamList(user, settings.GeneralPermissions, auth.ActionRead) {
+		http.Error(w, "You are not authorized to view the organization's templates.", http.StatusForbidden)
+		return
+	}
+	if _, single := mux.Vars(r)["templateID"]; single {
+		writeTemplate(w, r, settings.PeriodTemplates)
+	} else {
+		writeTemplates(w, settings.PeriodTemplates)
+	}
+}
+
+// handleWriteOrgTemplate changes the org-wide templates. These are part of the settings,
+// so changing them needs the same permission as changing the settings, and is recorded
+// in the settings' audit log.
+func (s *Server) handleWriteOrgTemplate(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.getAdministrableSettings(w, r)
+	if !ok {
+		return
+	}
+	templates, ok := changeTemplates(w, r, settings.PeriodTemplates)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	if err := s.store.UpdateOrgTemplates(ctx, templates); err != nil {
+		log.Printf("Could not save organization templates: error: %s", err)
+		http.Error(w, "Could not save templates (see server log)", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Updated organization templates")
+	s.recordAudit(ctx, r, "", "", audit.SummarizeTemplatesChange(settings.PeriodTemplates, templates))
+	writeTemplates(w, templates)
+}
+
+// findTemplateForTeam looks for a template first among the team's own, then org-wide
+func (s *Server) findTemplateForTeam(ctx context.Context, teamID, templateID string) (models.PeriodTemplate, bool, error) {
+	templates, _, err := s.store.GetTeamTemplates(ctx, teamID)
+	if err != nil {
+		return models.PeriodTemplate{}, false, err
+	}
+	if i := findTemplate(templates.Templates, templateID); i >= 0 {
+		return templates.Templates[i], true, nil
+	}
+	settings, err := s.store.GetSettings(ctx)
+	if err != nil {
+		return models.PeriodTemplate{}, false, err
+	}
+	if i := findTemplate(settings.PeriodTemplates, templateID); i >= 0 {
+		return settings.PeriodTemplates[i], true, nil
+	}
+	return models.PeriodTemplate{}, false, nil
+}
+
+// applyTemplate fills in anything not specified in a new period from the template
+func applyTemplate(period *models.Period, template models.PeriodTemplate) {
+	if period.Unit == "" {
+		period.Unit = template.Unit
+	}
+	if period.UnitAbbrev == "" {
+		period.UnitAbbrev = template.UnitAbbrev
+	}
+	if period.MaxCommittedPercentage == 0 {
+		period.MaxCommittedPercentage = template.MaxCommittedPercentage
+	}
+	if len(period.SecondaryUnits) == 0 {
+		period.SecondaryUnits = append([]models.SecondaryUnit{}, template.SecondaryUnits...)
+	}
+	if len(period.Buckets) == 0 {
+		period.Buckets = make([]models.Bucket, len(template.Buckets))
+		for i, bucket := range template.Buckets {
+			period.Buckets[i] = bucket
+			period.Buckets[i].Objectives = append([]models.Objective{}, bucket.Objectives...)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"peoplemath/auth"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// writeStorage2Error responds to an error from the versioned period store,
+// distinguishing things which don't exist from other failures
+func writeStorage2Error(w http.ResponseWriter, err error, action string) {
+	switch err.(type) {
+	case storage.TeamNotFoundError, storage.PeriodNotFoundError, storage.PeriodVersionNotFoundError, storage.TagNotFoundError:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case storage.TagExistsError:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Could not %s: error: %s", action, err)
+		http.Error(w, fmt.Sprintf("Could not %s (see server log)", action), http.StatusInternalServerError)
+	}
+}
+
+// ensureCanActOnTeam2 checks that the versioned period store is available, and that the
+// user can act on the team's periods. Otherwise it writes an error response and returns false.
+func (s *Server) ensureCanActOnTeam2(w http.ResponseWriter, r *http.Request, teamID string, action string) bool {
+	if s.store2 == nil {
+		http.Error(w, "Period version history is not available with this storage backend", http.StatusNotImplemented)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	team, err := s.store2.GetTeam(ctx, teamID)
+	if _, notFound := err.(storage.TeamNotFoundError); notFound && s.redirectRenamed(w, r, teamID, "") {
+		return false
+	}
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve team '%s'", teamID))
+		return false
+	}
+	// Every route with a period expects it to exist, so one which has been renamed is redirected
+	if periodID, ok := mux.Vars(r)["periodID"]; ok {
+		_, err := s.store2.GetPeriodLatestVersion(ctx, teamID, periodID)
+		if _, notFound := err.(storage.PeriodNotFoundError); notFound && s.redirectRenamed(w, r, teamID, periodID) {
+			return false
+		}
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	if !s.auth.CanActOnTeam(user, team, action) {
+		http.Error(w, "You are not authorized to do this for this team's periods.", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// resolveTag2 returns the version which a tag names, or the given version itself if it is not a tag
+func (s *Server) resolveTag2(w http.ResponseWriter, r *http.Request, teamID, periodID, version string) (string, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	tags, err := s.store2.GetPeriodTags(ctx, teamID, periodID)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve tags of period '%s' for team '%s'", periodID, teamID))
+		return "", false
+	}
+	return models.ResolveTag(tags, version), true
+}
+
+// handleGetPeriodVersions lists every saved version of a period, newest first
+func (s *Server) handleGetPeriodVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	versions, err := s.store2.GetPeriodVersions(ctx, teamID, periodID)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve versions of period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(versions)
+}
+
+// handleGetPeriodVersion fetches a version of a period, given its ID or the name of a tag
+func (s *Server) handleGetPeriodVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	version := vars["version"]
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return
+	}
+	version, ok := s.resolveTag2(w, r, teamID, periodID, version)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	period, err := s.store2.GetPeriodVersion(ctx, teamID, periodID, version)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve version '%s' of period '%s' for team '%s'", version, periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(period)
+}
+
+// handleGetPeriodAncestry lists a version of a period followed by its ancestors,
+// nearest first, optionally limited to ?limit= versions
+func (s *Server) handleGetPeriodAncestry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	version := vars["version"]
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid limit '%s'", limitStr), http.StatusBadRequest)
+			return
+		}
+	}
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return
+	}
+	version, ok := s.resolveTag2(w, r, teamID, periodID, version)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	ancestry, err := storage.GetPeriodAncestry(ctx, s.store2, teamID, periodID, version, limit)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve ancestry of version '%s' of period '%s' for team '%s'", version, periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(ancestry)
+}
+
+func (s *Server) handleGetPeriodTags2(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionRead) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	tags, err := s.store2.GetPeriodTags(ctx, teamID, periodID)
+	if err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("retrieve tags of period '%s' for team '%s'", periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(tags)
+}
+
+// handlePostPeriodTag2 tags a version of a period, or the latest version if none is given
+func (s *Server) handlePostPeriodTag2(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	request := models.TagRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Could not decode body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateTagName(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionWrite) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	version := request.Version
+	if version == "" {
+		latest, err := s.store2.GetPeriodLatestVersion(ctx, teamID, periodID)
+		if err != nil {
+			writeStorage2Error(w, err, fmt.Sprintf("retrieve period '%s' for team '%s'", periodID, teamID))
+			return
+		}
+		version = latest.Version
+	}
+	user := r.Context().Value(auth.ContextKey("user")).(models.User)
+	tag := models.VersionTag{
+		Name:      request.Name,
+		Version:   version,
+		User:      user.Identity(),
+		Timestamp: time.Now(),
+	}
+	if err := s.store2.AddPeriodTag(ctx, teamID, periodID, tag); err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("tag version '%s' of period '%s' for team '%s'", version, periodID, teamID))
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	enc.Encode(tag)
+}
+
+func (s *Server) handleDeletePeriodTag2(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	teamID := vars["teamID"]
+	periodID := vars["periodID"]
+	tagName := vars["tagName"]
+	if !s.ensureCanActOnTeam2(w, r, teamID, auth.ActionWrite) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.storeTimeout)
+	defer cancel()
+	if err := s.store2.DeletePeriodTag(ctx, teamID, periodID, tagName); err != nil {
+		writeStorage2Error(w, err, fmt.Sprintf("delete tag '%s' of period '%s' for team '%s'", tagName, periodID, teamID))
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares two versions of a period structurally.
+//
+// Buckets are matched by display name, objectives by name (so that moves between
+// buckets are recognized), people by ID, secondary units by name and assignments
+// by person. Where several items share a name, they are matched in order.
+// Changes are located by JSON paths such as $.buckets[0].objectives[2].resourceEstimate,
+// in the new version, or in the old version for removals.
+package diff
+
+import (
+	"fmt"
+	"peoplemath/models"
+	"reflect"
+	"strings"
+)
+
+// Kinds of change
+const (
+	OpAdd    = "add"
+	OpRemove = "remove"
+	OpMove   = "move"
+	OpChange = "change"
+)
+
+// Change is a single difference between two periods
+type Change struct {
+	Op string `json:"op"`
+	// Location in the new period, or in the old period for removals
+	Path string `json:"path"`
+	// Location in the old period, for moves
+	FromPath string `json:"fromPath,omitempty"`
+	// What changed, e.g. "objective 'Launch'"
+	Description string `json:"description"`
+	// Values before and after: the whole item for adds and removes, or the field for changes
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(change Change) {
+	d.changes = append(d.changes, change)
+}
+
+func (d *differ) field(path, description string, old, new interface{}) {
+	if !reflect.DeepEqual(old, new) {
+		d.add(Change{Op: OpChange, Path: path, Description: description, Old: old, New: new})
+	}
+}
+
+// ComparePeriods lists the differences between two versions of a period
+func ComparePeriods(from, to *models.Period) []Change {
+	d := &differ{changes: []Change{}}
+	d.field("$.id", "ID", from.ID, to.ID)
+	d.field("$.displayName", "display name", from.DisplayName, to.DisplayName)
+	d.field("$.unit", "unit", from.Unit, to.Unit)
+	d.field("$.unitAbbrev", "unit abbreviation", from.UnitAbbrev, to.UnitAbbrev)
+	d.field("$.notesURL", "notes URL", from.NotesURL, to.NotesURL)
+	d.field("$.maxCommittedPercentage", "maximum committed percentage", from.MaxCommittedPercentage, to.MaxCommittedPercentage)
+	d.field("$.startDate", "start date", from.StartDate, to.StartDate)
+	d.field("$.endDate", "end date", from.EndDate, to.EndDate)
+	d.secondaryUnits(from.SecondaryUnits, to.SecondaryUnits)
+	d.people(from.People, to.People)
+	d.buckets(from.Buckets, to.Buckets)
+	return d.changes
+}
+
+// ComparePeriod2s lists the differences between two versions of a Period2
+func ComparePeriod2s(from, to *models.Period2) []Change {
+	return ComparePeriods(from.AsPeriod(), to.AsPeriod())
+}
+
+// keys gives each item a key which is unique within the list, numbering repeated names
+func keys(names []string) []string {
+	counts := make(map[string]int)
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = fmt.Sprintf("%s#%d", name, counts[name])
+		counts[name]++
+	}
+	return result
+}
+
+func indexByKey(keys []string) map[string]int {
+	result := make(map[string]int)
+	for i, key := range keys {
+		result[key] = i
+	}
+	return result
+}
+
+// unmoved returns the keys in the longest common subsequence of two lists of keys.
+// Items which are in both lists but not in this subsequence have been moved.
+func unmoved(from, to []string) map[string]bool {
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	result := make(map[string]bool)
+	for i, j := 0, 0; i < len(from) && j < len(to); {
+		switch {
+		case from[i] == to[j]:
+			result[from[i]] = true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
+
+func (d *differ) secondaryUnits(from, to []models.SecondaryUnit) {
+	fromNames := make([]string, len(from))
+	for i, u := range from {
+		fromNames[i] = u.Name
+	}
+	toNames := make([]string, len(to))
+	for i, u := range to {
+		toNames[i] = u.Name
+	}
+	fromKeys, toKeys := keys(fromNames), keys(toNames)
+	fromIndex, toIndex := indexByKey(fromKeys), indexByKey(toKeys)
+	for j, key := range toKeys {
+		path := fmt.Sprintf("$.secondaryUnits[%d]", j)
+		description := fmt.Sprintf("secondary unit '%s'", to[j].Name)
+		i, ok := fromIndex[key]
+		if !ok {
+			d.add(Change{Op: OpAdd, Path: path, Description: description, New: to[j]})
+			continue
+		}
+		d.field(path+".conversionFactor", "conversion factor of "+description, from[i].ConversionFactor, to[j].ConversionFactor)
+	}
+	for i, key := range fromKeys {
+		if _, ok := toIndex[key]; !ok {
+			d.add(Change{Op: OpRemove, Path: fmt.Sprintf("$.secondaryUnits[%d]", i), Description: fmt.Sprintf("secondary unit '%s'", from[i].Name), Old: from[i]})
+		}
+	}
+}
+
+func (d *differ) people(from, to []models.Person) {
+	fromIDs := make([]string, len(from))
+	for i, p := range from {
+		fromIDs[i] = p.ID
+	}
+	toIDs := make([]string, len(to))
+	for i, p := range to {
+		toIDs[i] = p.ID
+	}
+	fromKeys, toKeys := keys(fromIDs), keys(toIDs)
+	fromIndex, toIndex := indexByKey(fromKeys), indexByKey(toKeys)
+	for j, key := range toKeys {
+		path := fmt.Sprintf("$.people[%d]", j)
+		description := fmt.Sprintf("person '%s'", to[j].ID)
+		i, ok := fromIndex[key]
+		if !ok {
+			d.add(Change{Op: OpAdd, Path: path, Description: description, New: to[j]})
+			continue
+		}
+		d.field(path+".displayName", "display name of "+description, from[i].DisplayName, to[j].DisplayName)
+		d.field(path+".location", "location of "+description, from[i].Location, to[j].Location)
+		d.field(path+".availability", "availability of "+description, from[i].Availability, to[j].Availability)
+	}
+	for i, key := range fromKeys {
+		if _, ok := toIndex[key]; !ok {
+			d.add(Change{Op: OpRemove, Path: fmt.Sprintf("$.people[%d]", i), Description: fmt.Sprintf("person '%s'", from[i].ID), Old: from[i]})
+		}
+	}
+}
+
+// objectiveLocation locates an objective within a period
+type objectiveLocation struct {
+	bucket, objective int
+}
+
+func (l objectiveLocation) path() string {
+	return fmt.Sprintf("$.buckets[%d].objectives[%d]", l.bucket, l.objective)
+}
+
+func objectiveKeys(buckets []models.Bucket) ([][]string, map[string]objectiveLocation) {
+	var names []string
+	for _, b := range buckets {
+		for _, o := range b.Objectives {
+			names = append(names, o.Name)
+		}
+	}
+	flat := keys(names)
+	byBucket := make([][]string, len(buckets))
+	locations := make(map[string]objectiveLocation)
+	n := 0
+	for b, bucket := range buckets {
+		for o := range bucket.Objectives {
+			byBucket[b] = append(byBucket[b], flat[n])
+			locations[flat[n]] = objectiveLocation{bucket: b, objective: o}
+			n++
+		}
+	}
+	return byBucket, locations
+}
+
+func (d *differ) buckets(from, to []models.Bucket) {
+	fromNames := make([]string, len(from))
+	for i, b := range from {
+		fromNames[i] = b.DisplayName
+	}
+	toNames := make([]string, len(to))
+	for i, b := range to {
+		toNames[i] = b.DisplayName
+	}
+	fromKeys, toKeys := keys(fromNames), keys(toNames)
+	fromIndex, toIndex := indexByKey(fromKeys), indexByKey(toKeys)
+	var commonFrom, commonTo []string
+	for _, key := range fromKeys {
+		if _, ok := toIndex[key]; ok {
+			commonFrom = append(commonFrom, key)
+		}
+	}
+	for _, key := range toKeys {
+		if _, ok := fromIndex[key]; ok {
+			commonTo = append(commonTo, key)
+		}
+	}
+	inPlace := unmoved(commonFrom, commonTo)
+
+	// Buckets which exist in both versions, so objectives can be compared within them
+	matchedBucket := make(map[int]int)
+	for j, key := range toKeys {
+		path := fmt.Sprintf("$.buckets[%d]", j)
+		description := fmt.Sprintf("bucket '%s'", to[j].DisplayName)
+		i, ok := fromIndex[key]
+		if !ok {
+			d.add(Change{Op: OpAdd, Path: path, Description: description, New: to[j]})
+			continue
+		}
+		matchedBucket[j] = i
+		if !inPlace[key] {
+			d.add(Change{Op: OpMove, Path: path, FromPath: fmt.Sprintf("$.buckets[%d]", i), Description: description})
+		}
+		d.field(path+".allocationType", "allocation type of "+description, from[i].AllocationType, to[j].AllocationType)
+		d.field(path+".allocationPercentage", "allocation percentage of "+description, from[i].AllocationPercentage, to[j].AllocationPercentage)
+		d.field(path+".allocationAbsolute", "absolute allocation of "+description, from[i].AllocationAbsolute, to[j].AllocationAbsolute)
+	}
+	for i, key := range fromKeys {
+		if _, ok := toIndex[key]; !ok {
+			d.add(Change{Op: OpRemove, Path: fmt.Sprintf("$.buckets[%d]", i), Description: fmt.Sprintf("bucket '%s'", from[i].DisplayName), Old: from[i]})
+		}
+	}
+	d.objectives(from, to, matchedBucket)
+}
+
+func (d *differ) objectives(from, to []models.Bucket, matchedBucket map[int]int) {
+	fromByBucket, fromLocations := objectiveKeys(from)
+	toByBucket, toLocations := objectiveKeys(to)
+
+	// Within each bucket, objectives which stayed in the bucket but aren't in the
+	// longest common subsequence have been reordered
+	inPlace := make(map[string]bool)
+	for j, i := range matchedBucket {
+		var stayedFrom, stayedTo []string
+		for _, key := range fromByBucket[i] {
+			if loc, ok := toLocations[key]; ok && loc.bucket == j {
+				stayedFrom = append(stayedFrom, key)
+			}
+		}
+		for _, key := range toByBucket[j] {
+			if loc, ok := fromLocations[key]; ok && loc.bucket == i {
+				stayedTo = append(stayedTo, key)
+			}
+		}
+		for key := range unmoved(stayedFrom, stayedTo) {
+			inPlace[key] = true
+		}
+	}
+
+	for j, bucketKeys := range toByBucket {
+		for _, key := range bucketKeys {
+			toLoc := toLocations[key]
+			objective := to[toLoc.bucket].Objectives[toLoc.objective]
+			description := fmt.Sprintf("objective '%s'", objective.Name)
+			fromLoc, ok := fromLocations[key]
+			if !ok {
+				d.add(Change{Op: OpAdd, Path: toLoc.path(), Description: fmt.Sprintf("%s in bucket '%s'", description, to[j].DisplayName), New: objective})
+				continue
+			}
+			if !inPlace[key] {
+				d.add(Change{Op: OpMove, Path: toLoc.path(), FromPath: fromLoc.path(), Description: description})
+			}
+			d.objective(toLoc.path(), description, from[fromLoc.bucket].Objectives[fromLoc.objective], objective)
+		}
+	}
+	for i, bucketKeys := range fromByBucket {
+		for _, key := range bucketKeys {
+			if _, ok := toLocations[key]; !ok {
+				fromLoc := fromLocations[key]
+				objective := from[fromLoc.bucket].Objectives[fromLoc.objective]
+				d.add(Change{Op: OpRemove, Path: fromLoc.path(), Description: fmt.Sprintf("objective '%s' in bucket '%s'", objective.Name, from[i].DisplayName), Old: objective})
+			}
+		}
+	}
+}
+
+func (d *differ) objective(path, description string, from, to models.Objective) {
+	d.field(path+".resourceEstimate", "resource estimate of "+description, from.ResourceEstimate, to.ResourceEstimate)
+	d.field(path+".commitmentType", "commitment type of "+description, from.CommitmentType, to.CommitmentType)
+	d.field(path+".notes", "notes of "+description, from.Notes, to.Notes)
+	d.field(path+".displayOptions.enableMarkdown", "markdown setting of "+description, from.DisplayOptions.EnableMarkdown, to.DisplayOptions.EnableMarkdown)
+	d.field(path+".blockID", "block of "+description, from.BlockID, to.BlockID)
+	d.field(path+".groups", "groups of "+description, normalizeGroups(from.Groups), normalizeGroups(to.Groups))
+	d.field(path+".tags", "tags of "+description, normalizeTags(from.Tags), normalizeTags(to.Tags))
+
+	fromPeople := make([]string, len(from.Assignments))
+	for i, a := range from.Assignments {
+		fromPeople[i] = a.PersonID
+	}
+	toPeople := make([]string, len(to.Assignments))
+	for i, a := range to.Assignments {
+		toPeople[i] = a.PersonID
+	}
+	fromKeys, toKeys := keys(fromPeople), keys(toPeople)
+	fromIndex, toIndex := indexByKey(fromKeys), indexByKey(toKeys)
+	for j, key := range toKeys {
+		assignmentPath := fmt.Sprintf("%s.assignments[%d]", path, j)
+		assignment := fmt.Sprintf("assignment of '%s' to %s", to.Assignments[j].PersonID, description)
+		i, ok := fromIndex[key]
+		if !ok {
+			d.add(Change{Op: OpAdd, Path: assignmentPath, Description: assignment, New: to.Assignments[j]})
+			continue
+		}
+		d.field(assignmentPath+".commitment", "commitment of "+assignment, from.Assignments[i].Commitment, to.Assignments[j].Commitment)
+	}
+	for i, key := range fromKeys {
+		if _, ok := toIndex[key]; !ok {
+			d.add(Change{
+				Op:          OpRemove,
+				Path:        fmt.Sprintf("%s.assignments[%d]", path, i),
+				Description: fmt.Sprintf("assignment of '%s' to %s", from.Assignments[i].PersonID, description),
+				Old:         from.Assignments[i],
+			})
+		}
+	}
+}
+
+// normalizeGroups treats nil and empty lists of groups the same
+func normalizeGroups(groups []models.ObjectiveGroup) []models.ObjectiveGroup {
+	if len(groups) == 0 {
+		return []models.ObjectiveGroup{}
+	}
+	return groups
+}
+
+func normalizeTags(tags []models.ObjectiveTag) []models.ObjectiveTag {
+	if len(tags) == 0 {
+		return []models.ObjectiveTag{}
+	}
+	return tags
+}
+
+// Text formats changes as readable text, one per line
+func Text(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Op {
+		case OpAdd:
+			fmt.Fprintf(&b, "+ Added %s at %s\n", c.Description, c.Path)
+		case OpRemove:
+			fmt.Fprintf(&b, "- Removed %s from %s\n", c.Description, c.Path)
+		case OpMove:
+			fmt.Fprintf(&b, "> Moved %s from %s to %s\n", c.Description, c.FromPath, c.Path)
+		case OpChange:
+			fmt.Fprintf(&b, "~ Changed %s from %s to %s at %s\n", c.Description, formatValue(c.Old), formatValue(c.New), c.Path)
+		}
+	}
+	return b.String()
+}
+
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []models.ObjectiveTag:
+		names := make([]string, len(value))
+		for i, t := range value {
+			names[i] = t.Name
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	case []models.ObjectiveGroup:
+		names := make([]string, len(value))
+		for i, g := range value {
+			names[i] = g.GroupType + ":" + g.GroupName
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
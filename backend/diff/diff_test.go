@@ -0,0 +1,169 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"peoplemath/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makePeriod() *models.Period {
+	return &models.Period{
+		ID:             "2026q1",
+		DisplayName:    "2026 Q1",
+		SecondaryUnits: []models.SecondaryUnit{{Name: "person weeks", ConversionFactor: 13}},
+		People: []models.Person{
+			{ID: "alice", Availability: 10},
+			{ID: "bob", Availability: 10},
+		},
+		Buckets: []models.Bucket{
+			{
+				DisplayName:          "First",
+				AllocationPercentage: 60,
+				Objectives: []models.Objective{
+					{Name: "Launch", ResourceEstimate: 8, Assignments: []models.Assignment{{PersonID: "alice", Commitment: 5}}},
+					{Name: "Docs", ResourceEstimate: 2},
+					{Name: "Cleanup", ResourceEstimate: 1},
+				},
+			},
+			{
+				DisplayName:          "Second",
+				AllocationPercentage: 40,
+				Objectives: []models.Objective{
+					{Name: "Support", ResourceEstimate: 4, Assignments: []models.Assignment{{PersonID: "bob", Commitment: 4}}},
+				},
+			},
+		},
+	}
+}
+
+func summarize(changes []Change) []string {
+	result := []string{}
+	for _, c := range changes {
+		result = append(result, c.Op+" "+c.FromPath+" "+c.Path)
+	}
+	return result
+}
+
+func TestCompareIdentical(t *testing.T) {
+	if changes := ComparePeriods(makePeriod(), makePeriod()); len(changes) != 0 {
+		t.Errorf("Expected no changes, found %v", changes)
+	}
+}
+
+func TestCompareFields(t *testing.T) {
+	before := makePeriod()
+	after := makePeriod()
+	after.DisplayName = "First quarter"
+	after.SecondaryUnits[0].ConversionFactor = 12
+	after.People[1].Availability = 8
+	after.Buckets[0].Objectives[0].ResourceEstimate = 10
+	after.Buckets[0].Objectives[0].Assignments[0].Commitment = 6
+	after.Buckets[0].Objectives[1].Tags = []models.ObjectiveTag{{Name: "docs"}}
+
+	changes := ComparePeriods(before, after)
+	expected := []Change{
+		{Op: OpChange, Path: "$.displayName", Description: "display name", Old: "2026 Q1", New: "First quarter"},
+		{Op: OpChange, Path: "$.secondaryUnits[0].conversionFactor", Description: "conversion factor of secondary unit 'person weeks'", Old: 13.0, New: 12.0},
+		{Op: OpChange, Path: "$.people[1].availability", Description: "availability of person 'bob'", Old: 10.0, New: 8.0},
+		{Op: OpChange, Path: "$.buckets[0].objectives[0].resourceEstimate", Description: "resource estimate of objective 'Launch'", Old: 8.0, New: 10.0},
+		{Op: OpChange, Path: "$.buckets[0].objectives[0].assignments[0].commitment", Description: "commitment of assignment of 'alice' to objective 'Launch'", Old: 5.0, New: 6.0},
+		{Op: OpChange, Path: "$.buckets[0].objectives[1].tags", Description: "tags of objective 'Docs'", Old: []models.ObjectiveTag{}, New: []models.ObjectiveTag{{Name: "docs"}}},
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("Expected %v, found %v", expected, changes)
+	}
+}
+
+func TestCompareAddsAndRemoves(t *testing.T) {
+	before := makePeriod()
+	after := makePeriod()
+	after.People = []models.Person{after.People[1], {ID: "carol", Availability: 5}}
+	after.Buckets[0].Objectives = append(after.Buckets[0].Objectives, models.Objective{Name: "Polish", ResourceEstimate: 1})
+	after.Buckets[1].Objectives[0].Assignments = nil
+	after.Buckets = append(after.Buckets, models.Bucket{DisplayName: "Third"})
+
+	expected := []string{
+		"add  $.people[1]",
+		"remove  $.people[0]",
+		"add  $.buckets[2]",
+		"add  $.buckets[0].objectives[3]",
+		"remove  $.buckets[1].objectives[0].assignments[0]",
+	}
+	if found := summarize(ComparePeriods(before, after)); !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected %v, found %v", expected, found)
+	}
+}
+
+func TestCompareMoves(t *testing.T) {
+	before := makePeriod()
+	after := makePeriod()
+	// Moving Cleanup to the top is one move, not two
+	first := after.Buckets[0].Objectives
+	after.Buckets[0].Objectives = []models.Objective{first[2], first[0], first[1]}
+	// Moving Support to the first bucket, and swapping the buckets
+	after.Buckets[0].Objectives = append(after.Buckets[0].Objectives, after.Buckets[1].Objectives[0])
+	after.Buckets[1].Objectives = []models.Objective{}
+	after.Buckets[0], after.Buckets[1] = after.Buckets[1], after.Buckets[0]
+
+	expected := []string{
+		"move $.buckets[0] $.buckets[1]",
+		"move $.buckets[0].objectives[2] $.buckets[1].objectives[0]",
+		"move $.buckets[1].objectives[0] $.buckets[1].objectives[3]",
+	}
+	if found := summarize(ComparePeriods(before, after)); !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected %v, found %v", expected, found)
+	}
+}
+
+func TestCompareDuplicateNames(t *testing.T) {
+	before := makePeriod()
+	before.Buckets[0].Objectives[1].Name = "Launch"
+	after := makePeriod()
+	after.Buckets[0].Objectives[1].Name = "Launch"
+	after.Buckets[0].Objectives[1].ResourceEstimate = 3
+
+	expected := []string{"change  $.buckets[0].objectives[1].resourceEstimate"}
+	if found := summarize(ComparePeriods(before, after)); !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected %v, found %v", expected, found)
+	}
+}
+
+func TestComparePeriod2s(t *testing.T) {
+	before := &models.Period2{ID: "p", DisplayName: "Before", Version: "v1"}
+	after := &models.Period2{ID: "p", DisplayName: "After", Version: "v2", ParentVersions: []string{"v1"}}
+	expected := []string{"change  $.displayName"}
+	if found := summarize(ComparePeriod2s(before, after)); !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected %v, found %v", expected, found)
+	}
+}
+
+func TestText(t *testing.T) {
+	before := makePeriod()
+	after := makePeriod()
+	after.DisplayName = "First quarter"
+	after.Buckets[0].Objectives = after.Buckets[0].Objectives[:2]
+
+	expected := []string{
+		`~ Changed display name from "2026 Q1" to "First quarter" at $.displayName`,
+		`- Removed objective 'Cleanup' in bucket 'First' from $.buckets[0].objectives[2]`,
+	}
+	found := strings.Split(strings.TrimSuffix(Text(ComparePeriods(before, after)), "\n"), "\n")
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected %v, found %v", expected, found)
+	}
+}
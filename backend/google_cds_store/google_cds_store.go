@@ -1,4 +1,4 @@
-// Copyright 2019-2021, 2024 Google LLC
+// Copyright 2019-2021, 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"peoplemath/models"
 	"peoplemath/storage"
+	"sort"
+	"time"
 
 	"google.golang.org/api/iterator"
 
@@ -32,6 +34,22 @@ const (
 	PeriodKind = "Period"
 	// PeriodBackupsKind - Datastore kind name for period backups
 	PeriodBackupsKind = "PeriodBackups"
+	// PeriodTemplatesKind - Datastore kind name for a team's period templates
+	PeriodTemplatesKind = "PeriodTemplates"
+	// TeamBackupsKind - Datastore kind name for team backups
+	TeamBackupsKind = "TeamBackups"
+	// DeletedItemKind - Datastore kind name for the index of deleted teams and periods
+	DeletedItemKind = "DeletedItem"
+	// TeamAliasKind - Datastore kind name for the aliases left behind by renamed teams
+	TeamAliasKind = "TeamAlias"
+	// PeriodAliasKind - Datastore kind name for the aliases left behind by renamed periods
+	PeriodAliasKind = "PeriodAlias"
+	// TeamRenameKind - Datastore kind name for renames of teams which are in progress
+	TeamRenameKind = "TeamRename"
+	// APIKeyKind - Datastore kind name for API keys
+	APIKeyKind = "APIKey"
+	// AuditEntryKind - Datastore kind name for audit entries
+	AuditEntryKind = "AuditEntry"
 	// SettingsKind - Datastore kind name for settings
 	SettingsKind = "Settings"
 	// SettingsEntity - entity name for settings
@@ -41,6 +59,9 @@ const (
 // StorageService using Google Cloud Datastore
 type googleCDSStore struct {
 	client *datastore.Client
+	// If set, this is called after each batch of a team's entities is moved while renaming it,
+	// and any error stops the rename. Tests use this to interrupt renames.
+	afterRenameBatch func() error
 }
 
 func MakeGoogleCDSStore(ctx context.Context, projectID string) (storage.StorageService, error) {
@@ -63,6 +84,57 @@ func getPeriodBackupsKey(teamKey *datastore.Key, periodID string) *datastore.Key
 	return datastore.NameKey(PeriodBackupsKind, periodID, teamKey)
 }
 
+func getPeriodTemplatesKey(teamKey *datastore.Key) *datastore.Key {
+	return datastore.NameKey(PeriodTemplatesKind, teamKey.Name, teamKey)
+}
+
+func getTeamBackupsKey(teamKey *datastore.Key) *datastore.Key {
+	return datastore.NameKey(TeamBackupsKind, teamKey.Name, teamKey)
+}
+
+func getAuditEntryKey(parentKey *datastore.Key, entryID string) *datastore.Key {
+	return datastore.NameKey(AuditEntryKind, entryID, parentKey)
+}
+
+func getDeletedItemKey(teamID, periodID string) *datastore.Key {
+	return datastore.NameKey(DeletedItemKind, storage.DeletedItemID(teamID, periodID), nil)
+}
+
+func getTeamAliasKey(teamID string) *datastore.Key {
+	return datastore.NameKey(TeamAliasKind, teamID, nil)
+}
+
+func getTeamRenameKey(teamID string) *datastore.Key {
+	return datastore.NameKey(TeamRenameKind, teamID, nil)
+}
+
+// checkNotRenaming fails with a TeamRenamingError if the team is being renamed, to or from
+// another ID. As the teamRename is read in the transaction, the transaction can't overlap
+// the start of a rename.
+func checkNotRenaming(tx *datastore.Transaction, teamID string) error {
+	var rename teamRename
+	err := tx.Get(getTeamRenameKey(teamID), &rename)
+	if err == nil {
+		return storage.TeamRenamingError(teamID)
+	}
+	if err != datastore.ErrNoSuchEntity {
+		return fmt.Errorf("Could not check for a rename of team '%s': %s", teamID, err)
+	}
+	return nil
+}
+
+func getPeriodAliasKey(teamKey *datastore.Key, periodID string) *datastore.Key {
+	return datastore.NameKey(PeriodAliasKind, periodID, teamKey)
+}
+
+func getAPIKeyKey(keyID string) *datastore.Key {
+	return datastore.NameKey(APIKeyKind, keyID, nil)
+}
+
+func getSettingsKey() *datastore.Key {
+	return datastore.NameKey(SettingsKind, SettingsEntity, nil)
+}
+
 func (s *googleCDSStore) GetAllTeams(ctx context.Context) ([]models.Team, error) {
 	query := datastore.NewQuery(TeamKind).Order("DisplayName")
 	iter := s.client.Run(ctx, query)
@@ -76,7 +148,9 @@ func (s *googleCDSStore) GetAllTeams(ctx context.Context) ([]models.Team, error)
 		if err != nil {
 			return result, err
 		}
-		result = append(result, t)
+		if t.Deleted == nil {
+			result = append(result, t)
+		}
 	}
 	return result, nil
 }
@@ -91,17 +165,24 @@ func (s *googleCDSStore) GetTeam(ctx context.Context, teamID string) (models.Tea
 	if err != nil {
 		return team, true, err
 	}
+	if team.Deleted != nil {
+		return models.Team{}, false, nil
+	}
 	return team, true, nil
 }
 
 func (s *googleCDSStore) CreateTeam(ctx context.Context, team models.Team) error {
 	key := getTeamKey(team.ID)
 	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		var empty models.Team
-		if err := tx.Get(key, &empty); err != datastore.ErrNoSuchEntity {
+		var existing models.Team
+		err := tx.Get(key, &existing)
+		if err == nil && existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(team.ID, ""))
+		}
+		if err != datastore.ErrNoSuchEntity {
 			return fmt.Errorf("Expected no existing team '%s', found: %s", team.ID, err)
 		}
-		_, err := tx.Put(key, &team)
+		_, err = tx.Put(key, &team)
 		return err
 	})
 	return err
@@ -137,7 +218,9 @@ func (s *googleCDSStore) GetAllPeriods(ctx context.Context, teamID string) ([]mo
 		if err != nil {
 			return result, true, err
 		}
-		result = append(result, p)
+		if p.Deleted == nil {
+			result = append(result, p)
+		}
 	}
 	return result, true, nil
 }
@@ -147,8 +230,8 @@ func (s *googleCDSStore) GetPeriod(ctx context.Context, teamID, periodID string)
 	periodKey := getPeriodKey(teamKey, periodID)
 	var period models.Period
 	err := s.client.Get(ctx, periodKey, &period)
-	if err == datastore.ErrNoSuchEntity {
-		return &period, false, nil
+	if err == datastore.ErrNoSuchEntity || (err == nil && period.Deleted != nil) {
+		return &models.Period{}, false, nil
 	}
 	return &period, true, err
 }
@@ -157,11 +240,18 @@ func (s *googleCDSStore) CreatePeriod(ctx context.Context, teamID string, period
 	teamKey := getTeamKey(teamID)
 	periodKey := getPeriodKey(teamKey, period.ID)
 	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		var empty models.Period
-		if err := tx.Get(periodKey, &empty); err != datastore.ErrNoSuchEntity {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var existing models.Period
+		err := tx.Get(periodKey, &existing)
+		if err == nil && existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(teamID, period.ID))
+		}
+		if err != datastore.ErrNoSuchEntity {
 			return fmt.Errorf("Expected no period '%s' for team '%s': %s", period.ID, teamID, err)
 		}
-		_, err := tx.Put(periodKey, period)
+		_, err = tx.Put(periodKey, period)
 		return err
 	})
 	return err
@@ -171,6 +261,9 @@ func (s *googleCDSStore) UpdatePeriod(ctx context.Context, teamID string, period
 	teamKey := getTeamKey(teamID)
 	periodKey := getPeriodKey(teamKey, period.ID)
 	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
 		var ignored models.Period
 		if err := tx.Get(periodKey, &ignored); err != nil {
 			return fmt.Errorf("Could not retrieve period '%s' for team '%s': %s", period.ID, teamID, err)
@@ -181,6 +274,33 @@ func (s *googleCDSStore) UpdatePeriod(ctx context.Context, teamID string, period
 	return err
 }
 
+func (s *googleCDSStore) ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error {
+	teamKey := getTeamKey(teamID)
+	periodKey := getPeriodKey(teamKey, periodID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var period models.Period
+		if err := tx.Get(periodKey, &period); err != nil {
+			return fmt.Errorf("Could not retrieve period '%s' for team '%s': %s", periodID, teamID, err)
+		}
+		if period.Deleted != nil {
+			return fmt.Errorf("Period '%s' for team '%s' was deleted", periodID, teamID)
+		}
+		if period.LastUpdateUUID != lastUpdateUUID {
+			return storage.ConcurrentModificationError(fmt.Sprintf(
+				"last saved UUID=%s, your last loaded UUID=%s", period.LastUpdateUUID, lastUpdateUUID))
+		}
+		if err := modify(&period); err != nil {
+			return err
+		}
+		_, err := tx.Put(periodKey, &period)
+		return err
+	})
+	return err
+}
+
 func (s *googleCDSStore) GetPeriodBackups(ctx context.Context, teamID, periodID string) (models.PeriodBackups, bool, error) {
 	teamKey := getTeamKey(teamID)
 	backupsKey := getPeriodBackupsKey(teamKey, periodID)
@@ -195,12 +315,90 @@ func (s *googleCDSStore) GetPeriodBackups(ctx context.Context, teamID, periodID
 func (s *googleCDSStore) UpsertPeriodBackups(ctx context.Context, teamID, periodID string, backups models.PeriodBackups) error {
 	teamKey := getTeamKey(teamID)
 	backupsKey := getPeriodBackupsKey(teamKey, periodID)
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("Could not retrieve settings for backup retention: %s", err)
+	}
+	retention := settings.GetBackupRetention()
+	_, err = s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var saved models.PeriodBackups
+		if err := tx.Get(backupsKey, &saved); err != nil && err != datastore.ErrNoSuchEntity {
+			return fmt.Errorf("Could not retrieve backups of period '%s' for team '%s': %s", periodID, teamID, err)
+		}
+		// The tags are kept as saved, so that tags added since the backups were read aren't lost
+		backups.Tags = saved.Tags
+		backups.Prune(retention, time.Now())
+		_, err := tx.Put(backupsKey, &backups)
+		return err
+	})
+	return err
+}
+
+func (s *googleCDSStore) UpdatePeriodTags(ctx context.Context, teamID, periodID string, update func(backups models.PeriodBackups) ([]models.VersionTag, error)) error {
+	teamKey := getTeamKey(teamID)
+	backupsKey := getPeriodBackupsKey(teamKey, periodID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var backups models.PeriodBackups
+		if err := tx.Get(backupsKey, &backups); err != nil && err != datastore.ErrNoSuchEntity {
+			return fmt.Errorf("Could not retrieve backups of period '%s' for team '%s': %s", periodID, teamID, err)
+		}
+		tags, err := update(backups)
+		if err != nil {
+			return err
+		}
+		backups.Tags = tags
+		_, err = tx.Put(backupsKey, &backups)
+		return err
+	})
+	return err
+}
+
+func (s *googleCDSStore) GetTeamBackups(ctx context.Context, teamID string) (models.TeamBackups, bool, error) {
+	backupsKey := getTeamBackupsKey(getTeamKey(teamID))
+	var backups models.TeamBackups
+	err := s.client.Get(ctx, backupsKey, &backups)
+	if err == datastore.ErrNoSuchEntity {
+		return backups, false, nil
+	}
+	return backups, true, err
+}
+
+func (s *googleCDSStore) UpsertTeamBackups(ctx context.Context, teamID string, backups models.TeamBackups) error {
+	backupsKey := getTeamBackupsKey(getTeamKey(teamID))
 	_, err := s.client.Put(ctx, backupsKey, &backups)
 	return err
 }
 
+func (s *googleCDSStore) GetTeamTemplates(ctx context.Context, teamID string) (models.PeriodTemplates, bool, error) {
+	templatesKey := getPeriodTemplatesKey(getTeamKey(teamID))
+	var templates models.PeriodTemplates
+	err := s.client.Get(ctx, templatesKey, &templates)
+	if err == datastore.ErrNoSuchEntity {
+		return templates, false, nil
+	}
+	return templates, true, err
+}
+
+func (s *googleCDSStore) UpsertTeamTemplates(ctx context.Context, teamID string, templates models.PeriodTemplates) error {
+	templatesKey := getPeriodTemplatesKey(getTeamKey(teamID))
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		_, err := tx.Put(templatesKey, &templates)
+		return err
+	})
+	return err
+}
+
 func (s *googleCDSStore) GetSettings(ctx context.Context) (models.Settings, error) {
-	key := datastore.NameKey(SettingsKind, SettingsEntity, nil)
+	key := getSettingsKey()
 	var result models.Settings
 	err := s.client.Get(ctx, key, &result)
 	if err == datastore.ErrNoSuchEntity {
@@ -214,6 +412,593 @@ func (s *googleCDSStore) GetSettings(ctx context.Context) (models.Settings, erro
 	return result, nil
 }
 
+func (s *googleCDSStore) UpdateSettings(ctx context.Context, settings models.Settings) error {
+	_, err := s.client.Put(ctx, getSettingsKey(), &settings)
+	return err
+}
+
+func (s *googleCDSStore) UpdateOrgTemplates(ctx context.Context, templates []models.PeriodTemplate) error {
+	key := getSettingsKey()
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var settings models.Settings
+		if err := tx.Get(key, &settings); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		settings.PeriodTemplates = templates
+		_, err := tx.Put(key, &settings)
+		return err
+	})
+	return err
+}
+
+// getAuditParentKey returns the key which a team's audit entries belong to. Changes to the
+// settings have no team, so their audit entries belong to the settings.
+func getAuditParentKey(teamID string) *datastore.Key {
+	if teamID == "" {
+		return getSettingsKey()
+	}
+	return getTeamKey(teamID)
+}
+
+func (s *googleCDSStore) AddAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	key := getAuditEntryKey(getAuditParentKey(entry.TeamID), entry.ID)
+	_, err := s.client.Put(ctx, key, &entry)
+	return err
+}
+
+// GetAuditEntries uses Datastore cursors as page tokens. The queries need the
+// composite indexes in index.yaml.
+func (s *googleCDSStore) GetAuditEntries(ctx context.Context, teamID, periodID, pageToken string, pageSize int) (models.AuditPage, error) {
+	query := datastore.NewQuery(AuditEntryKind).Ancestor(getAuditParentKey(teamID))
+	if periodID != "" {
+		query = query.FilterField("PeriodID", "=", periodID)
+	}
+	query = query.Order("-Timestamp").Limit(pageSize)
+	if pageToken != "" {
+		cursor, err := datastore.DecodeCursor(pageToken)
+		if err != nil {
+			return models.AuditPage{}, fmt.Errorf("invalid page token '%s': %s", pageToken, err)
+		}
+		query = query.Start(cursor)
+	}
+	iter := s.client.Run(ctx, query)
+	page := models.AuditPage{Entries: []models.AuditEntry{}}
+	for {
+		var entry models.AuditEntry
+		_, err := iter.Next(&entry)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return page, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if len(page.Entries) == pageSize {
+		cursor, err := iter.Cursor()
+		if err != nil {
+			return page, err
+		}
+		page.NextPageToken = cursor.String()
+	}
+	return page, nil
+}
+
+// Deleted teams and periods are marked as such, and also listed in an index of DeletedItem
+// entities, so that they can be found without loading every team and period
+
+func (s *googleCDSStore) DeleteTeam(ctx context.Context, teamID string, deletion models.Deletion) error {
+	key := getTeamKey(teamID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var team models.Team
+		if err := tx.Get(key, &team); err != nil {
+			return fmt.Errorf("Could not retrieve team '%s': %s", teamID, err)
+		}
+		if team.Deleted != nil {
+			return fmt.Errorf("Team '%s' is already deleted", teamID)
+		}
+		team.Deleted = &deletion
+		item := models.DeletedItem{
+			TeamID:      teamID,
+			DisplayName: team.DisplayName,
+			Deletion:    deletion,
+			Permissions: team.Permissions,
+		}
+		if _, err := tx.Put(key, &team); err != nil {
+			return err
+		}
+		_, err := tx.Put(getDeletedItemKey(teamID, ""), &item)
+		return err
+	})
+	return err
+}
+
+func (s *googleCDSStore) DeletePeriod(ctx context.Context, teamID, periodID string, deletion models.Deletion) error {
+	key := getPeriodKey(getTeamKey(teamID), periodID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var period models.Period
+		if err := tx.Get(key, &period); err != nil {
+			return fmt.Errorf("Could not retrieve period '%s' for team '%s': %s", periodID, teamID, err)
+		}
+		if period.Deleted != nil {
+			return fmt.Errorf("Period '%s' for team '%s' is already deleted", periodID, teamID)
+		}
+		period.Deleted = &deletion
+		item := models.DeletedItem{
+			TeamID:      teamID,
+			PeriodID:    periodID,
+			DisplayName: period.DisplayName,
+			Deletion:    deletion,
+		}
+		if _, err := tx.Put(key, &period); err != nil {
+			return err
+		}
+		_, err := tx.Put(getDeletedItemKey(teamID, periodID), &item)
+		return err
+	})
+	return err
+}
+
+func (s *googleCDSStore) GetDeletedItems(ctx context.Context) ([]models.DeletedItem, error) {
+	var result []models.DeletedItem
+	if _, err := s.client.GetAll(ctx, datastore.NewQuery(DeletedItemKind), &result); err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+func (s *googleCDSStore) RestoreDeletedItem(ctx context.Context, teamID, periodID string) error {
+	teamKey := getTeamKey(teamID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if periodID == "" {
+			var team models.Team
+			if err := tx.Get(teamKey, &team); err != nil || team.Deleted == nil {
+				return fmt.Errorf("Could not find deleted team '%s': %v", teamID, err)
+			}
+			team.Deleted = nil
+			if _, err := tx.Put(teamKey, &team); err != nil {
+				return err
+			}
+		} else {
+			periodKey := getPeriodKey(teamKey, periodID)
+			var period models.Period
+			if err := tx.Get(periodKey, &period); err != nil || period.Deleted == nil {
+				return fmt.Errorf("Could not find deleted period '%s' for team '%s': %v", periodID, teamID, err)
+			}
+			period.Deleted = nil
+			if _, err := tx.Put(periodKey, &period); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(getDeletedItemKey(teamID, periodID))
+	})
+	return err
+}
+
+func (s *googleCDSStore) PurgeDeletedItem(ctx context.Context, teamID, periodID string) error {
+	teamKey := getTeamKey(teamID)
+	if periodID != "" {
+		periodKey := getPeriodKey(teamKey, periodID)
+		_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+			var period models.Period
+			if err := tx.Get(periodKey, &period); err != nil || period.Deleted == nil {
+				return fmt.Errorf("Could not find deleted period '%s' for team '%s': %v", periodID, teamID, err)
+			}
+			return tx.DeleteMulti([]*datastore.Key{periodKey, getPeriodBackupsKey(teamKey, periodID), getDeletedItemKey(teamID, periodID)})
+		})
+		return err
+	}
+
+	// A team which is missing altogether was partly purged before, so just finish the job
+	var team models.Team
+	if err := s.client.Get(ctx, teamKey, &team); err != datastore.ErrNoSuchEntity && (err != nil || team.Deleted == nil) {
+		return fmt.Errorf("Could not find deleted team '%s': %v", teamID, err)
+	}
+	if err := s.updateAPIKeys(ctx, func(key *models.APIKey) bool { return key.RemoveTeam(teamID) }); err != nil {
+		return fmt.Errorf("Could not remove team '%s' from API keys: %s", teamID, err)
+	}
+	// Everything belonging to the team has the team as its ancestor. The team itself
+	// goes after that, and the index entries last, so that an interrupted purge can be retried.
+	keys, err := s.client.GetAll(ctx, datastore.NewQuery("").Ancestor(teamKey).KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+	itemKeys, err := s.client.GetAll(ctx, datastore.NewQuery(DeletedItemKind).FilterField("TeamID", "=", teamID).KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+	var toDelete []*datastore.Key
+	for _, key := range keys {
+		if !key.Equal(teamKey) {
+			toDelete = append(toDelete, key)
+		}
+	}
+	toDelete = append(toDelete, teamKey)
+	toDelete = append(toDelete, itemKeys...)
+	// Datastore limits how many entities can be deleted at once
+	const batchSize = 500
+	for start := 0; start < len(toDelete); start += batchSize {
+		end := min(start+batchSize, len(toDelete))
+		if err := s.client.DeleteMulti(ctx, toDelete[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveEntities puts entities at new keys and then deletes the old keys. Datastore limits
+// how many entities can be written at once, so this is done in batches.
+func moveEntities[T any](ctx context.Context, client *datastore.Client, oldKeys, newKeys []*datastore.Key, entities []T) error {
+	const batchSize = 500
+	for start := 0; start < len(oldKeys); start += batchSize {
+		end := min(start+batchSize, len(oldKeys))
+		if _, err := client.PutMulti(ctx, newKeys[start:end], entities[start:end]); err != nil {
+			return err
+		}
+		if err := client.DeleteMulti(ctx, oldKeys[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A team can have more entities than fit in a transaction, so it is renamed in steps:
+//
+//  1. A transaction writes the team at its new ID, and teamRenames at both IDs recording the
+//     rename in progress.
+//  2. Everything belonging to the team is moved to the new team, a few entities at a time.
+//  3. A transaction removes the team from its old ID, leaves an alias there, and removes the
+//     teamRenames.
+//
+// Until the last step, the team is listed under both IDs, and its periods are found under
+// whichever one they have been moved to so far. Anything written under the old ID after it
+// had been moved would be lost, so in the meantime writes to the team's periods, backups,
+// tags and templates under either ID fail with a TeamRenamingError; the team itself can still
+// be edited, as the last step copies it again. If the rename fails part way, the team stays
+// read-only like this until it is renamed to the same new ID again, which carries on from
+// where it stopped, as each step can be repeated.
+
+// teamRename records a rename of a team which is in progress. At the team's old ID, it has
+// the NewID and the Alias to leave; at the new ID, it has the OldID.
+type teamRename struct {
+	NewID string
+	Alias models.Alias
+	OldID string
+}
+
+// Entities can be up to 1 MiB each, and a write up to 10 MiB, so they are moved a few at a time
+const renameBatchSize = 10
+
+func (s *googleCDSStore) RenameTeam(ctx context.Context, teamID, newTeamID string, alias models.Alias) error {
+	teamKey := getTeamKey(teamID)
+	newTeamKey := getTeamKey(newTeamID)
+	renameKey := getTeamRenameKey(teamID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var team models.Team
+		if err := tx.Get(teamKey, &team); err != nil || team.Deleted != nil {
+			return fmt.Errorf("Could not find team '%s': %v", teamID, err)
+		}
+		var rename teamRename
+		err := tx.Get(renameKey, &rename)
+		if err == nil && rename.NewID == newTeamID {
+			// Carrying on with an earlier attempt
+			return nil
+		}
+		if err == nil && rename.OldID != "" {
+			return storage.TeamRenamingError(teamID)
+		}
+		if err == nil {
+			return fmt.Errorf("Team '%s' is already being renamed to '%s', which must be finished first", teamID, rename.NewID)
+		}
+		if err != datastore.ErrNoSuchEntity {
+			return fmt.Errorf("Could not check for a rename of team '%s': %s", teamID, err)
+		}
+		var existing models.Team
+		err = tx.Get(newTeamKey, &existing)
+		if err == nil && existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(newTeamID, ""))
+		}
+		if err == nil {
+			return storage.TeamExistsError(newTeamID)
+		}
+		if err != datastore.ErrNoSuchEntity {
+			return fmt.Errorf("Could not check for team '%s': %s", newTeamID, err)
+		}
+		team.ID = newTeamID
+		if _, err := tx.Put(newTeamKey, &team); err != nil {
+			return err
+		}
+		if _, err := tx.Put(getTeamRenameKey(newTeamID), &teamRename{OldID: teamID}); err != nil {
+			return err
+		}
+		_, err = tx.Put(renameKey, &teamRename{NewID: newTeamID, Alias: alias})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.moveTeamChildren(ctx, teamKey, newTeamKey); err != nil {
+		return fmt.Errorf("Could not move the periods of team '%s' to '%s': %s", teamID, newTeamID, err)
+	}
+
+	var backups models.TeamBackups
+	err = s.client.Get(ctx, getTeamBackupsKey(teamKey), &backups)
+	if err == nil {
+		backups.Rename(newTeamID)
+		if _, err := s.client.Put(ctx, getTeamBackupsKey(newTeamKey), &backups); err != nil {
+			return fmt.Errorf("Could not move the backups of team '%s' to '%s': %s", teamID, newTeamID, err)
+		}
+		if err := s.client.Delete(ctx, getTeamBackupsKey(teamKey)); err != nil {
+			return fmt.Errorf("Could not move the backups of team '%s' to '%s': %s", teamID, newTeamID, err)
+		}
+	} else if err != datastore.ErrNoSuchEntity {
+		return fmt.Errorf("Could not retrieve the backups of team '%s': %s", teamID, err)
+	}
+
+	var entries []models.AuditEntry
+	entryKeys, err := s.client.GetAll(ctx, datastore.NewQuery(AuditEntryKind).Ancestor(teamKey), &entries)
+	if err != nil {
+		return fmt.Errorf("Could not retrieve the audit entries of team '%s': %s", teamID, err)
+	}
+	newEntryKeys := make([]*datastore.Key, len(entryKeys))
+	for i := range entries {
+		entries[i].TeamID = newTeamID
+		newEntryKeys[i] = getAuditEntryKey(newTeamKey, entries[i].ID)
+	}
+	if err := moveEntities(ctx, s.client, entryKeys, newEntryKeys, entries); err != nil {
+		return fmt.Errorf("Could not move the audit entries of team '%s' to '%s': %s", teamID, newTeamID, err)
+	}
+
+	var items []models.DeletedItem
+	itemKeys, err := s.client.GetAll(ctx, datastore.NewQuery(DeletedItemKind).FilterField("TeamID", "=", teamID), &items)
+	if err != nil {
+		return fmt.Errorf("Could not retrieve the deleted periods of team '%s': %s", teamID, err)
+	}
+	newItemKeys := make([]*datastore.Key, len(itemKeys))
+	for i := range items {
+		items[i].TeamID = newTeamID
+		newItemKeys[i] = getDeletedItemKey(newTeamID, items[i].PeriodID)
+	}
+	if err := moveEntities(ctx, s.client, itemKeys, newItemKeys, items); err != nil {
+		return fmt.Errorf("Could not move the deleted periods of team '%s' to '%s': %s", teamID, newTeamID, err)
+	}
+
+	if err := s.updateAPIKeys(ctx, func(key *models.APIKey) bool { return key.RenameTeam(teamID, newTeamID) }); err != nil {
+		return fmt.Errorf("Could not rename team '%s' to '%s' in API keys: %s", teamID, newTeamID, err)
+	}
+
+	_, err = s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var rename teamRename
+		if err := tx.Get(renameKey, &rename); err != nil || rename.NewID != newTeamID {
+			return fmt.Errorf("Could not find the rename of team '%s' to '%s': %v", teamID, newTeamID, err)
+		}
+		// The team may have been edited while its periods were being moved
+		var team models.Team
+		if err := tx.Get(teamKey, &team); err != nil {
+			return fmt.Errorf("Could not find team '%s': %v", teamID, err)
+		}
+		team.ID = newTeamID
+		if _, err := tx.Put(newTeamKey, &team); err != nil {
+			return err
+		}
+		if err := tx.DeleteMulti([]*datastore.Key{teamKey, renameKey, getTeamRenameKey(newTeamID), getTeamAliasKey(newTeamID)}); err != nil {
+			return err
+		}
+		_, err := tx.Put(getTeamAliasKey(teamID), &rename.Alias)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Could not finish renaming team '%s' to '%s': %s", teamID, newTeamID, err)
+	}
+	return nil
+}
+
+// moveTeamChildren moves the periods, period backups, templates and period aliases of a
+// team to the new team, a batch at a time. Anything already moved is no longer found under
+// the old team, so this carries on from where it stopped if it fails part way.
+func (s *googleCDSStore) moveTeamChildren(ctx context.Context, teamKey, newTeamKey *datastore.Key) error {
+	keys, err := s.client.GetAll(ctx, datastore.NewQuery("").Ancestor(teamKey).KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+	var oldKeys, newKeys []*datastore.Key
+	for _, key := range keys {
+		switch key.Kind {
+		case TeamKind, AuditEntryKind, TeamBackupsKind:
+			// The team itself, and the entities which are changed as they are moved
+			continue
+		case PeriodTemplatesKind:
+			// Named after the team, like the team backups
+			newKeys = append(newKeys, getPeriodTemplatesKey(newTeamKey))
+		default:
+			newKeys = append(newKeys, datastore.NameKey(key.Kind, key.Name, newTeamKey))
+		}
+		oldKeys = append(oldKeys, key)
+	}
+	for start := 0; start < len(oldKeys); start += renameBatchSize {
+		end := min(start+renameBatchSize, len(oldKeys))
+		entities := make([]datastore.PropertyList, end-start)
+		if err := s.client.GetMulti(ctx, oldKeys[start:end], entities); err != nil {
+			return err
+		}
+		if err := moveEntities(ctx, s.client, oldKeys[start:end], newKeys[start:end], entities); err != nil {
+			return err
+		}
+		if s.afterRenameBatch != nil {
+			if err := s.afterRenameBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *googleCDSStore) RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string, alias models.Alias) error {
+	teamKey := getTeamKey(teamID)
+	periodKey := getPeriodKey(teamKey, periodID)
+	newPeriodKey := getPeriodKey(teamKey, newPeriodID)
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := checkNotRenaming(tx, teamID); err != nil {
+			return err
+		}
+		var period models.Period
+		if err := tx.Get(periodKey, &period); err != nil || period.Deleted != nil {
+			return fmt.Errorf("Could not find period '%s' for team '%s': %v", periodID, teamID, err)
+		}
+		var existing models.Period
+		err := tx.Get(newPeriodKey, &existing)
+		if err == nil && existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(teamID, newPeriodID))
+		}
+		if err == nil {
+			return storage.PeriodExistsError(newPeriodID)
+		}
+		if err != datastore.ErrNoSuchEntity {
+			return fmt.Errorf("Could not check for period '%s' for team '%s': %s", newPeriodID, teamID, err)
+		}
+		var backups models.PeriodBackups
+		err = tx.Get(getPeriodBackupsKey(teamKey, periodID), &backups)
+		if err == nil {
+			backups.Rename(newPeriodID)
+			if _, err := tx.Put(getPeriodBackupsKey(teamKey, newPeriodID), &backups); err != nil {
+				return err
+			}
+		} else if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+
+		period.ID = newPeriodID
+		if _, err := tx.Put(newPeriodKey, &period); err != nil {
+			return err
+		}
+		err = tx.DeleteMulti([]*datastore.Key{periodKey, getPeriodBackupsKey(teamKey, periodID), getPeriodAliasKey(teamKey, newPeriodID)})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Put(getPeriodAliasKey(teamKey, periodID), &alias)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var entries []models.AuditEntry
+	query := datastore.NewQuery(AuditEntryKind).Ancestor(teamKey).FilterField("PeriodID", "=", periodID)
+	entryKeys, err := s.client.GetAll(ctx, query, &entries)
+	if err != nil {
+		return fmt.Errorf("Renamed period '%s' to '%s', but could not retrieve its audit entries: %s", periodID, newPeriodID, err)
+	}
+	for i := range entries {
+		entries[i].PeriodID = newPeriodID
+	}
+	const batchSize = 500
+	for start := 0; start < len(entryKeys); start += batchSize {
+		end := min(start+batchSize, len(entryKeys))
+		if _, err := s.client.PutMulti(ctx, entryKeys[start:end], entries[start:end]); err != nil {
+			return fmt.Errorf("Renamed period '%s' to '%s', but could not update its audit entries: %s", periodID, newPeriodID, err)
+		}
+	}
+	return nil
+}
+
+func (s *googleCDSStore) GetTeamAlias(ctx context.Context, teamID string) (models.Alias, bool, error) {
+	var alias models.Alias
+	err := s.client.Get(ctx, getTeamAliasKey(teamID), &alias)
+	if err == datastore.ErrNoSuchEntity {
+		return alias, false, nil
+	}
+	return alias, err == nil, err
+}
+
+func (s *googleCDSStore) GetPeriodAlias(ctx context.Context, teamID, periodID string) (models.Alias, bool, error) {
+	var alias models.Alias
+	err := s.client.Get(ctx, getPeriodAliasKey(getTeamKey(teamID), periodID), &alias)
+	if err == datastore.ErrNoSuchEntity {
+		return alias, false, nil
+	}
+	return alias, err == nil, err
+}
+
+func (s *googleCDSStore) GetRenamedItems(ctx context.Context) ([]models.RenamedItem, error) {
+	var teamAliases, periodAliases []models.Alias
+	teamKeys, err := s.client.GetAll(ctx, datastore.NewQuery(TeamAliasKind), &teamAliases)
+	if err != nil {
+		return nil, err
+	}
+	periodKeys, err := s.client.GetAll(ctx, datastore.NewQuery(PeriodAliasKind), &periodAliases)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.RenamedItem, 0, len(teamAliases)+len(periodAliases))
+	for i, key := range teamKeys {
+		result = append(result, models.RenamedItem{TeamID: key.Name, Alias: teamAliases[i]})
+	}
+	for i, key := range periodKeys {
+		result = append(result, models.RenamedItem{TeamID: key.Parent.Name, PeriodID: key.Name, Alias: periodAliases[i]})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+func (s *googleCDSStore) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var result []models.APIKey
+	if _, err := s.client.GetAll(ctx, datastore.NewQuery(APIKeyKind), &result); err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreationTime.Before(result[j].CreationTime)
+	})
+	return result, nil
+}
+
+func (s *googleCDSStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error) {
+	var key models.APIKey
+	err := s.client.Get(ctx, getAPIKeyKey(keyID), &key)
+	if err == datastore.ErrNoSuchEntity {
+		return key, false, nil
+	}
+	return key, err == nil, err
+}
+
+func (s *googleCDSStore) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	_, err := s.client.Put(ctx, getAPIKeyKey(key.ID), &key)
+	return err
+}
+
+// updateAPIKeys saves every API key which update changes, reporting that it did so.
+// There are few API keys, so they are all read rather than queried by team.
+func (s *googleCDSStore) updateAPIKeys(ctx context.Context, update func(key *models.APIKey) bool) error {
+	var keys []models.APIKey
+	if _, err := s.client.GetAll(ctx, datastore.NewQuery(APIKeyKind), &keys); err != nil {
+		return err
+	}
+	for i := range keys {
+		if !update(&keys[i]) {
+			continue
+		}
+		if _, err := s.client.Put(ctx, getAPIKeyKey(keys[i].ID), &keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *googleCDSStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	return s.client.Delete(ctx, getAPIKeyKey(keyID))
+}
+
 func (s *googleCDSStore) Close() error {
 	return s.client.Close()
 }
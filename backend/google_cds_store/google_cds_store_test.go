@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package google_cds_store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"peoplemath/models"
+	"peoplemath/storage"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/google/uuid"
+)
+
+// makeEmulatorStore connects to the Datastore emulator, which is started with
+// `gcloud beta emulators datastore start`. If DATASTORE_EMULATOR_HOST isn't set,
+// as the emulator tells you to, the test is skipped.
+func makeEmulatorStore(t *testing.T) *googleCDSStore {
+	t.Helper()
+	if os.Getenv("DATASTORE_EMULATOR_HOST") == "" {
+		t.Skip("DATASTORE_EMULATOR_HOST is not set, so there is no Datastore emulator to test with")
+	}
+	client, err := datastore.NewClient(context.Background(), "peoplemath-test")
+	if err != nil {
+		t.Fatalf("Could not connect to the Datastore emulator: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &googleCDSStore{client: client}
+}
+
+func TestRenameTeamResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	store := makeEmulatorStore(t)
+	// The emulator keeps its data between runs
+	suffix := uuid.New().String()
+	teamID, newTeamID := "old-"+suffix, "new-"+suffix
+	if err := store.CreateTeam(ctx, models.Team{ID: teamID}); err != nil {
+		t.Fatalf("Could not create team: %v", err)
+	}
+	const periodCount = 3*renameBatchSize - 5
+	for i := 0; i < periodCount; i++ {
+		if err := store.CreatePeriod(ctx, teamID, &models.Period{ID: fmt.Sprintf("p%d", i), LastUpdateUUID: "v1"}); err != nil {
+			t.Fatalf("Could not create period: %v", err)
+		}
+	}
+
+	store.afterRenameBatch = func() error {
+		return errors.New("interrupted")
+	}
+	alias := models.Alias{NewID: newTeamID}
+	if err := store.RenameTeam(ctx, teamID, newTeamID, alias); err == nil {
+		t.Fatal("Expected the interrupted rename to fail")
+	}
+
+	// One batch has been moved, and nothing can be written under either ID until the rename is finished
+	remaining, _, err := store.GetAllPeriods(ctx, teamID)
+	if err != nil || len(remaining) != periodCount-renameBatchSize {
+		t.Fatalf("Expected %d periods still to be moved, found %d (%v)", periodCount-renameBatchSize, len(remaining), err)
+	}
+	err = store.ModifyPeriod(ctx, teamID, remaining[0].ID, "v1", func(period *models.Period) error {
+		t.Error("Expected no change to a period during the rename")
+		return nil
+	})
+	if _, ok := err.(storage.TeamRenamingError); !ok {
+		t.Errorf("Expected a change to a period still to be moved to fail with TeamRenamingError, found %v", err)
+	}
+	for _, id := range []string{teamID, newTeamID} {
+		err := store.CreatePeriod(ctx, id, &models.Period{ID: "extra"})
+		if _, ok := err.(storage.TeamRenamingError); !ok {
+			t.Errorf("Expected a new period for team '%s' to fail with TeamRenamingError, found %v", id, err)
+		}
+	}
+
+	store.afterRenameBatch = nil
+	if err := store.RenameTeam(ctx, teamID, newTeamID, alias); err != nil {
+		t.Fatalf("Could not resume the rename: %v", err)
+	}
+	periods, found, err := store.GetAllPeriods(ctx, newTeamID)
+	if err != nil || !found || len(periods) != periodCount {
+		t.Errorf("Expected all %d periods under the new ID, found %d (%v)", periodCount, len(periods), err)
+	}
+	if _, found, _ := store.GetTeam(ctx, teamID); found {
+		t.Error("Expected no team at the old ID")
+	}
+	if got, found, err := store.GetTeamAlias(ctx, teamID); err != nil || !found || got.NewID != newTeamID {
+		t.Errorf("Expected an alias to '%s', found %v (%v)", newTeamID, got, err)
+	}
+	err = store.ModifyPeriod(ctx, newTeamID, "p0", "v1", func(period *models.Period) error {
+		period.DisplayName = "Changed"
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected the renamed team to be writable, found %v", err)
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2019-2021, 2023 Google LLC
+// Copyright 2019-2021, 2023, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -20,7 +20,11 @@ import (
 	"log"
 	"math/rand"
 	"peoplemath/models"
+	"peoplemath/storage"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // In-memory implementation of StorageService, for local testing
@@ -28,7 +32,13 @@ type InMemStore struct {
 	teams         map[string]models.Team
 	periods       map[string]map[string]models.Period
 	periodBackups map[string]map[string]models.PeriodBackups
+	teamTemplates map[string]models.PeriodTemplates
+	teamBackups   map[string]models.TeamBackups
+	teamAliases   map[string]models.Alias
+	periodAliases map[string]map[string]models.Alias
+	apiKeys       []models.APIKey // Oldest first
 	settings      models.Settings
+	auditEntries  []models.AuditEntry // Oldest first
 }
 
 // The defaultDomain can be specified as a flag when running the application
@@ -51,6 +61,7 @@ func MakeInMemStore(defaultDomain string) *InMemStore {
 	generalPermissions := models.GeneralPermissions{
 		ReadTeamList: defaultPermissionList,
 		AddTeam:      defaultPermissionList,
+		Administer:   defaultPermissionList,
 	}
 	periods := map[string]map[string]models.Period{
 		"team1": {
@@ -72,7 +83,13 @@ func MakeInMemStore(defaultDomain string) *InMemStore {
 		GeneralPermissions: generalPermissions,
 	}
 
-	return &InMemStore{teams: teams, periods: periods, periodBackups: periodBackups, settings: settings}
+	teamTemplates := make(map[string]models.PeriodTemplates)
+	teamBackups := make(map[string]models.TeamBackups)
+	teamAliases := make(map[string]models.Alias)
+	periodAliases := make(map[string]map[string]models.Alias)
+
+	return &InMemStore{teams: teams, periods: periods, periodBackups: periodBackups, teamTemplates: teamTemplates,
+		teamBackups: teamBackups, teamAliases: teamAliases, periodAliases: periodAliases, settings: settings}
 }
 
 // AddAuthTestUsersAndTeam adds some test users plus a team, for unit tests
@@ -97,11 +114,19 @@ func (s *InMemStore) AddAuthTestUsersAndTeam() {
 	teamPermissions := models.TeamPermissions{
 		Read:  permissionsListInclC,
 		Write: permissionsList,
+		Admin: models.Permission{Allow: []models.UserMatcher{{
+			Type: models.UserMatcherTypeEmail,
+			ID:   userAEmail,
+		}}},
 	}
 
 	generalPermission := models.GeneralPermissions{
 		ReadTeamList: permissionsListInclC,
 		AddTeam:      permissionsList,
+		Administer: models.Permission{Allow: []models.UserMatcher{{
+			Type: models.UserMatcherTypeEmail,
+			ID:   userAEmail,
+		}}},
 	}
 
 	s.teams["teamAuthTest"] = models.Team{ID: "teamAuthTest", DisplayName: "Team authTest", Permissions: teamPermissions}
@@ -111,17 +136,25 @@ func (s *InMemStore) AddAuthTestUsersAndTeam() {
 func (s *InMemStore) GetAllTeams(ctx context.Context) ([]models.Team, error) {
 	teamsSlice := make([]models.Team, 0, len(s.teams))
 	for _, t := range s.teams {
-		teamsSlice = append(teamsSlice, t)
+		if t.Deleted == nil {
+			teamsSlice = append(teamsSlice, t)
+		}
 	}
 	return teamsSlice, nil
 }
 
 func (s *InMemStore) GetTeam(ctx context.Context, teamID string) (models.Team, bool, error) {
 	team, ok := s.teams[teamID]
+	if ok && team.Deleted != nil {
+		return models.Team{}, false, nil
+	}
 	return team, ok, nil
 }
 
 func (s *InMemStore) CreateTeam(ctx context.Context, team models.Team) error {
+	if existing, ok := s.teams[team.ID]; ok && existing.Deleted != nil {
+		return storage.DeletedError(storage.DeletedItemID(team.ID, ""))
+	}
 	s.teams[team.ID] = team
 	s.periods[team.ID] = map[string]models.Period{}
 	log.Printf("Added new team %s", team.ID)
@@ -135,10 +168,15 @@ func (s *InMemStore) UpdateTeam(ctx context.Context, team models.Team) error {
 }
 
 func (s *InMemStore) GetAllPeriods(ctx context.Context, teamID string) ([]models.Period, bool, error) {
+	if team, ok := s.teams[teamID]; ok && team.Deleted != nil {
+		return []models.Period{}, false, nil
+	}
 	if periodsByName, ok := s.periods[teamID]; ok {
 		periodSlice := make([]models.Period, 0, len(periodsByName))
 		for _, p := range periodsByName {
-			periodSlice = append(periodSlice, p)
+			if p.Deleted == nil {
+				periodSlice = append(periodSlice, p)
+			}
 		}
 		return periodSlice, true, nil
 	}
@@ -147,7 +185,7 @@ func (s *InMemStore) GetAllPeriods(ctx context.Context, teamID string) ([]models
 
 func (s *InMemStore) GetPeriod(ctx context.Context, teamID, periodID string) (*models.Period, bool, error) {
 	if periodsByName, ok := s.periods[teamID]; ok {
-		if period, ok := periodsByName[periodID]; ok {
+		if period, ok := periodsByName[periodID]; ok && period.Deleted == nil {
 			return &period, true, nil
 		}
 	}
@@ -156,6 +194,9 @@ func (s *InMemStore) GetPeriod(ctx context.Context, teamID, periodID string) (*m
 
 func (s *InMemStore) CreatePeriod(ctx context.Context, teamID string, period *models.Period) error {
 	if periodsByName, ok := s.periods[teamID]; ok {
+		if existing, ok := periodsByName[period.ID]; ok && existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(teamID, period.ID))
+		}
 		periodsByName[period.ID] = *period
 		log.Printf("Added period '%s' for team '%s': %v", period.ID, teamID, period)
 	}
@@ -170,6 +211,23 @@ func (s *InMemStore) UpdatePeriod(ctx context.Context, teamID string, period *mo
 	return nil
 }
 
+func (s *InMemStore) ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error {
+	period, ok := s.periods[teamID][periodID]
+	if !ok || period.Deleted != nil {
+		return fmt.Errorf("no period '%s' for team '%s'", periodID, teamID)
+	}
+	if period.LastUpdateUUID != lastUpdateUUID {
+		return storage.ConcurrentModificationError(fmt.Sprintf(
+			"last saved UUID=%s, your last loaded UUID=%s", period.LastUpdateUUID, lastUpdateUUID))
+	}
+	if err := modify(&period); err != nil {
+		return err
+	}
+	s.periods[teamID][periodID] = period
+	log.Printf("Modified period '%s' for team '%s': %v", periodID, teamID, period)
+	return nil
+}
+
 func (s *InMemStore) GetPeriodBackups(ctx context.Context, teamID, periodID string) (models.PeriodBackups, bool, error) {
 	if backupsByName, ok := s.periodBackups[teamID]; ok {
 		if backups, ok := backupsByName[periodID]; ok {
@@ -186,14 +244,334 @@ func (s *InMemStore) UpsertPeriodBackups(ctx context.Context, teamID, periodID s
 		backupsByName = map[string]models.PeriodBackups{}
 		s.periodBackups[teamID] = backupsByName
 	}
+	backups.Tags = backupsByName[periodID].Tags
+	retention := s.settings.GetBackupRetention()
+	backups.Prune(retention, time.Now())
 	backupsByName[periodID] = backups
 	return nil
 }
 
+func (s *InMemStore) UpdatePeriodTags(ctx context.Context, teamID, periodID string, update func(backups models.PeriodBackups) ([]models.VersionTag, error)) error {
+	backups, _, err := s.GetPeriodBackups(ctx, teamID, periodID)
+	if err != nil {
+		return err
+	}
+	tags, err := update(backups)
+	if err != nil {
+		return err
+	}
+	backups.Tags = tags
+	if _, ok := s.periodBackups[teamID]; !ok {
+		s.periodBackups[teamID] = map[string]models.PeriodBackups{}
+	}
+	s.periodBackups[teamID][periodID] = backups
+	return nil
+}
+
+func (s *InMemStore) GetTeamBackups(ctx context.Context, teamID string) (models.TeamBackups, bool, error) {
+	backups, ok := s.teamBackups[teamID]
+	return backups, ok, nil
+}
+
+func (s *InMemStore) UpsertTeamBackups(ctx context.Context, teamID string, backups models.TeamBackups) error {
+	s.teamBackups[teamID] = backups
+	return nil
+}
+
+func (s *InMemStore) GetTeamTemplates(ctx context.Context, teamID string) (models.PeriodTemplates, bool, error) {
+	templates, ok := s.teamTemplates[teamID]
+	return templates, ok, nil
+}
+
+func (s *InMemStore) UpsertTeamTemplates(ctx context.Context, teamID string, templates models.PeriodTemplates) error {
+	s.teamTemplates[teamID] = templates
+	return nil
+}
+
 func (s *InMemStore) GetSettings(ctx context.Context) (models.Settings, error) {
 	return s.settings, nil
 }
 
+func (s *InMemStore) UpdateSettings(ctx context.Context, settings models.Settings) error {
+	s.settings = settings
+	return nil
+}
+
+func (s *InMemStore) UpdateOrgTemplates(ctx context.Context, templates []models.PeriodTemplate) error {
+	s.settings.PeriodTemplates = templates
+	return nil
+}
+
+func (s *InMemStore) AddAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	s.auditEntries = append(s.auditEntries, entry)
+	return nil
+}
+
+// GetAuditEntries uses the number of matching entries already returned as the page token
+func (s *InMemStore) GetAuditEntries(ctx context.Context, teamID, periodID, pageToken string, pageSize int) (models.AuditPage, error) {
+	skip := 0
+	if pageToken != "" {
+		var err error
+		if skip, err = strconv.Atoi(pageToken); err != nil {
+			return models.AuditPage{}, fmt.Errorf("invalid page token '%s'", pageToken)
+		}
+	}
+	page := models.AuditPage{Entries: []models.AuditEntry{}}
+	matched := 0
+	for i := len(s.auditEntries) - 1; i >= 0; i-- {
+		entry := s.auditEntries[i]
+		if entry.TeamID != teamID || (periodID != "" && entry.PeriodID != periodID) {
+			continue
+		}
+		matched++
+		if matched <= skip {
+			continue
+		}
+		if len(page.Entries) == pageSize {
+			page.NextPageToken = strconv.Itoa(skip + pageSize)
+			break
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+func (s *InMemStore) DeleteTeam(ctx context.Context, teamID string, deletion models.Deletion) error {
+	team, ok := s.teams[teamID]
+	if !ok || team.Deleted != nil {
+		return fmt.Errorf("team '%s' not found", teamID)
+	}
+	team.Deleted = &deletion
+	s.teams[teamID] = team
+	return nil
+}
+
+func (s *InMemStore) DeletePeriod(ctx context.Context, teamID, periodID string, deletion models.Deletion) error {
+	period, ok := s.periods[teamID][periodID]
+	if !ok || period.Deleted != nil {
+		return fmt.Errorf("period '%s' for team '%s' not found", periodID, teamID)
+	}
+	period.Deleted = &deletion
+	s.periods[teamID][periodID] = period
+	return nil
+}
+
+func (s *InMemStore) GetDeletedItems(ctx context.Context) ([]models.DeletedItem, error) {
+	result := []models.DeletedItem{}
+	for teamID, team := range s.teams {
+		if team.Deleted != nil {
+			result = append(result, models.DeletedItem{
+				TeamID:      teamID,
+				DisplayName: team.DisplayName,
+				Deletion:    *team.Deleted,
+				Permissions: team.Permissions,
+			})
+		}
+		for periodID, period := range s.periods[teamID] {
+			if period.Deleted != nil {
+				result = append(result, models.DeletedItem{
+					TeamID:      teamID,
+					PeriodID:    periodID,
+					DisplayName: period.DisplayName,
+					Deletion:    *period.Deleted,
+				})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+func (s *InMemStore) RestoreDeletedItem(ctx context.Context, teamID, periodID string) error {
+	if periodID == "" {
+		team, ok := s.teams[teamID]
+		if !ok || team.Deleted == nil {
+			return fmt.Errorf("deleted team '%s' not found", teamID)
+		}
+		team.Deleted = nil
+		s.teams[teamID] = team
+		return nil
+	}
+	period, ok := s.periods[teamID][periodID]
+	if !ok || period.Deleted == nil {
+		return fmt.Errorf("deleted period '%s' for team '%s' not found", periodID, teamID)
+	}
+	period.Deleted = nil
+	s.periods[teamID][periodID] = period
+	return nil
+}
+
+func (s *InMemStore) PurgeDeletedItem(ctx context.Context, teamID, periodID string) error {
+	if periodID != "" {
+		period, ok := s.periods[teamID][periodID]
+		if !ok || period.Deleted == nil {
+			return fmt.Errorf("deleted period '%s' for team '%s' not found", periodID, teamID)
+		}
+		delete(s.periods[teamID], periodID)
+		delete(s.periodBackups[teamID], periodID)
+		return nil
+	}
+	team, ok := s.teams[teamID]
+	if !ok || team.Deleted == nil {
+		return fmt.Errorf("deleted team '%s' not found", teamID)
+	}
+	delete(s.teams, teamID)
+	delete(s.periods, teamID)
+	delete(s.periodBackups, teamID)
+	delete(s.teamTemplates, teamID)
+	delete(s.teamBackups, teamID)
+	entries := s.auditEntries[:0]
+	for _, entry := range s.auditEntries {
+		if entry.TeamID != teamID {
+			entries = append(entries, entry)
+		}
+	}
+	s.auditEntries = entries
+	for i := range s.apiKeys {
+		s.apiKeys[i].RemoveTeam(teamID)
+	}
+	return nil
+}
+
+func (s *InMemStore) RenameTeam(ctx context.Context, teamID, newTeamID string, alias models.Alias) error {
+	team, ok := s.teams[teamID]
+	if !ok || team.Deleted != nil {
+		return fmt.Errorf("team '%s' not found", teamID)
+	}
+	if existing, ok := s.teams[newTeamID]; ok {
+		if existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(newTeamID, ""))
+		}
+		return storage.TeamExistsError(newTeamID)
+	}
+	team.ID = newTeamID
+	s.teams[newTeamID] = team
+	delete(s.teams, teamID)
+	s.periods[newTeamID] = s.periods[teamID]
+	delete(s.periods, teamID)
+	if backups, ok := s.periodBackups[teamID]; ok {
+		s.periodBackups[newTeamID] = backups
+		delete(s.periodBackups, teamID)
+	}
+	if templates, ok := s.teamTemplates[teamID]; ok {
+		s.teamTemplates[newTeamID] = templates
+		delete(s.teamTemplates, teamID)
+	}
+	if backups, ok := s.teamBackups[teamID]; ok {
+		backups.Rename(newTeamID)
+		s.teamBackups[newTeamID] = backups
+		delete(s.teamBackups, teamID)
+	}
+	if aliases, ok := s.periodAliases[teamID]; ok {
+		s.periodAliases[newTeamID] = aliases
+		delete(s.periodAliases, teamID)
+	}
+	for i := range s.auditEntries {
+		if s.auditEntries[i].TeamID == teamID {
+			s.auditEntries[i].TeamID = newTeamID
+		}
+	}
+	for i := range s.apiKeys {
+		s.apiKeys[i].RenameTeam(teamID, newTeamID)
+	}
+	delete(s.teamAliases, newTeamID)
+	s.teamAliases[teamID] = alias
+	return nil
+}
+
+func (s *InMemStore) RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string, alias models.Alias) error {
+	period, ok := s.periods[teamID][periodID]
+	if !ok || period.Deleted != nil {
+		return fmt.Errorf("period '%s' for team '%s' not found", periodID, teamID)
+	}
+	if existing, ok := s.periods[teamID][newPeriodID]; ok {
+		if existing.Deleted != nil {
+			return storage.DeletedError(storage.DeletedItemID(teamID, newPeriodID))
+		}
+		return storage.PeriodExistsError(newPeriodID)
+	}
+	period.ID = newPeriodID
+	s.periods[teamID][newPeriodID] = period
+	delete(s.periods[teamID], periodID)
+	if backups, ok := s.periodBackups[teamID][periodID]; ok {
+		backups.Rename(newPeriodID)
+		s.periodBackups[teamID][newPeriodID] = backups
+		delete(s.periodBackups[teamID], periodID)
+	}
+	for i := range s.auditEntries {
+		if s.auditEntries[i].TeamID == teamID && s.auditEntries[i].PeriodID == periodID {
+			s.auditEntries[i].PeriodID = newPeriodID
+		}
+	}
+	aliases, ok := s.periodAliases[teamID]
+	if !ok {
+		aliases = map[string]models.Alias{}
+		s.periodAliases[teamID] = aliases
+	}
+	delete(aliases, newPeriodID)
+	aliases[periodID] = alias
+	return nil
+}
+
+func (s *InMemStore) GetTeamAlias(ctx context.Context, teamID string) (models.Alias, bool, error) {
+	alias, ok := s.teamAliases[teamID]
+	return alias, ok, nil
+}
+
+func (s *InMemStore) GetPeriodAlias(ctx context.Context, teamID, periodID string) (models.Alias, bool, error) {
+	alias, ok := s.periodAliases[teamID][periodID]
+	return alias, ok, nil
+}
+
+func (s *InMemStore) GetRenamedItems(ctx context.Context) ([]models.RenamedItem, error) {
+	result := []models.RenamedItem{}
+	for teamID, alias := range s.teamAliases {
+		result = append(result, models.RenamedItem{TeamID: teamID, Alias: alias})
+	}
+	for teamID, aliases := range s.periodAliases {
+		for periodID, alias := range aliases {
+			result = append(result, models.RenamedItem{TeamID: teamID, PeriodID: periodID, Alias: alias})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+func (s *InMemStore) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return append([]models.APIKey(nil), s.apiKeys...), nil
+}
+
+func (s *InMemStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error) {
+	for _, key := range s.apiKeys {
+		if key.ID == keyID {
+			return key, true, nil
+		}
+	}
+	return models.APIKey{}, false, nil
+}
+
+func (s *InMemStore) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	if _, found, _ := s.GetAPIKey(ctx, key.ID); found {
+		return fmt.Errorf("API key '%s' already exists", key.ID)
+	}
+	s.apiKeys = append(s.apiKeys, key)
+	return nil
+}
+
+func (s *InMemStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	for i, key := range s.apiKeys {
+		if key.ID == keyID {
+			s.apiKeys = append(s.apiKeys[:i], s.apiKeys[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
 func (s *InMemStore) Close() error {
 	return nil
 }
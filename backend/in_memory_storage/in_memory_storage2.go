@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -23,6 +23,7 @@ import (
 	"peoplemath/storage"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -34,7 +35,11 @@ type InMemStore2 struct {
 	// Team ID -> period ID -> version
 	latestPeriodIDs map[string]map[string]string
 	// Team ID -> period ID -> version -> period
-	periods  map[string]map[string]map[string]models.Period2
+	periods map[string]map[string]map[string]models.Period2
+	// Team ID -> period ID -> versions in the order they were saved
+	versionHistory map[string]map[string][]string
+	// Team ID -> period ID -> tags
+	tags     map[string]map[string][]models.VersionTag
 	settings models.Settings
 	// Mutex for protection against data races
 	mu sync.Mutex
@@ -46,6 +51,8 @@ func MakeEmptyInMemStore() *InMemStore2 {
 		teams:           make(map[string]models.Team),
 		latestPeriodIDs: make(map[string]map[string]string),
 		periods:         make(map[string]map[string]map[string]models.Period2),
+		versionHistory:  make(map[string]map[string][]string),
+		tags:            make(map[string]map[string][]models.VersionTag),
 	}
 }
 
@@ -70,6 +77,7 @@ func MakeInMemStore2(defaultDomain string) *InMemStore2 {
 	generalPermissions := models.GeneralPermissions{
 		ReadTeamList: defaultPermissionList,
 		AddTeam:      defaultPermissionList,
+		Administer:   defaultPermissionList,
 	}
 	periods := map[string][]models.Period2{
 		"team1": {
@@ -87,6 +95,7 @@ func MakeInMemStore2(defaultDomain string) *InMemStore2 {
 	}
 	latestPeriodIDs := make(map[string]map[string]string)
 	periodsByVersion := make(map[string]map[string]map[string]models.Period2)
+	versionHistory := make(map[string]map[string][]string)
 	for teamID, teamPeriods := range periods {
 		var teamLatestPeriods map[string]string
 		var ok bool
@@ -107,6 +116,10 @@ func MakeInMemStore2(defaultDomain string) *InMemStore2 {
 				teamPeriodsByVersion[period.ID] = periodVersions
 			}
 			periodVersions[period.Version] = period
+			if _, ok = versionHistory[teamID]; !ok {
+				versionHistory[teamID] = make(map[string][]string)
+			}
+			versionHistory[teamID][period.ID] = append(versionHistory[teamID][period.ID], period.Version)
 		}
 	}
 
@@ -114,6 +127,8 @@ func MakeInMemStore2(defaultDomain string) *InMemStore2 {
 		teams:           teams,
 		latestPeriodIDs: latestPeriodIDs,
 		periods:         periodsByVersion,
+		versionHistory:  versionHistory,
+		tags:            make(map[string]map[string][]models.VersionTag),
 		settings: models.Settings{
 			ImproveURL:         "https://github.com/google/peoplemath",
 			GeneralPermissions: generalPermissions,
@@ -151,6 +166,10 @@ func (s *InMemStore2) AddAuthTestUsersAndTeam() {
 	generalPermission := models.GeneralPermissions{
 		ReadTeamList: permissionsListInclC,
 		AddTeam:      permissionsList,
+		Administer: models.Permission{Allow: []models.UserMatcher{{
+			Type: models.UserMatcherTypeEmail,
+			ID:   userAEmail,
+		}}},
 	}
 
 	s.teams["teamAuthTest"] = models.Team{ID: "teamAuthTest", DisplayName: "Team authTest", Permissions: teamPermissions}
@@ -190,6 +209,7 @@ func (s *InMemStore2) CreateTeam(ctx context.Context, team models.Team) error {
 	s.teams[team.ID] = team
 	s.latestPeriodIDs[team.ID] = map[string]string{}
 	s.periods[team.ID] = map[string]map[string]models.Period2{}
+	s.versionHistory[team.ID] = map[string][]string{}
 	log.Printf("Added new team %s", team.ID)
 	return nil
 }
@@ -276,6 +296,14 @@ func (s *InMemStore2) UpsertPeriodLatestVersion(ctx context.Context, teamID stri
 	if period.Version == "" {
 		return nil, fmt.Errorf("period has no version")
 	}
+	if period.Author == "" {
+		return nil, fmt.Errorf("period has no author")
+	}
+	if period.Timestamp.IsZero() {
+		saved := *period
+		saved.Timestamp = time.Now()
+		period = &saved
+	}
 
 	latestVersion, err := s.periodLatestVersion(teamID, period.ID)
 	if err == nil {
@@ -305,6 +333,7 @@ func (s *InMemStore2) UpsertPeriodLatestVersion(ctx context.Context, teamID stri
 		}
 		s.latestPeriodIDs[teamID][period.ID] = merged.Version
 		s.periods[teamID][period.ID][merged.Version] = *merged
+		s.versionHistory[teamID][period.ID] = append(s.versionHistory[teamID][period.ID], merged.Version)
 		return merged, nil
 	} else if _, ok := err.(storage.PeriodNotFoundError); ok {
 		if len(period.ParentVersions) != 0 {
@@ -318,16 +347,187 @@ func (s *InMemStore2) UpsertPeriodLatestVersion(ctx context.Context, teamID stri
 		}
 		periodVersions[period.Version] = *period
 		s.latestPeriodIDs[teamID][period.ID] = period.Version
+		s.versionHistory[teamID][period.ID] = []string{period.Version}
 		return period, nil
 	} else {
 		return nil, err
 	}
 }
 
+func (s *InMemStore2) GetPeriodVersions(ctx context.Context, teamID, periodID string) ([]models.PeriodVersionInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.periodLatestVersion(teamID, periodID); err != nil {
+		return nil, err
+	}
+	history := s.versionHistory[teamID][periodID]
+	result := make([]models.PeriodVersionInfo, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		period := s.periods[teamID][periodID][history[i]]
+		result = append(result, period.VersionInfo())
+	}
+	return result, nil
+}
+
+func (s *InMemStore2) GetPeriodVersion(ctx context.Context, teamID, periodID, version string) (*models.Period2, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.periodLatestVersion(teamID, periodID); err != nil {
+		return nil, err
+	}
+	period, ok := s.periods[teamID][periodID][version]
+	if !ok {
+		return nil, storage.PeriodVersionNotFoundError(version)
+	}
+	return &period, nil
+}
+
+func (s *InMemStore2) CompactPeriodVersions(ctx context.Context, teamID, periodID string, policy models.VersionCompaction, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.periodLatestVersion(teamID, periodID)
+	if err != nil {
+		return 0, err
+	}
+	history := s.versionHistory[teamID][periodID]
+	versions := make([]models.Period2, len(history))
+	for i, version := range history {
+		versions[i] = s.periods[teamID][periodID][version]
+	}
+	pinned := make(map[string]bool)
+	for _, tag := range s.tags[teamID][periodID] {
+		pinned[tag.Version] = true
+	}
+	kept := merge.CompactVersions(versions, latest.Version, pinned, policy, now)
+
+	periodVersions := make(map[string]models.Period2)
+	keptHistory := make([]string, len(kept))
+	for i, period := range kept {
+		periodVersions[period.Version] = period
+		keptHistory[i] = period.Version
+	}
+	s.periods[teamID][periodID] = periodVersions
+	s.versionHistory[teamID][periodID] = keptHistory
+	return len(versions) - len(kept), nil
+}
+
+func (s *InMemStore2) GetPeriodTags(ctx context.Context, teamID, periodID string) ([]models.VersionTag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.periodLatestVersion(teamID, periodID); err != nil {
+		return nil, err
+	}
+	return append([]models.VersionTag{}, s.tags[teamID][periodID]...), nil
+}
+
+func (s *InMemStore2) AddPeriodTag(ctx context.Context, teamID, periodID string, tag models.VersionTag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.periodLatestVersion(teamID, periodID); err != nil {
+		return err
+	}
+	if _, ok := s.periods[teamID][periodID][tag.Version]; !ok {
+		return storage.PeriodVersionNotFoundError(tag.Version)
+	}
+	if models.FindTag(s.tags[teamID][periodID], tag.Name) >= 0 {
+		return storage.TagExistsError(tag.Name)
+	}
+	if _, ok := s.tags[teamID]; !ok {
+		s.tags[teamID] = make(map[string][]models.VersionTag)
+	}
+	s.tags[teamID][periodID] = append(s.tags[teamID][periodID], tag)
+	return nil
+}
+
+func (s *InMemStore2) DeletePeriodTag(ctx context.Context, teamID, periodID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := s.tags[teamID][periodID]
+	i := models.FindTag(tags, name)
+	if i < 0 {
+		return storage.TagNotFoundError(name)
+	}
+	s.tags[teamID][periodID] = append(tags[:i:i], tags[i+1:]...)
+	return nil
+}
+
+func (s *InMemStore2) RenameTeam(ctx context.Context, teamID, newTeamID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team, ok := s.teams[teamID]
+	if !ok {
+		return storage.TeamNotFoundError(teamID)
+	}
+	if _, ok := s.teams[newTeamID]; ok {
+		return storage.TeamExistsError(newTeamID)
+	}
+	team.ID = newTeamID
+	s.teams[newTeamID] = team
+	delete(s.teams, teamID)
+	s.latestPeriodIDs[newTeamID] = s.latestPeriodIDs[teamID]
+	delete(s.latestPeriodIDs, teamID)
+	s.periods[newTeamID] = s.periods[teamID]
+	delete(s.periods, teamID)
+	s.versionHistory[newTeamID] = s.versionHistory[teamID]
+	delete(s.versionHistory, teamID)
+	if tags, ok := s.tags[teamID]; ok {
+		s.tags[newTeamID] = tags
+		delete(s.tags, teamID)
+	}
+	return nil
+}
+
+func (s *InMemStore2) RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.periodLatestVersion(teamID, periodID); err != nil {
+		return err
+	}
+	if _, ok := s.latestPeriodIDs[teamID][newPeriodID]; ok {
+		return storage.PeriodExistsError(newPeriodID)
+	}
+	// Each version records the period's ID, so all of them have to change
+	periodVersions := make(map[string]models.Period2)
+	for version, period := range s.periods[teamID][periodID] {
+		period.ID = newPeriodID
+		periodVersions[version] = period
+	}
+	s.periods[teamID][newPeriodID] = periodVersions
+	delete(s.periods[teamID], periodID)
+	s.latestPeriodIDs[teamID][newPeriodID] = s.latestPeriodIDs[teamID][periodID]
+	delete(s.latestPeriodIDs[teamID], periodID)
+	s.versionHistory[teamID][newPeriodID] = s.versionHistory[teamID][periodID]
+	delete(s.versionHistory[teamID], periodID)
+	if tags, ok := s.tags[teamID][periodID]; ok {
+		s.tags[teamID][newPeriodID] = tags
+		delete(s.tags[teamID], periodID)
+	}
+	return nil
+}
+
 func (s *InMemStore2) GetSettings(ctx context.Context) (models.Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return s.settings, nil
 }
 
+func (s *InMemStore2) UpdateSettings(ctx context.Context, settings models.Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings = settings
+	return nil
+}
+
 func (s *InMemStore2) Close() error {
 	return nil
 }
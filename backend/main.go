@@ -1,4 +1,4 @@
-// Copyright 2019-2020, 2024 Google LLC
+// Copyright 2019-2020, 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -21,20 +21,26 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
 
+	"peoplemath/analytics"
+	"peoplemath/archive"
 	"peoplemath/auth"
 	"peoplemath/controllers"
 	"peoplemath/google_cds_store"
 	"peoplemath/in_memory_storage"
+	"peoplemath/models"
 	"peoplemath/storage"
 )
 
 const (
 	defaultStoreTimeout = 5 * time.Second
 	defaultAuthTimeout  = 5 * time.Second
+	// How long the deployment admins are cached for, when authenticating users
+	settingsCacheTTL = 30 * time.Second
 )
 
 func makeStorageService(ctx context.Context, useInMemStore bool, defaultDomain string) (storage.StorageService, error) {
@@ -51,7 +57,7 @@ func makeStorageService(ctx context.Context, useInMemStore bool, defaultDomain s
 	return google_cds_store.MakeGoogleCDSStore(ctx, gcloudProject)
 }
 
-func makeAuth(ctx context.Context, authMode string) (auth.Auth, error) {
+func makeAuth(ctx context.Context, authMode string, settings auth.SettingsSource, oidcConfig auth.OIDCConfig, proxyConfig auth.ProxyConfig) (auth.Auth, error) {
 	if authMode == "none" {
 		return auth.NoAuth{}, nil
 	} else if authMode == "firebase" {
@@ -67,22 +73,130 @@ func makeAuth(ctx context.Context, authMode string) (auth.Auth, error) {
 		firebaseAuth := &auth.FirebaseAuth{
 			FirebaseClient: firebaseClient,
 			AuthTimeout:    defaultAuthTimeout,
+			Settings:       settings,
 		}
 		return firebaseAuth, nil
+	} else if authMode == "oidc" {
+		log.Printf("Using OIDC authentication with issuer '%s' per command-line flag", oidcConfig.Issuer)
+		oidcAuth, err := auth.MakeOIDCAuth(oidcConfig, settings)
+		if err != nil {
+			return nil, fmt.Errorf("could not set up OIDC authentication: %v", err)
+		}
+		return oidcAuth, nil
+	} else if authMode == "proxy" {
+		log.Printf("Using identities from the %s header of an authenticating proxy per command-line flag", proxyConfig.Header)
+		proxyAuth, err := auth.MakeProxyAuth(proxyConfig, settings)
+		if err != nil {
+			return nil, fmt.Errorf("could not set up proxy authentication: %v", err)
+		}
+		return proxyAuth, nil
 	} else {
-		return nil, fmt.Errorf("%s is not a supported authMode. Supported are 'none' and 'firebase'", authMode)
+		return nil, fmt.Errorf("%s is not a supported authMode. Supported are 'none', 'firebase', 'oidc' and 'proxy'", authMode)
+	}
+
+}
+
+func writeArchive(ctx context.Context, store storage.StorageService, archiveDir, archiveUser string) error {
+	var options archive.Options
+	if archiveUser != "" {
+		emailParts := strings.Split(archiveUser, "@")
+		user := models.User{Email: archiveUser, Domain: emailParts[len(emailParts)-1]}
+		settings, err := store.GetSettings(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get settings: %v", err)
+		}
+		user.IsAdmin = user.IsPermitted(settings.Admins.Allow)
+		log.Printf("Archiving only teams readable by %s", archiveUser)
+		options.TeamFilter = archive.ReadableBy(auth.OfflineAuth{}, user)
+	}
+	return archive.WriteSite(ctx, store, archiveDir, options)
+}
+
+func writeAnalytics(ctx context.Context, store storage.StorageService, analyticsDir, analyticsSince string) error {
+	var options analytics.Options
+	if analyticsSince != "" {
+		since, err := time.Parse(time.RFC3339, analyticsSince)
+		if err != nil {
+			return fmt.Errorf("could not parse analyticssince: %v", err)
+		}
+		options.Since = since
+	}
+	return analytics.WriteFiles(ctx, store, analyticsDir, options)
+}
+
+// compactPeriodically compacts the saved versions of all periods at the given interval
+func compactPeriodically(ctx context.Context, store2 storage.StorageService2, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		removed, err := storage.CompactAllPeriodVersions(ctx, store2, time.Now())
+		if err != nil {
+			log.Printf("Could not compact period versions: %s", err)
+			continue
+		}
+		if removed > 0 {
+			log.Printf("Compacted period versions: removed %d", removed)
+		}
 	}
+}
 
+// purgePeriodically purges deleted teams and periods whose grace period has passed, at the given interval
+func purgePeriodically(ctx context.Context, store storage.StorageService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		purged, err := storage.PurgeExpiredDeletedItems(ctx, store, time.Now())
+		if err != nil {
+			log.Printf("Could not purge deleted items: %s", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged deleted items: %d", purged)
+		}
+	}
 }
 
 func main() {
 	var useInMemStore bool
 	var authMode string
 	var defaultDomain string
+	var archiveDir string
+	var archiveUser string
+	var analyticsDir string
+	var analyticsSince string
+	var compactInterval time.Duration
+	var purgeInterval time.Duration
+	var oidcConfig auth.OIDCConfig
+	var proxyConfig auth.ProxyConfig
+	var trustedProxies string
 	flag.BoolVar(&useInMemStore, "inmemstore", false, "Use in-memory datastore")
 	flag.StringVar(&defaultDomain, "defaultdomain", "google.com", "When using inmemstore: the domain that all team permissions are defaulted to")
-	flag.StringVar(&authMode, "authmode", "none", "Set authentication mode, either 'none' or 'firebase'")
+	flag.StringVar(&authMode, "authmode", "none", "Set authentication mode, either 'none', 'firebase', 'oidc' or 'proxy'")
+	flag.StringVar(&archiveDir, "archivedir", "", "Instead of serving, write a static HTML archive of all teams and periods to this directory, then exit")
+	flag.StringVar(&archiveUser, "archiveuser", "", "With archivedir: only archive teams which this user (email address) is allowed to read")
+	flag.StringVar(&analyticsDir, "analyticsdir", "", "Instead of serving, write NDJSON analytics tables to this directory, then exit")
+	flag.StringVar(&analyticsSince, "analyticssince", "", "With analyticsdir: only export teams and periods changed since this RFC 3339 time (e.g. the previous watermark)")
+	flag.DurationVar(&compactInterval, "compactinterval", time.Hour, "How often to compact saved period versions (0 to disable)")
+	flag.DurationVar(&purgeInterval, "purgeinterval", time.Hour, "How often to purge deleted teams and periods after their grace period (0 to disable)")
+	flag.StringVar(&oidcConfig.Issuer, "oidcissuer", "", "With authmode oidc: the issuer which tokens must come from")
+	flag.StringVar(&oidcConfig.Audience, "oidcaudience", "", "With authmode oidc: the audience (client ID) which tokens must be for")
+	flag.StringVar(&oidcConfig.JWKSURL, "oidcjwksurl", "", "With authmode oidc: the URL of the issuer's JSON Web Key Set")
+	flag.StringVar(&oidcConfig.JWKSFile, "oidcjwksfile", "", "With authmode oidc: a file to read the JSON Web Key Set from, instead of a URL")
+	flag.StringVar(&oidcConfig.EmailClaim, "oidcemailclaim", "email", "With authmode oidc: the claim holding the user's email address")
+	flag.StringVar(&oidcConfig.DomainClaim, "oidcdomainclaim", "", "With authmode oidc: the claim holding the user's domain (default: the domain of their email address)")
+	flag.StringVar(&oidcConfig.GroupsClaim, "oidcgroupsclaim", "", "With authmode oidc: the claim holding the user's groups, for Group user matchers")
+	flag.StringVar(&proxyConfig.Header, "proxyheader", "X-Goog-Authenticated-User-Email", "With authmode proxy: the header holding the user's email address")
+	flag.StringVar(&proxyConfig.HeaderPrefix, "proxyheaderprefix", "accounts.google.com:", "With authmode proxy: a prefix to remove from the header")
+	flag.StringVar(&proxyConfig.AssertionHeader, "proxyassertionheader", "", "With authmode proxy: the header holding a signed JWT assertion of the user's identity (e.g. X-Goog-IAP-JWT-Assertion)")
+	flag.StringVar(&proxyConfig.Issuer, "proxyissuer", "https://cloud.google.com/iap", "With proxyassertionheader: the issuer which assertions must come from")
+	flag.StringVar(&proxyConfig.Audience, "proxyaudience", "", "With proxyassertionheader: the audience which assertions must be for")
+	flag.StringVar(&proxyConfig.JWKSURL, "proxyjwksurl", "", "With proxyassertionheader: the URL of the JSON Web Key Set which signs assertions")
+	flag.StringVar(&proxyConfig.JWKSFile, "proxyjwksfile", "", "With proxyassertionheader: a file to read the JSON Web Key Set from, instead of a URL")
+	flag.StringVar(&trustedProxies, "proxytrusted", "", "With authmode proxy: comma-separated IP addresses or CIDR ranges of the proxy; requests from anywhere else are refused")
 	flag.Parse()
+	if trustedProxies != "" {
+		proxyConfig.TrustedProxies = strings.Split(trustedProxies, ",")
+	}
 
 	ctx := context.Background()
 
@@ -92,13 +206,43 @@ func main() {
 		return
 	}
 
-	authProvider, err := makeAuth(ctx, authMode)
+	if archiveDir != "" {
+		err = writeArchive(ctx, storage.MakeScrubbingWrapper(store), archiveDir, archiveUser)
+		if err != nil {
+			log.Fatalf("Could not write archive: %s", err)
+		}
+		return
+	}
+
+	if analyticsDir != "" {
+		err = writeAnalytics(ctx, storage.MakeScrubbingWrapper(store), analyticsDir, analyticsSince)
+		if err != nil {
+			log.Fatalf("Could not write analytics export: %s", err)
+		}
+		return
+	}
+
+	settings := &auth.CachedSettings{Source: store, TTL: settingsCacheTTL}
+	authProvider, err := makeAuth(ctx, authMode, settings, oidcConfig, proxyConfig)
 	if err != nil {
 		log.Fatalf("Could not instantiate auth: %s", err)
 		return
 	}
+	authProvider = &auth.APIKeyAuth{Auth: authProvider, Keys: store, AuthTimeout: defaultAuthTimeout}
 
 	server := controllers.MakeServer(storage.MakeScrubbingWrapper(store), defaultStoreTimeout, authProvider)
+	if useInMemStore {
+		// The versioned period model is not yet available with Cloud Datastore
+		store2 := in_memory_storage.MakeInMemStore2(defaultDomain)
+		server.UseStorage2(storage.MakeScrubbingWrapper2(store2))
+		if compactInterval > 0 {
+			go compactPeriodically(ctx, store2, compactInterval)
+		}
+	}
+
+	if purgeInterval > 0 {
+		go purgePeriodically(ctx, store, purgeInterval)
+	}
 
 	handler := server.MakeHandler()
 	port := os.Getenv("PORT")
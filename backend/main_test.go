@@ -1,4 +1,4 @@
-// Copyright 2019-2021 Google LLC
+// Copyright 2019-2021, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -24,14 +24,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"peoplemath/analytics"
 	"peoplemath/auth"
 	"peoplemath/controllers"
+	"peoplemath/diff"
 	"peoplemath/in_memory_storage"
 	"peoplemath/models"
+	"peoplemath/solver"
 	"peoplemath/storage"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	firebaseAuth "firebase.google.com/go/v4/auth"
 )
@@ -448,86 +453,1553 @@ func TestMissingCommitmentType(t *testing.T) {
 	}
 }
 
+func TestPeriodDates(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "datedteam", t)
+	for _, period := range []models.Period{
+		{ID: "q2", StartDate: "2026-04-01", EndDate: "2026-06-30"},
+		{ID: "undated"},
+		{ID: "q1", StartDate: "2026-01-01", EndDate: "2026-03-31"},
+	} {
+		addPeriod(handler, "datedteam", period.ID, periodToJSON(&period), t)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/period/datedteam/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	periods := []models.Period{}
+	json.NewDecoder(resp.Body).Decode(&periods)
+	var ids []string
+	for _, p := range periods {
+		ids = append(ids, p.ID)
+	}
+	if !reflect.DeepEqual([]string{"q1", "q2", "undated"}, ids) {
+		t.Errorf("Expected periods in time order, found %v", ids)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/datedteam/current?date=2026-05-05", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	current := models.Period{}
+	json.NewDecoder(resp.Body).Decode(&current)
+	if current.ID != "q2" {
+		t.Errorf("Expected current period q2, found %s", current.ID)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/period/datedteam/current?date=2027-01-01", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/period/datedteam/current?date=tomorrow", nil)
+	checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+
+	overlapping := models.Period{ID: "h1", StartDate: "2026-03-01", EndDate: "2026-08-31"}
+	resp = attemptWritePeriod(handler, "datedteam", "h1", periodToJSON(&overlapping), http.MethodPost, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+	invalid := models.Period{ID: "bad", StartDate: "2026-03-01"}
+	resp = attemptWritePeriod(handler, "datedteam", "bad", periodToJSON(&invalid), http.MethodPost, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+
+	// Changing a period's own dates doesn't count as an overlap
+	q1 := getPeriod(handler, "datedteam", "q1", t)
+	q1.EndDate = "2026-03-30"
+	resp = attemptWritePeriod(handler, "datedteam", "q1", periodToJSON(q1), http.MethodPut, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	q1 = getPeriod(handler, "datedteam", "q1", t)
+	q1.EndDate = "2026-04-01"
+	resp = attemptWritePeriod(handler, "datedteam", "q1", periodToJSON(q1), http.MethodPut, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+}
+
 func getBackups(ctx context.Context, store storage.StorageService, teamID, periodID string, t *testing.T) models.PeriodBackups {
 	backups, ok, err := store.GetPeriodBackups(ctx, teamID, periodID)
 	if err != nil {
 		t.Fatalf("Error loading backups: %s", err)
 	}
-	if !ok {
-		t.Fatalf("Backups unexpectedly not found")
+	if !ok {
+		t.Fatalf("Backups unexpectedly not found")
+	}
+	return backups
+}
+
+func TestPeriodBackups(t *testing.T) {
+	store := in_memory_storage.MakeInMemStore("google.com")
+	server := makeServer(store, auth.NoAuth{})
+	handler := server.MakeHandler()
+
+	teamID := "myteam"
+	periodID := "2021q4"
+	addTeam(handler, teamID, t)
+	period := models.Period{
+		ID:                     periodID,
+		DisplayName:            "test period",
+		Unit:                   "thingies",
+		NotesURL:               "",
+		MaxCommittedPercentage: 50,
+		Buckets:                []models.Bucket{},
+		People:                 []models.Person{},
+		SecondaryUnits:         []models.SecondaryUnit{},
+		LastUpdateUUID:         "",
+	}
+	resp := attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPost, t)
+	checkGoodJSONResponse(resp, t)
+	period.LastUpdateUUID = getLastUpdateUUID(resp.Body, t)
+
+	ctx := context.Background()
+
+	// No backups yet
+	backups, ok, err := store.GetPeriodBackups(ctx, teamID, periodID)
+	if err != nil {
+		t.Fatalf("Error loading backups: %s", err)
+	}
+	if ok {
+		t.Fatalf("Don't expect any backups after the initial POST, found: %v", backups)
+	}
+
+	for i := 0; i < 10; i++ {
+		period.MaxCommittedPercentage += 1
+		resp := attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPut, t)
+		checkGoodJSONResponse(resp, t)
+
+		backups := getBackups(ctx, store, teamID, periodID, t)
+		if len(backups.Backups) != i+1 {
+			t.Fatalf("Expected %d backups, found %d: %v", i+1, len(backups.Backups), backups)
+		}
+		expectedMCP := 50.0
+		for i, backup := range backups.Backups {
+			if backup.Period.MaxCommittedPercentage != expectedMCP {
+				t.Fatalf("Expected period backup %d to have MCP %f, found %f", i, expectedMCP, backup.Period.MaxCommittedPercentage)
+			}
+			expectedMCP += 1
+		}
+
+		period.LastUpdateUUID = getLastUpdateUUID(resp.Body, t)
+	}
+
+	period.MaxCommittedPercentage += 1
+	resp = attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPut, t)
+	checkGoodJSONResponse(resp, t)
+
+	backups = getBackups(ctx, store, teamID, periodID, t)
+
+	// The default retention policy keeps every backup from the last hour
+	if len(backups.Backups) != 11 {
+		t.Fatalf("Expected 11 backups, found %d: %v", len(backups.Backups), backups)
+	}
+	expectedMCP := 50.0
+	for i, backup := range backups.Backups {
+		if backup.Period.MaxCommittedPercentage != expectedMCP {
+			t.Fatalf("Expected period backup %d to have MCP %f, found %f", i, expectedMCP, backup.Period.MaxCommittedPercentage)
+		}
+		expectedMCP += 1
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "backupteam", t)
+	addPeriod(handler, "backupteam", "p1", `{"id":"p1","displayName":"Original"}`, t)
+	for _, name := range []string{"Second", "Third"} {
+		period := getPeriod(handler, "backupteam", "p1", t)
+		period.DisplayName = name
+		checkResponseStatus(http.StatusOK, attemptWritePeriod(handler, "backupteam", "p1", periodToJSON(period), http.MethodPut, t), t)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/period/backupteam/p1/backup/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	summaries := []models.BackupSummary{}
+	json.NewDecoder(resp.Body).Decode(&summaries)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 backups, found %v", summaries)
+	}
+	expected := []string{"Changed display name from 'Original' to 'Second'"}
+	if !reflect.DeepEqual(expected, summaries[1].Summary) {
+		t.Errorf("Expected oldest backup summary %v, found %v", expected, summaries[1].Summary)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/backupteam/p1/backup/"+summaries[1].ID, nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	backup := models.PeriodBackup{}
+	json.NewDecoder(resp.Body).Decode(&backup)
+	if backup.Period.DisplayName != "Original" {
+		t.Errorf("Expected original period in backup, found %v", backup.Period)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/period/backupteam/p1/backup/nonexistent", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+
+	restore := func(backupID, lastUpdateUUID string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/period/backupteam/p1/backup/"+backupID+"/restore",
+			strings.NewReader(`{"lastUpdateUUID":"`+lastUpdateUUID+`"}`))
+		return makeHTTPRequest(req, handler, t)
+	}
+	checkResponseStatus(http.StatusConflict, restore(summaries[1].ID, "stale"), t)
+	current := getPeriod(handler, "backupteam", "p1", t)
+	resp = restore(summaries[1].ID, current.LastUpdateUUID)
+	checkGoodJSONResponse(resp, t)
+	restored := getPeriod(handler, "backupteam", "p1", t)
+	if restored.DisplayName != "Original" || restored.LastUpdateUUID != getLastUpdateUUID(resp.Body, t) {
+		t.Errorf("Expected original period to be restored, found %v", restored)
+	}
+
+	// The overwritten version is backed up, so the restore can be undone
+	req = httptest.NewRequest(http.MethodGet, "/api/period/backupteam/p1/backup/", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	json.NewDecoder(resp.Body).Decode(&summaries)
+	if len(summaries) != 3 || summaries[0].ID != current.LastUpdateUUID {
+		t.Errorf("Expected overwritten version to be backed up, found %v", summaries)
+	}
+}
+
+func TestPeriodDiff(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "diffteam", t)
+	addPeriod(handler, "diffteam", "p1", `{"id":"p1","displayName":"Original"}`, t)
+	addPeriod(handler, "diffteam", "p2", `{"id":"p2","displayName":"Next"}`, t)
+	period := getPeriod(handler, "diffteam", "p1", t)
+	original := period.LastUpdateUUID
+	period.DisplayName = "Changed"
+	checkResponseStatus(http.StatusOK, attemptWritePeriod(handler, "diffteam", "p1", periodToJSON(period), http.MethodPut, t), t)
+
+	getDiff := func(query string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/api/diff?"+query, nil)
+		return makeHTTPRequest(req, handler, t)
+	}
+	resp := getDiff("from=diffteam/p1@" + original + "&to=diffteam/p1")
+	checkGoodJSONResponse(resp, t)
+	changes := []diff.Change{}
+	json.NewDecoder(resp.Body).Decode(&changes)
+	if len(changes) != 1 || changes[0].Path != "$.displayName" || changes[0].Old != "Original" || changes[0].New != "Changed" {
+		t.Errorf("Expected display name change, found %v", changes)
+	}
+
+	resp = getDiff("from=diffteam/p1&to=diffteam/p2&format=text")
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), `Changed ID from "p1" to "p2"`) {
+		t.Errorf("Expected ID change in text diff, found %s", string(bodyBytes))
+	}
+
+	checkResponseStatus(http.StatusBadRequest, getDiff("from=diffteam/p1"), t)
+	checkResponseStatus(http.StatusBadRequest, getDiff("from=diffteam&to=diffteam/p1"), t)
+	checkResponseStatus(http.StatusBadRequest, getDiff("from=diffteam/p1&to=diffteam/p2&format=xml"), t)
+	checkResponseStatus(http.StatusNotFound, getDiff("from=diffteam/p1@nonexistent&to=diffteam/p1"), t)
+	checkResponseStatus(http.StatusNotFound, getDiff("from=diffteam/p3&to=diffteam/p1"), t)
+}
+
+func TestPeriodTags(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "tagteam", t)
+	addPeriod(handler, "tagteam", "p1", `{"id":"p1","displayName":"Signed off"}`, t)
+	period := getPeriod(handler, "tagteam", "p1", t)
+
+	postTag := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/period/tagteam/p1/tag/", strings.NewReader(body))
+		return makeHTTPRequest(req, handler, t)
+	}
+	resp := postTag(`{"name":"baseline"}`)
+	checkGoodJSONResponse(resp, t)
+	tag := models.VersionTag{}
+	json.NewDecoder(resp.Body).Decode(&tag)
+	if tag.Name != "baseline" || tag.Version != period.LastUpdateUUID {
+		t.Errorf("Expected current version to be tagged, found %v", tag)
+	}
+	checkResponseStatus(http.StatusConflict, postTag(`{"name":"baseline"}`), t)
+	checkResponseStatus(http.StatusBadRequest, postTag(`{"name":"base/line"}`), t)
+	checkResponseStatus(http.StatusNotFound, postTag(`{"name":"other","version":"nonexistent"}`), t)
+
+	period.DisplayName = "Current plan"
+	checkResponseStatus(http.StatusOK, attemptWritePeriod(handler, "tagteam", "p1", periodToJSON(period), http.MethodPut, t), t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diff?from=tagteam/p1@baseline&to=tagteam/p1", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	changes := []diff.Change{}
+	json.NewDecoder(resp.Body).Decode(&changes)
+	if len(changes) != 1 || changes[0].Old != "Signed off" || changes[0].New != "Current plan" {
+		t.Errorf("Expected display name change since baseline, found %v", changes)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/tagteam/p1/report?format=markdown&version=baseline", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), "Signed off") {
+		t.Errorf("Expected report of the baseline, found %s", string(bodyBytes))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/tagteam/p1/tag/", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	tags := []models.VersionTag{}
+	json.NewDecoder(resp.Body).Decode(&tags)
+	if len(tags) != 1 || tags[0].Name != "baseline" {
+		t.Errorf("Expected baseline tag, found %v", tags)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/period/tagteam/p1/tag/baseline", nil)
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodDelete, "/api/period/tagteam/p1/tag/baseline", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/diff?from=tagteam/p1@baseline&to=tagteam/p1", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+}
+
+func TestPeriodTagsWithConcurrentBackups(t *testing.T) {
+	ctx := context.Background()
+	store := in_memory_storage.MakeInMemStore("google.com")
+	server := makeServer(store, auth.NoAuth{})
+	handler := server.MakeHandler()
+	addTeam(handler, "tagteam", t)
+	addPeriod(handler, "tagteam", "p1", `{"id":"p1"}`, t)
+	period := getPeriod(handler, "tagteam", "p1", t)
+
+	// A backup made from backups read before the tag was added keeps the tag
+	backups, _, _ := store.GetPeriodBackups(ctx, "tagteam", "p1")
+	req := httptest.NewRequest(http.MethodPost, "/api/period/tagteam/p1/tag/", strings.NewReader(`{"name":"baseline"}`))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	backups.Backups = append(backups.Backups, models.PeriodBackup{Timestamp: time.Now(), Period: *period})
+	if err := store.UpsertPeriodBackups(ctx, "tagteam", "p1", backups); err != nil {
+		t.Fatalf("Could not back up period: %v", err)
+	}
+	backups, _, _ = store.GetPeriodBackups(ctx, "tagteam", "p1")
+	if len(backups.Backups) != 1 || len(backups.Tags) != 1 || backups.Tags[0].Name != "baseline" {
+		t.Errorf("Expected the new backup and the tag, found %v", backups)
+	}
+
+	// Changing the tags leaves the backups alone
+	req = httptest.NewRequest(http.MethodDelete, "/api/period/tagteam/p1/tag/baseline", nil)
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	backups, _, _ = store.GetPeriodBackups(ctx, "tagteam", "p1")
+	if len(backups.Backups) != 1 || len(backups.Tags) != 0 {
+		t.Errorf("Expected the backup without the tag, found %v", backups)
+	}
+}
+
+func TestPeriodVersionHistory(t *testing.T) {
+	ctx := context.Background()
+	store2 := in_memory_storage.MakeEmptyInMemStore()
+	store2.CreateTeam(ctx, models.Team{ID: "versionteam"})
+	for _, period := range []models.Period2{
+		{ID: "p1", DisplayName: "First", Version: "v1", Author: "alice@domain.com"},
+		{ID: "p1", DisplayName: "Second", Version: "v2", ParentVersions: []string{"v1"}, Author: "bob@domain.com"},
+	} {
+		if _, err := store2.UpsertPeriodLatestVersion(ctx, "versionteam", &period); err != nil {
+			t.Fatalf("Could not save period: %v", err)
+		}
+	}
+	server := makeServer(in_memory_storage.MakeInMemStore("google.com"), auth.NoAuth{})
+	server.UseStorage2(storage.MakeScrubbingWrapper2(store2))
+	handler := server.MakeHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	versions := []models.PeriodVersionInfo{}
+	json.NewDecoder(resp.Body).Decode(&versions)
+	if len(versions) != 2 || versions[0].Version != "v2" || versions[0].Author != "bob@domain.com" || versions[1].Version != "v1" {
+		t.Errorf("Expected versions v2 and v1, found %v", versions)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/v1", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	period := models.Period2{}
+	json.NewDecoder(resp.Body).Decode(&period)
+	if period.DisplayName != "First" || period.Buckets == nil {
+		t.Errorf("Expected first version, found %v", period)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/v2/ancestry?limit=1", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	json.NewDecoder(resp.Body).Decode(&versions)
+	if len(versions) != 1 || versions[0].Version != "v2" {
+		t.Errorf("Expected only v2 in limited ancestry, found %v", versions)
+	}
+
+	for _, target := range []string{
+		"/api/v2/period/versionteam/p1/version/v3",
+		"/api/v2/period/versionteam/p2/version/",
+		"/api/v2/period/otherteam/p1/version/",
+	} {
+		req = httptest.NewRequest(http.MethodGet, target, nil)
+		checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/period/versionteam/p1/tag/", strings.NewReader(`{"name":"baseline","version":"v1"}`))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/period/versionteam/p1/tag/", strings.NewReader(`{"name":"baseline"}`))
+	checkResponseStatus(http.StatusConflict, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/baseline", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	json.NewDecoder(resp.Body).Decode(&period)
+	if period.Version != "v1" {
+		t.Errorf("Expected tagged version v1, found %v", period)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/api/v2/period/versionteam/p1/tag/baseline", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodDelete, "/api/v2/period/versionteam/p1/tag/baseline", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/v2/ancestry?limit=none", nil)
+	checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1/version/", nil)
+	checkResponseStatus(http.StatusNotImplemented, makeHTTPRequest(req, makeHandler(), t), t)
+}
+
+func TestPeriodDiff2(t *testing.T) {
+	ctx := context.Background()
+	store2 := in_memory_storage.MakeEmptyInMemStore()
+	store2.CreateTeam(ctx, models.Team{ID: "versionteam"})
+	for _, period := range []models.Period2{
+		{ID: "p1", DisplayName: "First", Version: "v1", Author: "alice@domain.com"},
+		{ID: "p1", DisplayName: "Second", Version: "v2", ParentVersions: []string{"v1"}, Author: "bob@domain.com"},
+	} {
+		if _, err := store2.UpsertPeriodLatestVersion(ctx, "versionteam", &period); err != nil {
+			t.Fatalf("Could not save period: %v", err)
+		}
+	}
+	if err := store2.AddPeriodTag(ctx, "versionteam", "p1", models.VersionTag{Name: "baseline", Version: "v1"}); err != nil {
+		t.Fatalf("Could not tag version: %v", err)
+	}
+	server := makeServer(in_memory_storage.MakeInMemStore("google.com"), auth.NoAuth{})
+	server.UseStorage2(storage.MakeScrubbingWrapper2(store2))
+	handler := server.MakeHandler()
+	get := func(handler http.Handler, target string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		return makeHTTPRequest(req, handler, t)
+	}
+
+	for _, from := range []string{"v1", "baseline"} {
+		resp := get(handler, "/api/diff?from=versionteam/p1@v2:"+from+"&to=versionteam/p1@v2:v2")
+		checkGoodJSONResponse(resp, t)
+		changes := []diff.Change{}
+		json.NewDecoder(resp.Body).Decode(&changes)
+		if len(changes) != 1 || changes[0].Old != "First" || changes[0].New != "Second" {
+			t.Errorf("Expected display name change since %s, found %v", from, changes)
+		}
+	}
+
+	resp := get(handler, "/api/period/versionteam/p1/report?format=markdown&version=v2:baseline")
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), "First") {
+		t.Errorf("Expected report of the baseline, found %s", string(bodyBytes))
+	}
+
+	checkResponseStatus(http.StatusNotFound, get(handler, "/api/diff?from=versionteam/p1@v2:v3&to=versionteam/p1@v2:v2"), t)
+	checkResponseStatus(http.StatusBadRequest, get(handler, "/api/diff?from=versionteam/p1@v2:&to=versionteam/p1@v2:v2"), t)
+	checkResponseStatus(http.StatusNotImplemented, get(makeHandler(), "/api/diff?from=team1/2018q4@v2:v1&to=team1/2018q4"), t)
+	checkResponseStatus(http.StatusNotImplemented, get(makeHandler(), "/api/period/team1/2018q4/report?version=v2:v1"), t)
+}
+
+func asOfQuery(t time.Time) string {
+	return "?asOf=" + url.QueryEscape(t.UTC().Format(time.RFC3339Nano))
+}
+
+func TestAsOf(t *testing.T) {
+	handler := makeHandler()
+	beforeTeam := time.Now()
+	addTeam(handler, "asofteam", t)
+	beforePeriod := time.Now()
+	addPeriod(handler, "asofteam", "p1", `{"id":"p1","displayName":"Original"}`, t)
+	original := time.Now()
+	period := getPeriod(handler, "asofteam", "p1", t)
+	period.DisplayName = "Second"
+	checkResponseStatus(http.StatusOK, attemptWritePeriod(handler, "asofteam", "p1", periodToJSON(period), http.MethodPut, t), t)
+	second := time.Now()
+
+	for asOf, expected := range map[time.Time]string{original: "Original", second: "Second"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/period/asofteam/p1"+asOfQuery(asOf), nil)
+		resp := makeHTTPRequest(req, handler, t)
+		checkGoodJSONResponse(resp, t)
+		found := models.Period{}
+		json.NewDecoder(resp.Body).Decode(&found)
+		if found.DisplayName != expected {
+			t.Errorf("Expected %s version, found %v", expected, found.DisplayName)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/period/asofteam/p1"+asOfQuery(beforePeriod), nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/period/asofteam/p1?asOf=yesterday", nil)
+	checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+
+	req = httptest.NewRequest(http.MethodPut, "/api/team/asofteam", strings.NewReader(`{"id":"asofteam","displayName":"Renamed","teamPermissions":{"read":{"allow":[{"type":"Email","id":"alice@google.com"}]}}}`))
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/team/asofteam"+asOfQuery(second), nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	team := models.Team{}
+	json.NewDecoder(resp.Body).Decode(&team)
+	if team.DisplayName != "asofteam" || len(team.Permissions.Read.Allow) != 1 || team.Permissions.Read.Allow[0].Type != models.UserMatcherTypeDomain {
+		t.Errorf("Expected team before renaming, found %v", team)
+	}
+	if team := getTeam(handler, "asofteam", t); team.DisplayName != "Renamed" || len(team.Permissions.Read.Allow) != 1 || team.Permissions.Read.Allow[0].ID != "alice@google.com" {
+		t.Errorf("Expected renamed team, found %v", team)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/team/asofteam"+asOfQuery(beforeTeam), nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+}
+
+func TestAsOf2(t *testing.T) {
+	ctx := context.Background()
+	saved := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	store2 := in_memory_storage.MakeEmptyInMemStore()
+	store2.CreateTeam(ctx, models.Team{ID: "versionteam"})
+	for _, period := range []models.Period2{
+		{ID: "p1", DisplayName: "First", Version: "v1", Timestamp: saved, Author: "alice@domain.com"},
+		{ID: "p1", DisplayName: "Second", Version: "v2", ParentVersions: []string{"v1"}, Timestamp: saved.Add(time.Hour), Author: "bob@domain.com"},
+	} {
+		if _, err := store2.UpsertPeriodLatestVersion(ctx, "versionteam", &period); err != nil {
+			t.Fatalf("Could not save period: %v", err)
+		}
+	}
+	server := makeServer(in_memory_storage.MakeInMemStore("google.com"), auth.NoAuth{})
+	server.UseStorage2(storage.MakeScrubbingWrapper2(store2))
+	handler := server.MakeHandler()
+
+	for query, expected := range map[string]string{
+		"":                                  "v2",
+		asOfQuery(saved.Add(time.Minute)):   "v1",
+		asOfQuery(saved.Add(2 * time.Hour)): "v2",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1"+query, nil)
+		resp := makeHTTPRequest(req, handler, t)
+		checkGoodJSONResponse(resp, t)
+		period := models.Period2{}
+		json.NewDecoder(resp.Body).Decode(&period)
+		if period.Version != expected {
+			t.Errorf("Expected version %s for query '%s', found %v", expected, query, period.Version)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/period/versionteam/p1"+asOfQuery(saved.Add(-time.Minute)), nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+}
+
+func getDeletedItems(handler http.Handler, t *testing.T) []models.DeletedItem {
+	req := httptest.NewRequest(http.MethodGet, "/api/deleted/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	items := []models.DeletedItem{}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+	return items
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "delteam", t)
+	addPeriod(handler, "delteam", "p1", `{"id":"p1","displayName":"First"}`, t)
+	addPeriod(handler, "delteam", "p2", `{"id":"p2","displayName":"Second"}`, t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/period/delteam/p1", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/period/delteam/p1", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/period/delteam/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	periods := []models.Period{}
+	json.NewDecoder(resp.Body).Decode(&periods)
+	if len(periods) != 1 || periods[0].ID != "p2" {
+		t.Errorf("Expected only the remaining period, found %v", periods)
+	}
+	checkResponseStatus(http.StatusConflict, attemptWritePeriod(handler, "delteam", "p1", `{"id":"p1"}`, http.MethodPost, t), t)
+
+	items := getDeletedItems(handler, t)
+	if len(items) != 1 || items[0].TeamID != "delteam" || items[0].PeriodID != "p1" || items[0].DisplayName != "First" {
+		t.Fatalf("Expected deleted period, found %v", items)
+	}
+	if grace := items[0].PurgeTime.Sub(items[0].Timestamp); grace != models.DefaultDeletionGraceDays*24*time.Hour {
+		t.Errorf("Expected default grace period, found %v", grace)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/deleted/delteam/p1/restore", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	if period := getPeriod(handler, "delteam", "p1", t); period.DisplayName != "First" {
+		t.Errorf("Expected restored period, found %v", period)
+	}
+	if items := getDeletedItems(handler, t); len(items) != 0 {
+		t.Errorf("Expected no deleted items after restoring, found %v", items)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/team/delteam", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/team/delteam", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/period/delteam/p1", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	checkResponseStatus(http.StatusConflict, attemptAddTeam(handler, "delteam", t), t)
+	req = httptest.NewRequest(http.MethodPost, "/api/deleted/delteam/p1/restore", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	req = httptest.NewRequest(http.MethodPost, "/api/deleted/delteam/restore", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	if period := getPeriod(handler, "delteam", "p2", t); period.DisplayName != "Second" {
+		t.Errorf("Expected period of restored team, found %v", period)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/api/team/otherteam", nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+}
+
+func TestPurgeDeletedItems(t *testing.T) {
+	ctx := context.Background()
+	store := in_memory_storage.MakeInMemStore("google.com")
+	server := makeServer(store, auth.NoAuth{})
+	handler := server.MakeHandler()
+	addTeam(handler, "purgeteam", t)
+	addTeam(handler, "keepteam", t)
+	addPeriod(handler, "keepteam", "p1", `{"id":"p1"}`, t)
+
+	now := time.Now()
+	if err := store.DeleteTeam(ctx, "purgeteam", models.Deletion{Timestamp: now.Add(-31 * 24 * time.Hour)}); err != nil {
+		t.Fatalf("Could not delete team: %v", err)
+	}
+	if err := store.DeletePeriod(ctx, "keepteam", "p1", models.Deletion{Timestamp: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("Could not delete period: %v", err)
+	}
+	purged, err := storage.PurgeExpiredDeletedItems(ctx, store, now)
+	if err != nil {
+		t.Fatalf("Could not purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected to purge only the team deleted before the grace period, purged %d", purged)
+	}
+	items, _ := store.GetDeletedItems(ctx)
+	if len(items) != 1 || items[0].PeriodID != "p1" {
+		t.Errorf("Expected deleted period to remain, found %v", items)
+	}
+	// The ID of a purged team is free again
+	addTeam(handler, "purgeteam", t)
+}
+
+func renameRequest(handler http.Handler, target, newID string, t *testing.T) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"newID":"`+newID+`"}`))
+	return makeHTTPRequest(req, handler, t)
+}
+
+func checkRedirect(handler http.Handler, method, target, expected string, t *testing.T) {
+	req := httptest.NewRequest(method, target, nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusPermanentRedirect, resp, t)
+	if location := resp.Header.Get("Location"); location != expected {
+		t.Errorf("Expected redirect from %s to %s, found %s", target, expected, location)
+	}
+}
+
+func TestRename(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "oldteam", t)
+	addPeriod(handler, "oldteam", "p1", `{"id":"p1","displayName":"First"}`, t)
+	addPeriod(handler, "oldteam", "p3", `{"id":"p3","displayName":"Third"}`, t)
+
+	checkResponseStatus(http.StatusOK, renameRequest(handler, "/api/period/oldteam/p1/rename", "p2", t), t)
+	if period := getPeriod(handler, "oldteam", "p2", t); period.ID != "p2" || period.DisplayName != "First" {
+		t.Errorf("Expected renamed period, found %v", period)
+	}
+	checkRedirect(handler, http.MethodGet, "/api/period/oldteam/p1", "/api/period/oldteam/p2", t)
+	checkRedirect(handler, http.MethodGet, "/api/period/oldteam/p1/report?x=1", "/api/period/oldteam/p2/report?x=1", t)
+	for newID, status := range map[string]int{
+		"":    http.StatusBadRequest,
+		"p2":  http.StatusBadRequest,
+		"a/b": http.StatusBadRequest,
+		"p3":  http.StatusConflict,
+	} {
+		checkResponseStatus(status, renameRequest(handler, "/api/period/oldteam/p2/rename", newID, t), t)
+	}
+
+	checkResponseStatus(http.StatusOK, renameRequest(handler, "/api/team/oldteam/rename", "newteam", t), t)
+	if team := getTeam(handler, "newteam", t); team.ID != "newteam" {
+		t.Errorf("Expected renamed team, found %v", team)
+	}
+	if period := getPeriod(handler, "newteam", "p2", t); period.DisplayName != "First" {
+		t.Errorf("Expected period of renamed team, found %v", period)
+	}
+	checkRedirect(handler, http.MethodGet, "/api/team/oldteam", "/api/team/newteam", t)
+	checkRedirect(handler, http.MethodDelete, "/api/period/oldteam/p2", "/api/period/newteam/p2", t)
+	// A renamed period of a renamed team takes two redirects
+	checkRedirect(handler, http.MethodGet, "/api/period/oldteam/p1", "/api/period/newteam/p1", t)
+	checkRedirect(handler, http.MethodGet, "/api/period/newteam/p1", "/api/period/newteam/p2", t)
+
+	// The audit log moves with the team
+	req := httptest.NewRequest(http.MethodGet, "/api/team/newteam/audit", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	page := models.AuditPage{}
+	json.NewDecoder(resp.Body).Decode(&page)
+	if len(page.Entries) == 0 || page.Entries[0].Summary[0] != "Renamed team from 'oldteam' to 'newteam'" {
+		t.Errorf("Expected rename in audit log, found %v", page.Entries)
+	}
+	found := false
+	for _, entry := range page.Entries {
+		found = found || (entry.PeriodID == "p2" && entry.Summary[0] == "Renamed period from 'p1' to 'p2'")
+	}
+	if !found {
+		t.Errorf("Expected period rename in audit log, found %v", page.Entries)
+	}
+
+	addTeam(handler, "otherteam", t)
+	checkResponseStatus(http.StatusConflict, renameRequest(handler, "/api/team/newteam/rename", "otherteam", t), t)
+	checkResponseStatus(http.StatusNotFound, renameRequest(handler, "/api/team/nonexistent/rename", "another", t), t)
+	// The old ID can be used again, and then no longer redirects
+	addTeam(handler, "oldteam", t)
+	if team := getTeam(handler, "oldteam", t); team.ID != "oldteam" {
+		t.Errorf("Expected new team with the old ID, found %v", team)
+	}
+}
+
+func TestRename2(t *testing.T) {
+	ctx := context.Background()
+	store2 := in_memory_storage.MakeEmptyInMemStore()
+	store2.CreateTeam(ctx, models.Team{ID: "versionteam"})
+	period := models.Period2{ID: "p1", DisplayName: "First", Version: "v1", Author: "alice@domain.com"}
+	if _, err := store2.UpsertPeriodLatestVersion(ctx, "versionteam", &period); err != nil {
+		t.Fatalf("Could not save period: %v", err)
+	}
+	server := makeServer(in_memory_storage.MakeInMemStore("google.com"), auth.NoAuth{})
+	server.UseStorage2(storage.MakeScrubbingWrapper2(store2))
+	handler := server.MakeHandler()
+	addTeam(handler, "versionteam", t)
+	addPeriod(handler, "versionteam", "p1", `{"id":"p1"}`, t)
+
+	checkResponseStatus(http.StatusOK, renameRequest(handler, "/api/period/versionteam/p1/rename", "p2", t), t)
+	checkResponseStatus(http.StatusOK, renameRequest(handler, "/api/team/versionteam/rename", "renamedteam", t), t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/period/renamedteam/p2/version/", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	versions := []models.PeriodVersionInfo{}
+	json.NewDecoder(resp.Body).Decode(&versions)
+	if len(versions) != 1 || versions[0].Version != "v1" {
+		t.Errorf("Expected versions of renamed period, found %v", versions)
+	}
+	checkRedirect(handler, http.MethodGet, "/api/v2/period/versionteam/p2/version/", "/api/v2/period/renamedteam/p2/version/", t)
+	checkRedirect(handler, http.MethodGet, "/api/v2/period/renamedteam/p1/version/v1", "/api/v2/period/renamedteam/p2/version/v1", t)
+
+	// A rename which fails leaves the version history where it was
+	addTeam(handler, "takenteam", t)
+	addPeriod(handler, "renamedteam", "p3", `{"id":"p3"}`, t)
+	checkResponseStatus(http.StatusConflict, renameRequest(handler, "/api/team/renamedteam/rename", "takenteam", t), t)
+	checkResponseStatus(http.StatusConflict, renameRequest(handler, "/api/period/renamedteam/p2/rename", "p3", t), t)
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/period/renamedteam/p2/version/", nil)
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+}
+
+func getSettings(handler http.Handler, t *testing.T) models.Settings {
+	req := httptest.NewRequest(http.MethodGet, "/api/settings", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	settings := models.Settings{}
+	if err := json.NewDecoder(resp.Body).Decode(&settings); err != nil {
+		t.Fatalf("Could not decode settings: %v", err)
+	}
+	return settings
+}
+
+func TestSettings(t *testing.T) {
+	handler := makeHandler()
+	settings := getSettings(handler, t)
+	if settings.ImproveURL != "https://github.com/google/peoplemath" {
+		t.Errorf("Unexpected settings: %v", settings)
+	}
+
+	putSettings := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body))
+		return makeHTTPRequest(req, handler, t)
+	}
+	checkResponseStatus(http.StatusBadRequest, putSettings(`{"improveURL":"not a URL"}`), t)
+	checkResponseStatus(http.StatusBadRequest, putSettings(`{"deletionGraceDays":-1}`), t)
+	checkResponseStatus(http.StatusBadRequest, putSettings(`{"backupRetention":{"allHours":-1}}`), t)
+	checkResponseStatus(http.StatusBadRequest, putSettings(`{"generalPermissions":{"addTeam":{"allow":[{"type":"Team","id":"t"}]}}}`), t)
+
+	resp := putSettings(`{"improveURL":"https://example.com/improve","deletionGraceDays":7,` +
+		`"generalPermissions":{"addTeam":{"allow":[{"type":"Domain","id":"example.com"}]}}}`)
+	checkGoodJSONResponse(resp, t)
+	settings = getSettings(handler, t)
+	if settings.ImproveURL != "https://example.com/improve" || settings.DeletionGraceDays != 7 ||
+		len(settings.GeneralPermissions.AddTeam.Allow) != 1 {
+		t.Errorf("Expected updated settings, found %v", settings)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/audit", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	page := models.AuditPage{}
+	json.NewDecoder(resp.Body).Decode(&page)
+	if len(page.Entries) != 1 || page.Entries[0].TeamID != "" {
+		t.Fatalf("Expected one settings audit entry, found %v", page.Entries)
+	}
+	expected := []string{
+		"Changed improve URL from 'https://github.com/google/peoplemath' to 'https://example.com/improve'",
+		"Changed team list read permissions",
+		"Changed add team permissions",
+		"Changed administer permissions",
+		"Changed deletion grace period in days from 0 to 7",
+	}
+	if !reflect.DeepEqual(expected, page.Entries[0].Summary) {
+		t.Errorf("Expected audit summary %q, found %q", expected, page.Entries[0].Summary)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	store := in_memory_storage.MakeInMemStore("google.com")
+	server := makeServer(store, &auth.APIKeyAuth{Auth: auth.NoAuth{}, Keys: store, AuthTimeout: time.Second})
+	handler := server.MakeHandler()
+
+	postAPIKey := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/apikey/", strings.NewReader(body))
+		return makeHTTPRequest(req, handler, t)
+	}
+	checkResponseStatus(http.StatusBadRequest, postAPIKey(`{"teams":[{"teamID":"team1","access":"write"}]}`), t)
+	checkResponseStatus(http.StatusBadRequest, postAPIKey(`{"name":"bot","teams":[]}`), t)
+	checkResponseStatus(http.StatusBadRequest, postAPIKey(`{"name":"bot","teams":[{"teamID":"team1","access":"admin"}]}`), t)
+	checkResponseStatus(http.StatusBadRequest, postAPIKey(`{"name":"bot","teams":[{"teamID":"noteam","access":"read"}]}`), t)
+
+	resp := postAPIKey(`{"name":"bot","teams":[{"teamID":"team1","access":"write"},{"teamID":"team2","access":"read"}]}`)
+	checkGoodJSONResponse(resp, t)
+	created := models.CreatedAPIKey{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	if created.Key == "" || created.Name != "bot" || len(created.Teams) != 2 {
+		t.Fatalf("Unexpected created API key %+v", created)
+	}
+	checkResponseStatus(http.StatusConflict, postAPIKey(`{"name":"bot","teams":[{"teamID":"team1","access":"read"}]}`), t)
+
+	// The key itself is never sent again, and its hash is never sent at all
+	req := httptest.NewRequest(http.MethodGet, "/api/apikey/", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), created.Key[strings.Index(created.Key, ".")+1:]) || strings.Contains(strings.ToLower(string(body)), "hash") {
+		t.Errorf("API key list contains secrets: %s", body)
+	}
+	keys := []models.APIKey{}
+	json.Unmarshal(body, &keys)
+	if len(keys) != 1 || keys[0].ID != created.ID {
+		t.Errorf("Expected one API key %s, found %v", created.ID, keys)
+	}
+
+	withKey := func(method, target, key, body string) *http.Response {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Add(auth.APIKeyHeader, key)
+		return makeHTTPRequest(req, handler, t)
+	}
+	periodBody := `{"id":"keyperiod","displayName":"Key period","unit":"person weeks","buckets":[],"people":[]}`
+	checkResponseStatus(http.StatusOK, withKey(http.MethodGet, "/api/team/team1", created.Key, ""), t)
+	checkResponseStatus(http.StatusOK, withKey(http.MethodPost, "/api/period/team1/", created.Key, periodBody), t)
+	checkResponseStatus(http.StatusOK, withKey(http.MethodGet, "/api/period/team2/2018q4", created.Key, ""), t)
+	// The key can only read team2, can't do admin actions, and can't reach the team list or settings
+	checkResponseStatus(http.StatusForbidden, withKey(http.MethodPost, "/api/period/team2/", created.Key, periodBody), t)
+	checkResponseStatus(http.StatusForbidden, withKey(http.MethodDelete, "/api/period/team1/keyperiod", created.Key, ""), t)
+	checkResponseStatus(http.StatusForbidden, withKey(http.MethodGet, "/api/team/", created.Key, ""), t)
+	checkResponseStatus(http.StatusForbidden, withKey(http.MethodGet, "/api/apikey/", created.Key, ""), t)
+	checkResponseStatus(http.StatusUnauthorized, withKey(http.MethodGet, "/api/team/team1", created.ID+".wrong", ""), t)
+	checkResponseStatus(http.StatusUnauthorized, withKey(http.MethodGet, "/api/team/team1", "garbage", ""), t)
+
+	getAuditPage := func(target string) models.AuditPage {
+		resp := makeHTTPRequest(httptest.NewRequest(http.MethodGet, target, nil), handler, t)
+		checkGoodJSONResponse(resp, t)
+		page := models.AuditPage{}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("Could not decode audit page: %s", err)
+		}
+		return page
+	}
+	// Changes made with the key are recorded under its name
+	page := getAuditPage("/api/period/team1/keyperiod/audit")
+	if len(page.Entries) != 1 || page.Entries[0].User != "apikey:bot" {
+		t.Errorf("Expected an audit entry by apikey:bot, found %v", page.Entries)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/apikey/"+created.ID, nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	checkResponseStatus(http.StatusUnauthorized, withKey(http.MethodGet, "/api/team/team1", created.Key, ""), t)
+	req = httptest.NewRequest(http.MethodDelete, "/api/apikey/"+created.ID, nil)
+	checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+
+	page = getAuditPage("/api/settings/audit")
+	expected := []string{"Revoked API key 'bot'", "Created API key 'bot' with write access to team 'team1', read access to team 'team2'"}
+	var summaries []string
+	for _, entry := range page.Entries {
+		summaries = append(summaries, entry.Summary...)
+	}
+	if !reflect.DeepEqual(expected, summaries) {
+		t.Errorf("Expected settings audit summaries %q, found %q", expected, summaries)
+	}
+}
+
+func TestAPIKeysFollowTeams(t *testing.T) {
+	ctx := context.Background()
+	store := in_memory_storage.MakeInMemStore("google.com")
+	server := makeServer(store, &auth.APIKeyAuth{Auth: auth.NoAuth{}, Keys: store, AuthTimeout: time.Second})
+	handler := server.MakeHandler()
+	secret, hash, err := auth.NewAPIKeySecret()
+	if err != nil {
+		t.Fatalf("Could not generate secret: %v", err)
+	}
+	err = store.CreateAPIKey(ctx, models.APIKey{ID: "k1", Name: "bot", SecretHash: hash, Teams: []models.APIKeyTeam{
+		{TeamID: "team1", Access: models.APIKeyAccessWrite},
+		{TeamID: "team2", Access: models.APIKeyAccessRead},
+	}})
+	if err != nil {
+		t.Fatalf("Could not create API key: %v", err)
+	}
+	withKey := func(target string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Add(auth.APIKeyHeader, auth.APIKeyToken("k1", secret))
+		return makeHTTPRequest(req, handler, t)
+	}
+
+	// Renaming a team keeps the key's access to it
+	checkResponseStatus(http.StatusOK, renameRequest(handler, "/api/team/team1/rename", "renamedteam", t), t)
+	checkResponseStatus(http.StatusOK, withKey("/api/team/renamedteam"), t)
+
+	// Purging a team removes the key's access, so it doesn't get access to a new team with the same ID
+	req := httptest.NewRequest(http.MethodDelete, "/api/team/team2", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	if err := store.PurgeDeletedItem(ctx, "team2", ""); err != nil {
+		t.Fatalf("Could not purge team: %v", err)
+	}
+	addTeam(handler, "team2", t)
+	checkResponseStatus(http.StatusForbidden, withKey("/api/team/team2"), t)
+
+	key, _, _ := store.GetAPIKey(ctx, "k1")
+	expected := []models.APIKeyTeam{{TeamID: "renamedteam", Access: models.APIKeyAccessWrite}}
+	if !reflect.DeepEqual(expected, key.Teams) {
+		t.Errorf("Expected the key's teams to be %v, found %v", expected, key.Teams)
+	}
+}
+
+func TestPeriodReport(t *testing.T) {
+	handler := makeHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/period/team1/2018q4/report", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Errorf("Expected HTML content type, found %s", contentType)
+	}
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), "<h2>First bucket</h2>") {
+		t.Errorf("Expected bucket heading in HTML report, found %s", string(bodyBytes))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/team1/2018q4/report?format=markdown", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ = ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), "## First bucket\n") {
+		t.Errorf("Expected bucket heading in Markdown report, found %s", string(bodyBytes))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/team1/2018q4/report?format=pdf", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/period/team1/nonexistent/report", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusNotFound, resp, t)
+}
+
+func getTemplates(handler http.Handler, url string, t *testing.T) []models.PeriodTemplate {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkGoodJSONResponse(resp, t)
+	templates := models.PeriodTemplates{}
+	if err := json.NewDecoder(resp.Body).Decode(&templates); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+	return templates.Templates
+}
+
+func TestTemplates(t *testing.T) {
+	handler := makeHandler()
+	for _, baseURL := range []string{"/api/team/team1/template/", "/api/template/"} {
+		if templates := getTemplates(handler, baseURL, t); len(templates) != 0 {
+			t.Errorf("%s: expected no templates, found %v", baseURL, templates)
+		}
+
+		body := `{"id": "standard", "displayName": "Standard", "buckets": [{"displayName": "Roadmap", "allocationPercentage": 100}]}`
+		req := httptest.NewRequest(http.MethodPost, baseURL, strings.NewReader(body))
+		checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+		req = httptest.NewRequest(http.MethodPost, baseURL, strings.NewReader(body))
+		checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+
+		body = `{"id": "standard", "displayName": "Updated"}`
+		req = httptest.NewRequest(http.MethodPut, baseURL+"standard", strings.NewReader(body))
+		checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+		req = httptest.NewRequest(http.MethodPut, baseURL+"other", strings.NewReader(body))
+		checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+		req = httptest.NewRequest(http.MethodGet, baseURL+"standard", nil)
+		resp := makeHTTPRequest(req, handler, t)
+		checkGoodJSONResponse(resp, t)
+		template := models.PeriodTemplate{}
+		json.NewDecoder(resp.Body).Decode(&template)
+		if template.DisplayName != "Updated" {
+			t.Errorf("%s: expected updated template, found %v", baseURL, template)
+		}
+
+		req = httptest.NewRequest(http.MethodDelete, baseURL+"standard", nil)
+		checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+		req = httptest.NewRequest(http.MethodDelete, baseURL+"standard", nil)
+		checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+		req = httptest.NewRequest(http.MethodGet, baseURL+"standard", nil)
+		checkResponseStatus(http.StatusNotFound, makeHTTPRequest(req, handler, t), t)
+	}
+}
+
+func TestOrgTemplatePermissions(t *testing.T) {
+	store := in_memory_storage.MakeInMemStore("")
+	store.AddAuthTestUsersAndTeam()
+	request := func(email, method, target, body string) *http.Response {
+		server := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: email}})
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer pass")
+		return makeHTTPRequest(req, server.MakeHandler(), t)
+	}
+
+	// User B can add teams, but only user A can administer the settings
+	body := `{"id": "standard", "displayName": "Standard"}`
+	checkResponseStatus(http.StatusForbidden, request("userb@userb.com", http.MethodPost, "/api/template/", body), t)
+	checkGoodJSONResponse(request("usera@domain.com", http.MethodPost, "/api/template/", body), t)
+	checkGoodJSONResponse(request("userb@userb.com", http.MethodGet, "/api/template/standard", ""), t)
+	checkResponseStatus(http.StatusForbidden, request("userb@userb.com", http.MethodDelete, "/api/template/standard", ""), t)
+
+	resp := request("usera@domain.com", http.MethodGet, "/api/settings/audit", "")
+	checkGoodJSONResponse(resp, t)
+	page := models.AuditPage{}
+	json.NewDecoder(resp.Body).Decode(&page)
+	if len(page.Entries) != 1 || !reflect.DeepEqual([]string{"Added template 'standard'"}, page.Entries[0].Summary) {
+		t.Errorf("Expected an audit entry for the new template, found %v", page.Entries)
 	}
-	return backups
 }
 
-func TestPeriodBackups(t *testing.T) {
-	store := in_memory_storage.MakeInMemStore("google.com")
-	server := makeServer(store, auth.NoAuth{})
-	handler := server.MakeHandler()
+func TestPostPeriodFromTemplate(t *testing.T) {
+	handler := makeHandler()
+	orgTemplate := `{"id": "standard", "unit": "person weeks", "maxCommittedPercentage": 50,
+		"secondaryUnits": [{"name": "person years", "conversionFactor": 0.02}],
+		"buckets": [{"displayName": "Keep the lights on", "allocationPercentage": 30, "objectives": [{"name": "On-call", "resourceEstimate": 4}]},
+			{"displayName": "Roadmap", "allocationPercentage": 60}, {"displayName": "Innovation", "allocationPercentage": 10}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/template/", strings.NewReader(orgTemplate))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	teamTemplate := `{"id": "standard", "unit": "person days"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/team/team2/template/", strings.NewReader(teamTemplate))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team1/?template=standard", strings.NewReader(`{"id": "2026q1", "unitAbbrev": "pw"}`))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	period := getPeriod(handler, "team1", "2026q1", t)
+	if period.Unit != "person weeks" || period.UnitAbbrev != "pw" || period.MaxCommittedPercentage != 50 || len(period.SecondaryUnits) != 1 {
+		t.Errorf("Expected period settings from template, found %v", period)
+	}
+	if len(period.Buckets) != 3 || len(period.Buckets[0].Objectives) != 1 || period.Buckets[0].Objectives[0].Name != "On-call" {
+		t.Errorf("Expected buckets and standing objectives from template, found %v", period.Buckets)
+	}
 
-	teamID := "myteam"
-	periodID := "2021q4"
-	addTeam(handler, teamID, t)
-	period := models.Period{
-		ID:                     periodID,
-		DisplayName:            "test period",
-		Unit:                   "thingies",
-		NotesURL:               "",
-		MaxCommittedPercentage: 50,
-		Buckets:                []models.Bucket{},
-		People:                 []models.Person{},
-		SecondaryUnits:         []models.SecondaryUnit{},
-		LastUpdateUUID:         "",
+	// The team's own template takes precedence
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team2/?template=standard", strings.NewReader(`{"id": "2026q1"}`))
+	checkGoodJSONResponse(makeHTTPRequest(req, handler, t), t)
+	if period := getPeriod(handler, "team2", "2026q1", t); period.Unit != "person days" {
+		t.Errorf("Expected team template to be used, found %v", period)
 	}
-	resp := attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPost, t)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team1/?template=nonexistent", strings.NewReader(`{"id": "2026q2"}`))
+	checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+}
+
+func TestPeriodProposal(t *testing.T) {
+	handler := makeHandler()
+
+	period := getPeriod(handler, "team1", "2018q4", t)
+	period.People = append(period.People, models.Person{ID: "newperson", Availability: 20})
+	period.Buckets[0].Objectives[0].ResourceEstimate = 20
+	req := httptest.NewRequest(http.MethodPost, "/api/period/team1/2018q4/proposal", strings.NewReader(periodToJSON(period)))
+	resp := makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	proposal := solver.Proposal{}
+	if err := json.NewDecoder(resp.Body).Decode(&proposal); err != nil {
+		t.Fatalf("Could not decode proposal: %v", err)
+	}
+	assigned := 0.0
+	for _, a := range proposal.Period.Buckets[0].Objectives[0].Assignments {
+		assigned += a.Commitment
+	}
+	if assigned != 20 {
+		t.Errorf("Expected objective to be fully resourced, found %v assigned", assigned)
+	}
+	if len(proposal.Changes) == 0 {
+		t.Errorf("Expected proposed changes")
+	}
+
+	// The proposal is not saved, but can be accepted by saving it
+	if saved := getPeriod(handler, "team1", "2018q4", t); reflect.DeepEqual(saved.Buckets, proposal.Period.Buckets) {
+		t.Errorf("Expected proposal not to be saved")
+	}
+	resp = attemptWritePeriod(handler, "team1", "2018q4", periodToJSON(proposal.Period), http.MethodPut, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team1/2019q1/proposal", strings.NewReader(periodToJSON(period)))
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+}
+
+func TestRollForward(t *testing.T) {
+	handler := makeHandler()
+
+	source := getPeriod(handler, "team1", "2018q4", t)
+	body := `{"id": "2019q2", "resetAvailability": true, "availability": 10, "clearAssignments": true,
+		"carryOver": {"tags": ["tag1"], "objectives": [{"bucketIndex": 1, "objectiveIndex": 0}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/period/team1/2018q4/rollforward", strings.NewReader(body))
+	resp := makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
 	checkGoodJSONResponse(resp, t)
-	period.LastUpdateUUID = getLastUpdateUUID(resp.Body, t)
 
-	ctx := context.Background()
+	period := getPeriod(handler, "team1", "2019q2", t)
+	if period.DisplayName != "2019q2" || period.Unit != source.Unit || !reflect.DeepEqual(period.SecondaryUnits, source.SecondaryUnits) {
+		t.Errorf("Expected period details to be copied, found %v", period)
+	}
+	if len(period.People) != len(source.People) || period.People[0].Availability != 10 {
+		t.Errorf("Expected people with reset availability, found %v", period.People)
+	}
+	if len(period.Buckets) != len(source.Buckets) {
+		t.Fatalf("Expected %d buckets, found %d", len(source.Buckets), len(period.Buckets))
+	}
+	expectedOrigins := [][]models.ObjectiveOrigin{
+		{{PeriodID: "2018q4", BucketIndex: 0, ObjectiveIndex: 1, ObjectiveName: source.Buckets[0].Objectives[1].Name}},
+		{{PeriodID: "2018q4", BucketIndex: 1, ObjectiveIndex: 0, ObjectiveName: source.Buckets[1].Objectives[0].Name}},
+		{},
+	}
+	for b, bucket := range period.Buckets {
+		origins := []models.ObjectiveOrigin{}
+		for _, objective := range bucket.Objectives {
+			if len(objective.Assignments) != 0 {
+				t.Errorf("Expected assignments to be cleared, found %v", objective.Assignments)
+			}
+			origins = append(origins, *objective.CarriedOverFrom)
+		}
+		if !reflect.DeepEqual(expectedOrigins[b], origins) {
+			t.Errorf("Bucket %d: expected objectives from %v, found %v", b, expectedOrigins[b], origins)
+		}
+	}
 
-	// No backups yet
-	backups, ok, err := store.GetPeriodBackups(ctx, teamID, periodID)
-	if err != nil {
-		t.Fatalf("Error loading backups: %s", err)
+	// The new period must not already exist
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team1/2018q4/rollforward", strings.NewReader(`{"id": "2019q1"}`))
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/period/team1/nonexistent/rollforward", strings.NewReader(`{"id": "2019q3"}`))
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusNotFound, resp, t)
+}
+
+func changePeriodState(handler http.Handler, teamID, periodID, state, lastUpdateUUID, token string, t *testing.T) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/state",
+		strings.NewReader(`{"state":"`+state+`","lastUpdateUUID":"`+lastUpdateUUID+`"}`))
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
 	}
-	if ok {
-		t.Fatalf("Don't expect any backups after the initial POST, found: %v", backups)
+	return makeHTTPRequest(req, handler, t)
+}
+
+func TestPeriodLifecycle(t *testing.T) {
+	handler := makeHandler()
+	addTeam(handler, "lifecycleteam", t)
+	addPeriod(handler, "lifecycleteam", "p1", `{"id":"p1","state":"closed"}`, t)
+
+	// New periods always start as drafts
+	period := getPeriod(handler, "lifecycleteam", "p1", t)
+	if period.State != models.PeriodStateDraft {
+		t.Errorf("Expected new period to be a draft, found %s", period.State)
 	}
 
-	for i := 0; i < 10; i++ {
-		period.MaxCommittedPercentage += 1
-		resp := attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPut, t)
+	checkResponseStatus(http.StatusBadRequest, changePeriodState(handler, "lifecycleteam", "p1", "closed", period.LastUpdateUUID, "", t), t)
+	checkResponseStatus(http.StatusBadRequest, changePeriodState(handler, "lifecycleteam", "p1", "finished", period.LastUpdateUUID, "", t), t)
+	checkResponseStatus(http.StatusNotFound, changePeriodState(handler, "lifecycleteam", "p2", "planning", period.LastUpdateUUID, "", t), t)
+	checkResponseStatus(http.StatusConflict, changePeriodState(handler, "lifecycleteam", "p1", "planning", "stale", "", t), t)
+	lastUpdateUUID := period.LastUpdateUUID
+	for _, state := range []string{models.PeriodStatePlanning, models.PeriodStateActive, models.PeriodStateClosed} {
+		resp := changePeriodState(handler, "lifecycleteam", "p1", state, lastUpdateUUID, "", t)
+		checkResponseStatus(http.StatusOK, resp, t)
 		checkGoodJSONResponse(resp, t)
-
-		backups := getBackups(ctx, store, teamID, periodID, t)
-		if len(backups.Backups) != i+1 {
-			t.Fatalf("Expected %d backups, found %d: %v", i+1, len(backups.Backups), backups)
+		update := models.ObjectUpdateResponse{}
+		json.NewDecoder(resp.Body).Decode(&update)
+		if update.LastUpdateUUID == lastUpdateUUID {
+			t.Errorf("Expected a new LastUpdateUUID after changing the state")
 		}
-		expectedMCP := 50.0
-		for i, backup := range backups.Backups {
-			if backup.Period.MaxCommittedPercentage != expectedMCP {
-				t.Fatalf("Expected period backup %d to have MCP %f, found %f", i, expectedMCP, backup.Period.MaxCommittedPercentage)
-			}
-			expectedMCP += 1
+		lastUpdateUUID = update.LastUpdateUUID
+	}
+
+	period = getPeriod(handler, "lifecycleteam", "p1", t)
+	if period.State != models.PeriodStateClosed {
+		t.Errorf("Expected period to be closed, found %s", period.State)
+	}
+	var transitions []string
+	for _, transition := range period.StateHistory {
+		transitions = append(transitions, transition.From+"->"+transition.To)
+		if transition.Time.IsZero() {
+			t.Errorf("Expected transition time to be recorded")
 		}
+	}
+	if !reflect.DeepEqual([]string{"draft->planning", "planning->active", "active->closed"}, transitions) {
+		t.Errorf("Unexpected state history %v", transitions)
+	}
 
-		period.LastUpdateUUID = getLastUpdateUUID(resp.Body, t)
+	// The state can't be changed by saving the period
+	period.State = models.PeriodStateDraft
+	period.StateHistory = nil
+	resp := attemptWritePeriod(handler, "lifecycleteam", "p1", periodToJSON(period), http.MethodPut, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	period = getPeriod(handler, "lifecycleteam", "p1", t)
+	if period.State != models.PeriodStateClosed || len(period.StateHistory) != 3 {
+		t.Errorf("Expected state to be unchanged by save, found %s %v", period.State, period.StateHistory)
 	}
 
-	period.MaxCommittedPercentage += 1
-	resp = attemptWritePeriod(handler, teamID, periodID, periodToJSON(&period), http.MethodPut, t)
+	req := httptest.NewRequest(http.MethodGet, "/api/period/lifecycleteam/", nil)
+	resp = makeHTTPRequest(req, handler, t)
 	checkGoodJSONResponse(resp, t)
+	periods := []models.Period{}
+	json.NewDecoder(resp.Body).Decode(&periods)
+	if len(periods) != 1 || periods[0].State != models.PeriodStateClosed {
+		t.Errorf("Expected state in period list, found %v", periods)
+	}
+}
 
-	backups = getBackups(ctx, store, teamID, periodID, t)
+func TestClosedPeriodLocking(t *testing.T) {
+	teamID := "teamAuthTest"
+	periodID := "2019q1"
+	store := in_memory_storage.MakeInMemStore("")
+	store.AddAuthTestUsersAndTeam()
+	// User A is an admin of the team, user B can only write
+	adminServer := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "usera@domain.com"}})
+	adminHandler := adminServer.MakeHandler()
+	writerServer := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "userb@userb.com"}})
+	writerHandler := writerServer.MakeHandler()
+
+	changeState := func(handler http.Handler, state string) *http.Response {
+		period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+		return changePeriodState(handler, teamID, periodID, state, period.LastUpdateUUID, "pass", t)
+	}
+	checkResponseStatus(http.StatusOK, changeState(writerHandler, models.PeriodStatePlanning), t)
+	checkResponseStatus(http.StatusOK, changeState(writerHandler, models.PeriodStateActive), t)
+	checkResponseStatus(http.StatusOK, changeState(writerHandler, models.PeriodStateClosed), t)
+
+	writePeriod := func(handler http.Handler) *http.Response {
+		period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+		period.DisplayName = "Changed"
+		req := httptest.NewRequest(http.MethodPut, "/api/period/"+teamID+"/"+periodID, strings.NewReader(periodToJSON(period)))
+		req.Header.Add("Authorization", "Bearer pass")
+		return makeHTTPRequest(req, handler, t)
+	}
+	checkResponseStatus(http.StatusForbidden, writePeriod(writerHandler), t)
+	checkResponseStatus(http.StatusForbidden, changeState(writerHandler, models.PeriodStateActive), t)
+	checkResponseStatus(http.StatusOK, writePeriod(adminHandler), t)
+	checkResponseStatus(http.StatusOK, changeState(adminHandler, models.PeriodStateActive), t)
+	checkResponseStatus(http.StatusOK, writePeriod(writerHandler), t)
+
+	period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+	last := period.StateHistory[len(period.StateHistory)-1]
+	if last.User != "usera@domain.com" || last.From != models.PeriodStateClosed {
+		t.Errorf("Expected reopening by user A to be recorded, found %v", last)
+	}
+}
+
+func TestPeriodReview(t *testing.T) {
+	teamID := "teamAuthTest"
+	periodID := "2019q1"
+	store := in_memory_storage.MakeInMemStore("")
+	store.AddAuthTestUsersAndTeam()
+	handlerFor := func(email string) http.Handler {
+		server := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: email}})
+		return server.MakeHandler()
+	}
+	lead := handlerFor("usera@domain.com")
+	director := handlerFor("userc@domain.com")
+	otherReviewer := handlerFor("someone@userb.com")
+	nonReviewer := handlerFor("userb@userb.com")
 
-	if len(backups.Backups) != 10 {
-		t.Fatalf("Expected 10 backups, found %d: %v", len(backups.Backups), backups)
+	post := func(handler http.Handler, path, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/review"+path, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer pass")
+		return makeHTTPRequest(req, handler, t)
 	}
-	expectedMCP := 51.0
-	for i, backup := range backups.Backups {
-		if backup.Period.MaxCommittedPercentage != expectedMCP {
-			t.Fatalf("Expected period backup %d to have MCP %f, found %f", i, expectedMCP, backup.Period.MaxCommittedPercentage)
+	decide := func(handler http.Handler, decision string) *http.Response {
+		period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+		return post(handler, "/decision", `{"decision":"`+decision+`","comment":"ok","lastUpdateUUID":"`+period.LastUpdateUUID+`"}`)
+	}
+	checkStatus := func(expected string) {
+		t.Helper()
+		period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+		if period.Review == nil || period.Review.Status != expected {
+			t.Errorf("Expected approval status %s, found %v", expected, period.Review)
 		}
-		expectedMCP += 1
+	}
+
+	checkResponseStatus(http.StatusBadRequest, decide(director, models.ReviewEventApproved), t)
+	checkResponseStatus(http.StatusBadRequest, post(lead, "", `{"reviewers":[]}`), t)
+	checkResponseStatus(http.StatusForbidden, post(director, "", `{"reviewers":[{"type":"Email","id":"userC@domain.com"}]}`), t)
+	submitted := time.Now()
+	resp := post(lead, "", `{"reviewers":[{"type":"Email","id":"userC@domain.com"},{"type":"Domain","id":"userb.com"}]}`)
+	checkResponseStatus(http.StatusOK, resp, t)
+	checkGoodJSONResponse(resp, t)
+	checkStatus(models.ApprovalStatusPending)
+	// Like any other change, a review updates the last update time, and backs up the previous version
+	if reviewed, _, _ := store.GetPeriod(context.Background(), teamID, periodID); reviewed.LastUpdateTime.Before(submitted) {
+		t.Errorf("Expected the last update time to be updated by the review, found %v", reviewed.LastUpdateTime)
+	}
+	if backups, _, _ := store.GetPeriodBackups(context.Background(), teamID, periodID); len(backups.Backups) != 1 || backups.Backups[0].Period.Review != nil {
+		t.Errorf("Expected a backup of the period from before the review, found %v", backups.Backups)
+	}
+
+	checkResponseStatus(http.StatusForbidden, decide(lead, models.ReviewEventApproved), t)
+	checkResponseStatus(http.StatusBadRequest, decide(director, "rejected"), t)
+	checkResponseStatus(http.StatusConflict, post(director, "/decision", `{"decision":"approved","lastUpdateUUID":"stale"}`), t)
+	checkResponseStatus(http.StatusOK, decide(director, models.ReviewEventApproved), t)
+	checkStatus(models.ApprovalStatusPending)
+	checkResponseStatus(http.StatusOK, decide(otherReviewer, models.ReviewEventApproved), t)
+	checkStatus(models.ApprovalStatusApproved)
+
+	// Editing the period invalidates the approvals
+	period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+	approvedVersion := period.LastUpdateUUID
+	period.DisplayName = "Changed"
+	req := httptest.NewRequest(http.MethodPut, "/api/period/"+teamID+"/"+periodID, strings.NewReader(periodToJSON(period)))
+	req.Header.Add("Authorization", "Bearer pass")
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, lead, t), t)
+	checkStatus(models.ApprovalStatusPending)
+	checkResponseStatus(http.StatusOK, decide(nonReviewer, models.ReviewEventChangesRequested), t)
+	checkStatus(models.ApprovalStatusChangesRequested)
+
+	period, _, _ = store.GetPeriod(context.Background(), teamID, periodID)
+	var events []string
+	for _, event := range period.Review.Events {
+		events = append(events, event.Type)
+	}
+	expected := []string{"submitted", "approved", "approved", "invalidated", "changesRequested"}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("Expected review events %v, found %v", expected, events)
+	}
+	if period.Review.Events[1].Version != approvedVersion || period.Review.Events[4].Version != period.LastUpdateUUID {
+		t.Errorf("Expected events to record the version they applied to")
+	}
+
+	// A review of a version which has since been edited isn't saved
+	err := store.ModifyPeriod(context.Background(), teamID, periodID, approvedVersion, func(period *models.Period) error {
+		t.Error("Expected no update of the review of an old version")
+		return nil
+	})
+	if _, ok := err.(storage.ConcurrentModificationError); !ok {
+		t.Errorf("Expected ConcurrentModificationError, found %v", err)
+	}
+}
+
+// interleavingStore runs a change just before the next transactional update of a period,
+// as if another request had saved it since the handler read the period
+type interleavingStore struct {
+	*in_memory_storage.InMemStore
+	interleave func()
+}
+
+func (s *interleavingStore) ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error {
+	if interleave := s.interleave; interleave != nil {
+		s.interleave = nil
+		interleave()
+	}
+	return s.InMemStore.ModifyPeriod(ctx, teamID, periodID, lastUpdateUUID, modify)
+}
+
+// renamingStore behaves like a team which is part way through being renamed
+type renamingStore struct {
+	*in_memory_storage.InMemStore
+}
+
+func (s renamingStore) CreatePeriod(ctx context.Context, teamID string, period *models.Period) error {
+	return storage.TeamRenamingError(teamID)
+}
+
+func (s renamingStore) ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error {
+	return storage.TeamRenamingError(teamID)
+}
+
+func TestWriteDuringTeamRename(t *testing.T) {
+	store := renamingStore{in_memory_storage.MakeInMemStore("google.com")}
+	server := makeServer(store, auth.NoAuth{})
+	handler := server.MakeHandler()
+	period := getPeriod(handler, "team1", "2019q1", t)
+	resp := attemptWritePeriod(handler, "team1", "2019q1", periodToJSON(period), http.MethodPut, t)
+	checkResponseStatus(http.StatusConflict, resp, t)
+	resp = attemptWritePeriod(handler, "team1", "", `{"id":"2030q1"}`, http.MethodPost, t)
+	checkResponseStatus(http.StatusConflict, resp, t)
+}
+
+func TestEditDuringReview(t *testing.T) {
+	teamID := "teamAuthTest"
+	periodID := "2019q1"
+	inMemStore := in_memory_storage.MakeInMemStore("")
+	inMemStore.AddAuthTestUsersAndTeam()
+	store := &interleavingStore{InMemStore: inMemStore}
+	server := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "usera@domain.com"}})
+	handler := server.MakeHandler()
+	req := httptest.NewRequest(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/review",
+		strings.NewReader(`{"reviewers":[{"type":"Email","id":"userC@domain.com"}]}`))
+	req.Header.Add("Authorization", "Bearer pass")
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+
+	// The approval is saved after the edit has read the period, but before it's written
+	period, _, _ := store.GetPeriod(context.Background(), teamID, periodID)
+	store.interleave = func() {
+		err := inMemStore.ModifyPeriod(context.Background(), teamID, periodID, period.LastUpdateUUID, func(saved *models.Period) error {
+			saved.Review = saved.Review.AddEvent(models.ReviewEvent{Type: models.ReviewEventApproved, User: "userc@domain.com",
+				Version: period.LastUpdateUUID, Time: time.Now(), Matched: saved.Review.Reviewers})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Could not approve: %v", err)
+		}
+	}
+	period.DisplayName = "Changed"
+	req = httptest.NewRequest(http.MethodPut, "/api/period/"+teamID+"/"+periodID, strings.NewReader(periodToJSON(period)))
+	req.Header.Add("Authorization", "Bearer pass")
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+
+	period, _, _ = store.GetPeriod(context.Background(), teamID, periodID)
+	var events []string
+	for _, event := range period.Review.Events {
+		events = append(events, event.Type)
+	}
+	expected := []string{"submitted", "approved", "invalidated"}
+	if period.DisplayName != "Changed" || !reflect.DeepEqual(expected, events) {
+		t.Errorf("Expected the edit to be saved and to invalidate the approval, found %s with review events %v", period.DisplayName, events)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	teamID := "teamAuthTest"
+	store := in_memory_storage.MakeInMemStore("")
+	store.AddAuthTestUsersAndTeam()
+	server := makeServer(store, &auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "usera@domain.com"}})
+	handler := server.MakeHandler()
+	request := func(method, target, body string) *http.Response {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer pass")
+		return makeHTTPRequest(req, handler, t)
+	}
+	getPage := func(target string) models.AuditPage {
+		resp := request(http.MethodGet, target, "")
+		checkGoodJSONResponse(resp, t)
+		page := models.AuditPage{}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("Could not decode audit page: %s", err)
+		}
+		return page
+	}
+
+	team, _, _ := store.GetTeam(context.Background(), teamID)
+	team.DisplayName = "Renamed"
+	teamJSON, _ := json.Marshal(team)
+	checkResponseStatus(http.StatusOK, request(http.MethodPut, "/api/team/"+teamID, string(teamJSON)), t)
+	checkResponseStatus(http.StatusOK, request(http.MethodPost, "/api/period/"+teamID+"/", `{"id":"2026q1","displayName":"2026q1"}`), t)
+	period, _, _ := store.GetPeriod(context.Background(), teamID, "2026q1")
+	period.DisplayName = "2026 Q1"
+	checkResponseStatus(http.StatusOK, request(http.MethodPut, "/api/period/"+teamID+"/2026q1", periodToJSON(period)), t)
+
+	page := getPage("/api/team/" + teamID + "/audit?pageSize=2")
+	if len(page.Entries) != 2 || page.NextPageToken == "" {
+		t.Fatalf("Expected a full first page, found %v", page)
+	}
+	latest := page.Entries[0]
+	if latest.User != "usera@domain.com" || latest.PeriodID != "2026q1" || latest.Method != http.MethodPut ||
+		latest.Endpoint != "/api/period/"+teamID+"/2026q1" || latest.Timestamp.IsZero() {
+		t.Errorf("Unexpected audit entry %v", latest)
+	}
+	if !reflect.DeepEqual([]string{"Changed display name from '2026q1' to '2026 Q1'"}, latest.Summary) {
+		t.Errorf("Unexpected change summary %v", latest.Summary)
+	}
+	page = getPage("/api/team/" + teamID + "/audit?pageSize=2&pageToken=" + page.NextPageToken)
+	if len(page.Entries) != 1 || page.NextPageToken != "" || page.Entries[0].Summary[0] != "Changed display name from 'Team authTest' to 'Renamed'" {
+		t.Errorf("Unexpected last page %v", page)
+	}
+	page = getPage("/api/period/" + teamID + "/2026q1/audit")
+	if len(page.Entries) != 2 || page.Entries[1].Summary[0] != "Created period '2026q1'" {
+		t.Errorf("Unexpected period audit log %v", page)
+	}
+
+	resp := request(http.MethodGet, "/api/team/"+teamID+"/audit?format=ndjson", "")
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if lines := strings.Split(strings.TrimSpace(string(bodyBytes)), "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 exported entries, found %d", len(lines))
+	}
+	checkResponseStatus(http.StatusBadRequest, request(http.MethodGet, "/api/team/"+teamID+"/audit?pageSize=none", ""), t)
+	checkResponseStatus(http.StatusNotFound, request(http.MethodGet, "/api/period/"+teamID+"/nonexistent/audit", ""), t)
+}
+
+func TestAnalyticsExport(t *testing.T) {
+	handler := makeHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/analytics/objectives", nil)
+	resp := makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected NDJSON content type, found %s", contentType)
+	}
+	watermark := resp.Header.Get(controllers.WatermarkHeader)
+	if watermark == "" {
+		t.Fatalf("Expected watermark header")
+	}
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), `"teamId":"team1","periodId":"2018q4","bucketIndex":0,"objectiveIndex":0,"bucketName":"First bucket"`) {
+		t.Errorf("Expected objective row for 2018q4, found %s", string(bodyBytes))
+	}
+
+	// Only the period changed after the watermark should be exported incrementally
+	period := getPeriod(handler, "team1", "2019q1", t)
+	period.DisplayName = "Changed"
+	resp = attemptWritePeriod(handler, "team1", "2019q1", periodToJSON(period), http.MethodPut, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	req = httptest.NewRequest(http.MethodGet, "/api/export/analytics/periods?since="+url.QueryEscape(watermark), nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusOK, resp, t)
+	bodyBytes, _ = ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(bodyBytes), `"periodId":"2019q1","displayName":"Changed"`) {
+		t.Errorf("Expected changed period in incremental export, found %s", string(bodyBytes))
+	}
+	if strings.Contains(string(bodyBytes), "2018q4") {
+		t.Errorf("Expected unchanged period to be excluded, found %s", string(bodyBytes))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/export/analytics/nonexistent", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusNotFound, resp, t)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/export/analytics/teams?since=yesterday", nil)
+	resp = makeHTTPRequest(req, handler, t)
+	checkResponseStatus(http.StatusBadRequest, resp, t)
+}
+
+func TestAnalyticsExportDeletions(t *testing.T) {
+	handler := makeHandler()
+	exportSince := func(table, since string) (string, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/export/analytics/"+table+"?since="+url.QueryEscape(since), nil)
+		resp := makeHTTPRequest(req, handler, t)
+		checkResponseStatus(http.StatusOK, resp, t)
+		bodyBytes, _ := ioutil.ReadAll(resp.Body)
+		return string(bodyBytes), resp.Header.Get(controllers.WatermarkHeader)
+	}
+	_, watermark := exportSince(analytics.TableDeletions, "")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/period/team1/2018q4", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	deletions, watermark := exportSince(analytics.TableDeletions, watermark)
+	if !strings.Contains(deletions, `"teamId":"team1","periodId":"2018q4","reason":"deleted"`) {
+		t.Errorf("Expected deleted period in incremental export, found %s", deletions)
+	}
+
+	// Once restored, the period is exported again, as its rows will have been deleted
+	req = httptest.NewRequest(http.MethodPost, "/api/deleted/team1/2018q4/restore", nil)
+	checkResponseStatus(http.StatusOK, makeHTTPRequest(req, handler, t), t)
+	if periods, _ := exportSince(analytics.TablePeriods, watermark); !strings.Contains(periods, `"teamId":"team1","periodId":"2018q4"`) {
+		t.Errorf("Expected restored period in incremental export, found %s", periods)
+	}
+	if deletions, _ := exportSince(analytics.TableDeletions, watermark); deletions != "" {
+		t.Errorf("Expected no deletions after restoring, found %s", deletions)
 	}
 }
 
@@ -579,6 +2051,10 @@ func (auth failAuthenticationStub) CanActOnTeamList(user models.User, generalPer
 	return true
 }
 
+func (auth failAuthenticationStub) IsPermitted(user models.User, allow []models.UserMatcher) bool {
+	return true
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	server := makeServer(in_memory_storage.MakeInMemStore("google.com"), failAuthenticationStub{})
 	handler := server.MakeHandler()
@@ -595,10 +2071,57 @@ func TestAuthMiddleware(t *testing.T) {
 	assertAuthenticationFailure(http.MethodGet, "/api/team/")
 	assertAuthenticationFailure(http.MethodPost, "/api/team/")
 	assertAuthenticationFailure(http.MethodPut, "/api/team/"+teamID)
+	assertAuthenticationFailure(http.MethodDelete, "/api/team/"+teamID)
+	assertAuthenticationFailure(http.MethodPost, "/api/team/"+teamID+"/rename")
 	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID)
+	assertAuthenticationFailure(http.MethodGet, "/api/team/"+teamID+"/audit")
+	assertAuthenticationFailure(http.MethodGet, "/api/team/"+teamID+"/template/")
+	assertAuthenticationFailure(http.MethodPost, "/api/team/"+teamID+"/template/")
+	assertAuthenticationFailure(http.MethodGet, "/api/team/"+teamID+"/template/t1")
+	assertAuthenticationFailure(http.MethodPut, "/api/team/"+teamID+"/template/t1")
+	assertAuthenticationFailure(http.MethodDelete, "/api/team/"+teamID+"/template/t1")
+	assertAuthenticationFailure(http.MethodGet, "/api/template/")
+	assertAuthenticationFailure(http.MethodPost, "/api/template/")
+	assertAuthenticationFailure(http.MethodGet, "/api/template/t1")
+	assertAuthenticationFailure(http.MethodPut, "/api/template/t1")
+	assertAuthenticationFailure(http.MethodDelete, "/api/template/t1")
 	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/")
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/current")
 	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/")
 	assertAuthenticationFailure(http.MethodPut, "/api/period/"+teamID+"/"+periodID)
+	assertAuthenticationFailure(http.MethodDelete, "/api/period/"+teamID+"/"+periodID)
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID+"/audit")
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID+"/backup/")
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID+"/backup/b1")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/backup/b1/restore")
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID+"/tag/")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/tag/")
+	assertAuthenticationFailure(http.MethodDelete, "/api/period/"+teamID+"/"+periodID+"/tag/t1")
+	assertAuthenticationFailure(http.MethodGet, "/api/period/"+teamID+"/"+periodID+"/report")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/proposal")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/rename")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/rollforward")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/state")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/review")
+	assertAuthenticationFailure(http.MethodPost, "/api/period/"+teamID+"/"+periodID+"/review/decision")
+	assertAuthenticationFailure(http.MethodGet, "/api/v2/period/"+teamID+"/"+periodID)
+	assertAuthenticationFailure(http.MethodGet, "/api/v2/period/"+teamID+"/"+periodID+"/version/")
+	assertAuthenticationFailure(http.MethodGet, "/api/v2/period/"+teamID+"/"+periodID+"/version/v1")
+	assertAuthenticationFailure(http.MethodGet, "/api/v2/period/"+teamID+"/"+periodID+"/version/v1/ancestry")
+	assertAuthenticationFailure(http.MethodGet, "/api/v2/period/"+teamID+"/"+periodID+"/tag/")
+	assertAuthenticationFailure(http.MethodPost, "/api/v2/period/"+teamID+"/"+periodID+"/tag/")
+	assertAuthenticationFailure(http.MethodDelete, "/api/v2/period/"+teamID+"/"+periodID+"/tag/t1")
+	assertAuthenticationFailure(http.MethodGet, "/api/deleted/")
+	assertAuthenticationFailure(http.MethodPost, "/api/deleted/"+teamID+"/restore")
+	assertAuthenticationFailure(http.MethodPost, "/api/deleted/"+teamID+"/"+periodID+"/restore")
+	assertAuthenticationFailure(http.MethodGet, "/api/settings")
+	assertAuthenticationFailure(http.MethodPut, "/api/settings")
+	assertAuthenticationFailure(http.MethodGet, "/api/settings/audit")
+	assertAuthenticationFailure(http.MethodGet, "/api/apikey/")
+	assertAuthenticationFailure(http.MethodPost, "/api/apikey/")
+	assertAuthenticationFailure(http.MethodDelete, "/api/apikey/key")
+	assertAuthenticationFailure(http.MethodGet, "/api/diff?from="+teamID+"/"+periodID+"&to="+teamID+"/"+periodID)
+	assertAuthenticationFailure(http.MethodGet, "/api/export/analytics/teams")
 
 	// "/improve" is not covered by authentication so it should not return a 401
 	getreq := httptest.NewRequest(http.MethodGet, "/improve", nil)
@@ -714,6 +2237,18 @@ func TestFirebaseAuthorization(t *testing.T) {
 
 	assertCorrectPermissionPassedThroughGetAllTeam(handler, true)
 
+	// User A administers the settings, but can't remove their own permission to do so
+	assertAuthorizationPass(handler, http.MethodGet, "/api/settings", nil)
+	assertAuthorizationPass(handler, http.MethodGet, "/api/settings/audit", nil)
+	assertAuthorizationPass(handler, http.MethodGet, "/api/apikey/", nil)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"improveURL":"https://example.com"}`))
+	req.Header.Add("Authorization", "Bearer pass")
+	checkResponseStatus(http.StatusBadRequest, makeHTTPRequest(req, handler, t), t)
+
+	// Renaming and deleting need admin permission, which user A has on the team they added
+	assertAuthorizationPass(handler, http.MethodPost, "/api/team/"+newTeamId+"/rename", strings.NewReader(`{"newID":"teamTest3"}`))
+	assertAuthorizationPass(handler, http.MethodDelete, "/api/team/teamTest3", nil)
+
 	// User B has all permissions through their email domain
 	testAuth = auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "userb@userb.com"}}
 	store = in_memory_storage.MakeInMemStore("")
@@ -731,7 +2266,28 @@ func TestFirebaseAuthorization(t *testing.T) {
 	assertAuthorizationPass(handler, http.MethodPost, "/api/period/"+existingTeamId+"/", strings.NewReader(addPeriodBody))
 
 	assertAuthorizationPass(handler, http.MethodPut, "/api/period/"+existingTeamId+"/"+existingPeriodId, strings.NewReader(updatePeriodBody))
-	assertAuthorizationPass(handler, http.MethodPut, "/api/team/"+existingTeamId, strings.NewReader(updateTeamBody))
+	// Editors can change the team, but only owners can change its permissions
+	assertAuthorizationFail(handler, http.MethodPut, "/api/team/"+existingTeamId, strings.NewReader(updateTeamBody))
+	team, _, err := store.GetTeam(context.Background(), existingTeamId)
+	if err != nil {
+		t.Fatalf("Could not get team: %v", err)
+	}
+	team.DisplayName = "team"
+	teamJSON, err := json.Marshal(team)
+	if err != nil {
+		t.Fatalf("Could not marshal team: %v", err)
+	}
+	assertAuthorizationPass(handler, http.MethodPut, "/api/team/"+existingTeamId, bytes.NewReader(teamJSON))
+	// Renaming and deleting need admin permission, not just write
+	assertAuthorizationFail(handler, http.MethodDelete, "/api/period/"+existingTeamId+"/"+existingPeriodId, nil)
+	assertAuthorizationFail(handler, http.MethodDelete, "/api/team/"+existingTeamId, nil)
+	assertAuthorizationFail(handler, http.MethodPost, "/api/period/"+existingTeamId+"/"+existingPeriodId+"/rename", strings.NewReader(`{"newID":"renamed"}`))
+	assertAuthorizationFail(handler, http.MethodPost, "/api/team/"+existingTeamId+"/rename", strings.NewReader(`{"newID":"renamed"}`))
+	// Only user A administers the settings
+	assertAuthorizationFail(handler, http.MethodGet, "/api/settings", nil)
+	assertAuthorizationFail(handler, http.MethodGet, "/api/apikey/", nil)
+	assertAuthorizationFail(handler, http.MethodPost, "/api/apikey/", strings.NewReader(`{"name":"bot","teams":[{"teamID":"teamAuthTest","access":"write"}]}`))
+	assertAuthorizationFail(handler, http.MethodPut, "/api/settings", strings.NewReader(`{}`))
 
 	assertCorrectPermissionPassedThroughGetAllTeam(handler, true)
 
@@ -753,6 +2309,18 @@ func TestFirebaseAuthorization(t *testing.T) {
 
 	assertAuthorizationFail(handler, http.MethodPut, "/api/period/"+existingTeamId+"/"+existingPeriodId, strings.NewReader(updatePeriodBody))
 	assertAuthorizationFail(handler, http.MethodPut, "/api/team/"+existingTeamId, strings.NewReader(updateTeamBody))
+	assertAuthorizationFail(handler, http.MethodDelete, "/api/period/"+existingTeamId+"/"+existingPeriodId, nil)
+	assertAuthorizationFail(handler, http.MethodDelete, "/api/team/"+existingTeamId, nil)
+
+	// Overlapping dates aren't checked, so the overlapping period isn't revealed, until the write is authorized
+	err = store.CreatePeriod(context.Background(), existingTeamId, &models.Period{ID: "dated", StartDate: "2026-01-01", EndDate: "2026-03-31"})
+	if err != nil {
+		t.Fatalf("Could not create period: %v", err)
+	}
+	overlappingPeriodBody := `{"id":"` + newPeriodId + `","startDate":"2026-02-01","endDate":"2026-02-28"}`
+	assertAuthorizationFail(handler, http.MethodPost, "/api/period/"+existingTeamId+"/", strings.NewReader(overlappingPeriodBody))
+	overlappingPeriodBody = `{"id":"` + existingPeriodId + `","startDate":"2026-02-01","endDate":"2026-02-28"}`
+	assertAuthorizationFail(handler, http.MethodPut, "/api/period/"+existingTeamId+"/"+existingPeriodId, strings.NewReader(overlappingPeriodBody))
 
 	assertCorrectPermissionPassedThroughGetAllTeam(handler, false)
 
@@ -776,6 +2344,39 @@ func TestFirebaseAuthorization(t *testing.T) {
 	assertAuthorizationFail(handler, http.MethodPut, "/api/team/"+existingTeamId, strings.NewReader(updateTeamBody))
 }
 
+func TestDeploymentAdmin(t *testing.T) {
+	ctx := context.Background()
+	// User D has no permissions on the team, but is a deployment admin
+	testAuth := auth.FirebaseAuth{FirebaseClient: AuthClientStub{userEmail: "userd@domain.com"}}
+	store := in_memory_storage.MakeInMemStore("")
+	store.AddAuthTestUsersAndTeam()
+	settings, _ := store.GetSettings(ctx)
+	settings.Admins = models.Permission{Allow: []models.UserMatcher{{Type: models.UserMatcherTypeEmail, ID: "userd@domain.com"}}}
+	store.UpdateSettings(ctx, settings)
+	server := makeServer(store, &testAuth)
+	handler := server.MakeHandler()
+
+	request := func(method, target, body string) *http.Response {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer pass")
+		return makeHTTPRequest(req, handler, t)
+	}
+	// Until the authenticator knows where to find the settings, nobody is an admin
+	checkResponseStatus(http.StatusForbidden, request(http.MethodGet, "/api/team/teamAuthTest", ""), t)
+
+	testAuth.Settings = store
+	checkResponseStatus(http.StatusOK, request(http.MethodGet, "/api/team/teamAuthTest", ""), t)
+	checkResponseStatus(http.StatusOK, request(http.MethodGet, "/api/team/", ""), t)
+	checkResponseStatus(http.StatusOK, request(http.MethodGet, "/api/period/teamAuthTest/2019q1", ""), t)
+	checkResponseStatus(http.StatusOK, request(http.MethodPost, "/api/team/", `{"id":"adminteam","displayName":"Admin team"}`), t)
+	// Admins can fix a team's permissions
+	body := `{"id":"teamAuthTest","displayName":"Team authTest","teamPermissions":{"write":{"allow":[{"type":"Email","id":"new@domain.com"}]}}}`
+	checkResponseStatus(http.StatusOK, request(http.MethodPut, "/api/team/teamAuthTest", body), t)
+	// Admin actions, and administering the settings, are not overridden
+	checkResponseStatus(http.StatusForbidden, request(http.MethodDelete, "/api/team/teamAuthTest", ""), t)
+	checkResponseStatus(http.StatusForbidden, request(http.MethodGet, "/api/settings", ""), t)
+}
+
 func TestDefaultTeamPermissions(t *testing.T) {
 	store := in_memory_storage.MakeInMemStore("")
 	store.AddAuthTestUsersAndTeam()
@@ -792,7 +2393,8 @@ func TestDefaultTeamPermissions(t *testing.T) {
 	permissions := settings.GeneralPermissions
 
 	if !(reflect.DeepEqual(team.Permissions.Read, permissions.ReadTeamList) &&
-		reflect.DeepEqual(team.Permissions.Write, permissions.AddTeam)) {
+		reflect.DeepEqual(team.Permissions.Write, permissions.AddTeam) &&
+		reflect.DeepEqual(team.Permissions.Admin, permissions.AddTeam)) {
 		t.Fatalf("Response team ID should be %v, found %v", teamID, team.ID)
 	}
 }
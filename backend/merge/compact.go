@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"peoplemath/models"
+	"sort"
+	"time"
+)
+
+// CompactVersions works out which versions of a period to keep under a compaction policy:
+// the latest version, any versions in pinned, any versions which were the latest within
+// the policy's merge window, and whichever other versions the policy's retention keeps.
+//
+// It returns the kept versions, in their original order, with their ParentVersions rewritten
+// to refer only to kept versions. Each removed parent is replaced by its nearest kept ancestor
+// along the chain of first parents. Since ParentVersions[0] was the latest version at the time
+// of each update, this keeps the chain of latest versions intact for MergeBaseVersion.
+func CompactVersions(versions []models.Period2, latest string, pinned map[string]bool, policy models.VersionCompaction, now time.Time) []models.Period2 {
+	byVersion := make(map[string]*models.Period2)
+	for i := range versions {
+		byVersion[versions[i].Version] = &versions[i]
+	}
+
+	// A version stopped being the latest when the first version derived from it was saved
+	replacedAt := make(map[string]time.Time)
+	for _, v := range versions {
+		if len(v.ParentVersions) == 0 {
+			continue
+		}
+		parent := v.ParentVersions[0]
+		if t, ok := replacedAt[parent]; !ok || v.Timestamp.Before(t) {
+			replacedAt[parent] = v.Timestamp
+		}
+	}
+
+	keep := make(map[string]bool)
+	windowStart := now.Add(-time.Duration(policy.MergeWindowHours) * time.Hour)
+	var others []*models.Period2
+	for i := range versions {
+		v := &versions[i]
+		t, replaced := replacedAt[v.Version]
+		if v.Version == latest || pinned[v.Version] || (replaced && !t.Before(windowStart)) {
+			keep[v.Version] = true
+		} else {
+			others = append(others, v)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].Timestamp.Before(others[j].Timestamp)
+	})
+	times := make([]time.Time, len(others))
+	for i, v := range others {
+		times[i] = v.Timestamp
+	}
+	for i, kept := range policy.Retention.Keep(times, now) {
+		if kept {
+			keep[others[i].Version] = true
+		}
+	}
+
+	// nearestKept follows first parents from a version until it reaches a kept version,
+	// returning "" if there is none
+	nearest := make(map[string]string)
+	var nearestKept func(version string, depth int) string
+	nearestKept = func(version string, depth int) string {
+		if keep[version] {
+			return version
+		}
+		if result, ok := nearest[version]; ok {
+			return result
+		}
+		result := ""
+		// A version can't have more ancestors than there are versions, unless there is a cycle
+		if v, ok := byVersion[version]; ok && len(v.ParentVersions) > 0 && depth < len(versions) {
+			result = nearestKept(v.ParentVersions[0], depth+1)
+		}
+		nearest[version] = result
+		return result
+	}
+
+	result := make([]models.Period2, 0, len(keep))
+	for _, v := range versions {
+		if !keep[v.Version] {
+			continue
+		}
+		var parents []string
+		seen := make(map[string]bool)
+		for _, parent := range v.ParentVersions {
+			if kept := nearestKept(parent, 0); kept != "" && !seen[kept] {
+				seen[kept] = true
+				parents = append(parents, kept)
+			}
+		}
+		v.ParentVersions = parents
+		result = append(result, v)
+	}
+	return result
+}
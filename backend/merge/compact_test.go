@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"peoplemath/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A Monday
+var compactionTime = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+func makeVersionHistory() []models.Period2 {
+	ago := func(d time.Duration) time.Time {
+		return compactionTime.Add(-d)
+	}
+	day := 24 * time.Hour
+	return []models.Period2{
+		{ID: periodID, Version: "v1", Timestamp: ago(30 * day)},
+		{ID: periodID, Version: "v2", Timestamp: ago(30*day - time.Hour), ParentVersions: []string{"v1"}},
+		{ID: periodID, Version: "v3", Timestamp: ago(10 * day), ParentVersions: []string{"v2"}},
+		{ID: periodID, Version: "v4", Timestamp: ago(2 * time.Hour), ParentVersions: []string{"v3"}},
+		// Merged with a concurrent edit based on v3
+		{ID: periodID, Version: "v5", Timestamp: ago(time.Hour), ParentVersions: []string{"v4", "v3"}},
+	}
+}
+
+func versionParents(versions []models.Period2) map[string][]string {
+	result := make(map[string][]string)
+	for _, v := range versions {
+		result[v.Version] = v.ParentVersions
+	}
+	return result
+}
+
+func TestCompactVersions(t *testing.T) {
+	policy := models.VersionCompaction{
+		MergeWindowHours: 24,
+		Retention:        models.BackupRetention{WeeklyWeeks: 2},
+	}
+	for _, tc := range []struct {
+		name     string
+		pinned   map[string]bool
+		expected map[string][]string
+	}{
+		{
+			// v3 and v4 were replaced within the merge window, and v1 and v2 are too old to keep
+			name:     "unpinned",
+			expected: map[string][]string{"v3": nil, "v4": {"v3"}, "v5": {"v4", "v3"}},
+		},
+		{
+			name:     "pinned",
+			pinned:   map[string]bool{"v1": true},
+			expected: map[string][]string{"v1": nil, "v3": {"v1"}, "v4": {"v3"}, "v5": {"v4", "v3"}},
+		},
+	} {
+		kept := CompactVersions(makeVersionHistory(), "v5", tc.pinned, policy, compactionTime)
+		if found := versionParents(kept); !reflect.DeepEqual(tc.expected, found) {
+			t.Errorf("%s: expected versions and parents %v, found %v", tc.name, tc.expected, found)
+		}
+	}
+}
+
+func TestCompactVersionsRetention(t *testing.T) {
+	// With no merge window, only the latest in each week is kept
+	policy := models.VersionCompaction{Retention: models.BackupRetention{}}
+	kept := CompactVersions(makeVersionHistory(), "v5", nil, policy, compactionTime)
+	expected := map[string][]string{"v2": nil, "v3": {"v2"}, "v4": {"v3"}, "v5": {"v4", "v3"}}
+	if found := versionParents(kept); !reflect.DeepEqual(expected, found) {
+		t.Errorf("Expected versions and parents %v, found %v", expected, found)
+	}
+}
+
+func TestMergeBaseAfterCompaction(t *testing.T) {
+	policy := models.VersionCompaction{
+		MergeWindowHours: 24,
+		Retention:        models.BackupRetention{WeeklyWeeks: 2},
+	}
+	m := make(map[string]models.Period2)
+	for _, v := range CompactVersions(makeVersionHistory(), "v5", nil, policy, compactionTime) {
+		m[v.Version] = v
+	}
+	// A client which loaded v3 within the merge window can still be merged
+	period, err := MergeBaseVersion(testLookup(m), "v3", "v5")
+	if err != nil {
+		t.Fatalf("error getting base version: %v", err)
+	}
+	if period.Version != "v3" {
+		t.Errorf("expected merge base v3, found %s", period.Version)
+	}
+}
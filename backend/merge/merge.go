@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -129,6 +129,8 @@ func (m *PeriodMerger) MergePeriods(base, latest, incoming *models.Period2) *mod
 		People:                 m.mergePeople(base.People, latest.People, incoming.People),
 		Version:                incoming.Version,
 		ParentVersions:         []string{latest.Version, base.Version},
+		Timestamp:              incoming.Timestamp,
+		Author:                 incoming.Author,
 	}
 }
 
@@ -0,0 +1,65 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alias is left behind when a team or period is renamed, so that its old ID still finds it
+type Alias struct {
+	NewID     string    `json:"newID"`
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RenamedItem is a renamed team, or a renamed period if PeriodID is set, with the alias left at its old ID
+type RenamedItem struct {
+	TeamID   string `json:"teamID"`
+	PeriodID string `json:"periodID"`
+	Alias
+}
+
+// RenameRequest is the body of a request to rename a team or period
+type RenameRequest struct {
+	NewID string `json:"newID"`
+}
+
+// ValidateID checks that a new team or period ID can be used in URLs
+func ValidateID(id string) error {
+	if id == "" {
+		return fmt.Errorf("ID must be specified")
+	}
+	if strings.ContainsAny(id, "/?#") {
+		return fmt.Errorf("ID '%s' must not contain '/', '?' or '#'", id)
+	}
+	return nil
+}
+
+// Rename changes the ID recorded in each backup, so that they match a renamed team
+func (b *TeamBackups) Rename(newID string) {
+	for i := range b.Backups {
+		b.Backups[i].Team.ID = newID
+	}
+}
+
+// Rename changes the ID recorded in each backup, so that they match a renamed period
+func (b *PeriodBackups) Rename(newID string) {
+	for i := range b.Backups {
+		b.Backups[i].Period.ID = newID
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	APIKeyAccessRead  = "read"
+	APIKeyAccessWrite = "write"
+)
+
+// APIKey lets scripts act on specific teams without signing in
+type APIKey struct {
+	ID string `json:"id"`
+	// Shown in the audit log in place of a user's email address
+	Name         string       `json:"name"`
+	Teams        []APIKeyTeam `json:"teams"`
+	CreatedBy    string       `json:"createdBy"`
+	CreationTime time.Time    `json:"creationTime"`
+	// Hash of the key's secret, which is never stored, or sent after the key is created
+	SecretHash string `json:"-"`
+}
+
+// APIKeyTeam gives an API key read or write access (see APIKeyAccess* constants) to a team
+type APIKeyTeam struct {
+	TeamID string `json:"teamID"`
+	Access string `json:"access"`
+}
+
+// CreateAPIKeyRequest is the body of a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name  string       `json:"name"`
+	Teams []APIKeyTeam `json:"teams"`
+}
+
+// CreatedAPIKey is the response to creating an API key. This is the only time the key itself is sent.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Validate checks that an API key has a name, and access to at least one team
+func (r CreateAPIKeyRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name must be specified")
+	}
+	if len(r.Teams) == 0 {
+		return fmt.Errorf("an API key needs access to at least one team")
+	}
+	seen := make(map[string]bool)
+	for _, team := range r.Teams {
+		if team.TeamID == "" {
+			return fmt.Errorf("team ID must be specified")
+		}
+		if seen[team.TeamID] {
+			return fmt.Errorf("team '%s' is listed more than once", team.TeamID)
+		}
+		seen[team.TeamID] = true
+		if team.Access != APIKeyAccessRead && team.Access != APIKeyAccessWrite {
+			return fmt.Errorf("unknown access '%s' to team '%s'", team.Access, team.TeamID)
+		}
+	}
+	return nil
+}
+
+// TeamAccess returns the key's access to a team, or an empty string if it has none
+func (key APIKey) TeamAccess(teamID string) string {
+	for _, team := range key.Teams {
+		if team.TeamID == teamID {
+			return team.Access
+		}
+	}
+	return ""
+}
+
+// RenameTeam moves the key's access from a team to the team's new ID, and reports whether
+// it had any
+func (key *APIKey) RenameTeam(teamID, newTeamID string) bool {
+	renamed := false
+	teams := make([]APIKeyTeam, len(key.Teams))
+	for i, team := range key.Teams {
+		if team.TeamID == teamID {
+			team.TeamID = newTeamID
+			renamed = true
+		}
+		teams[i] = team
+	}
+	key.Teams = teams
+	return renamed
+}
+
+// RemoveTeam removes the key's access to a team, and reports whether it had any
+func (key *APIKey) RemoveTeam(teamID string) bool {
+	teams := make([]APIKeyTeam, 0, len(key.Teams))
+	for _, team := range key.Teams {
+		if team.TeamID != teamID {
+			teams = append(teams, team)
+		}
+	}
+	removed := len(teams) < len(key.Teams)
+	key.Teams = teams
+	return removed
+}
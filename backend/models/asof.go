@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// A backup's timestamp is when the backed-up version was replaced, so the version current at
+// a given time is the first one replaced after it, or the current version if none was. That
+// version must also have been saved by then: if it wasn't, either the period did not exist yet,
+// or the version which was current has since been pruned.
+
+// PeriodAsOf returns the version of a period which was current at the given time,
+// given the current version and its backups, oldest first
+func PeriodAsOf(current *Period, backups []PeriodBackup, t time.Time) (*Period, bool) {
+	result := current
+	for i := range backups {
+		if backups[i].Timestamp.After(t) {
+			result = &backups[i].Period
+			break
+		}
+	}
+	return result, !result.LastUpdateTime.After(t)
+}
+
+// TeamAsOf returns the version of a team which was current at the given time,
+// given the current version and its backups, oldest first
+func TeamAsOf(current Team, backups []TeamBackup, t time.Time) (Team, bool) {
+	result := current
+	for _, backup := range backups {
+		if backup.Timestamp.After(t) {
+			result = backup.Team
+			break
+		}
+	}
+	return result, !result.LastUpdateTime.After(t)
+}
+
+// Period2VersionAsOf returns the version of a Period2 which was the latest at the given time,
+// given its versions, newest first
+func Period2VersionAsOf(versions []PeriodVersionInfo, t time.Time) (string, bool) {
+	for _, v := range versions {
+		if !v.Timestamp.After(t) {
+			return v.Version, true
+		}
+	}
+	return "", false
+}
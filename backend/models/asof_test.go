@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodAsOf(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC)
+	}
+	// v1 saved on the 1st, replaced by v2 on the 5th, replaced by v3 on the 10th
+	backups := []PeriodBackup{
+		{Timestamp: day(5), Period: Period{LastUpdateUUID: "v1", LastUpdateTime: day(1)}},
+		{Timestamp: day(10), Period: Period{LastUpdateUUID: "v2", LastUpdateTime: day(5)}},
+	}
+	current := &Period{LastUpdateUUID: "v3", LastUpdateTime: day(10)}
+	for _, tc := range []struct {
+		t        time.Time
+		expected string
+	}{
+		{day(1), "v1"},
+		{day(4), "v1"},
+		{day(5), "v2"},
+		{day(9), "v2"},
+		{day(10), "v3"},
+		{day(20), "v3"},
+		{day(1).Add(-time.Second), ""},
+	} {
+		period, found := PeriodAsOf(current, backups, tc.t)
+		if tc.expected == "" {
+			if found {
+				t.Errorf("Expected no version as of %v, found %s", tc.t, period.LastUpdateUUID)
+			}
+		} else if !found || period.LastUpdateUUID != tc.expected {
+			t.Errorf("Expected %s as of %v, found %v", tc.expected, tc.t, period.LastUpdateUUID)
+		}
+	}
+}
+
+func TestTeamAsOf(t *testing.T) {
+	changed := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	// A team saved before update times were recorded
+	backups := []TeamBackup{{Timestamp: changed, Team: Team{DisplayName: "Before"}}}
+	current := Team{DisplayName: "After", LastUpdateTime: changed}
+	if team, found := TeamAsOf(current, backups, changed.Add(-time.Hour)); !found || team.DisplayName != "Before" {
+		t.Errorf("Expected team before change, found %v", team)
+	}
+	if team, found := TeamAsOf(current, backups, changed); !found || team.DisplayName != "After" {
+		t.Errorf("Expected team after change, found %v", team)
+	}
+}
+
+func TestPeriod2VersionAsOf(t *testing.T) {
+	saved := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	versions := []PeriodVersionInfo{
+		{Version: "v2", Timestamp: saved.Add(time.Hour)},
+		{Version: "v1", Timestamp: saved},
+	}
+	if version, found := Period2VersionAsOf(versions, saved.Add(30*time.Minute)); !found || version != "v1" {
+		t.Errorf("Expected v1, found %s", version)
+	}
+	if version, found := Period2VersionAsOf(versions, saved.Add(-time.Minute)); found {
+		t.Errorf("Expected no version, found %s", version)
+	}
+}
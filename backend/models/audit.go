@@ -0,0 +1,39 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// AuditEntry records a change to a team or one of its periods
+type AuditEntry struct {
+	ID       string `json:"id"`
+	TeamID   string `json:"teamID"`
+	PeriodID string `json:"periodID"` // Empty for changes to the team itself
+	// Email address of the user who made the change
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+	// HTTP method and path of the request which made the change
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	// Human-readable description of what changed
+	Summary []string `json:"summary" datastore:",noindex"`
+}
+
+// AuditPage is one page of audit entries, newest first
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	// Pass this to get the next page. Empty if there are no more entries.
+	NextPageToken string `json:"nextPageToken"`
+}
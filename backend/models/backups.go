@@ -0,0 +1,152 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BackupRetention configures which period backups are kept, in tiers by age.
+// Within the hourly, daily and weekly tiers, the latest backup in each hour, day
+// or week (starting on Monday, UTC) is kept.
+type BackupRetention struct {
+	// Every backup less than this many hours old is kept
+	AllHours int `json:"allHours"`
+	// Hourly backups are kept until they are this many days old
+	HourlyDays int `json:"hourlyDays"`
+	// Daily backups are kept until they are this many days old
+	DailyDays int `json:"dailyDays"`
+	// Weekly backups are kept until they are this many weeks old (0 for no limit)
+	WeeklyWeeks int `json:"weeklyWeeks"`
+}
+
+// DefaultBackupRetention applies when no retention policy is configured in the settings
+var DefaultBackupRetention = BackupRetention{
+	AllHours:    1,
+	HourlyDays:  1,
+	DailyDays:   30,
+	WeeklyWeeks: 0,
+}
+
+// GetBackupRetention returns the configured backup retention policy, or the default
+func (s *Settings) GetBackupRetention() BackupRetention {
+	if s.BackupRetention == nil {
+		return DefaultBackupRetention
+	}
+	return *s.BackupRetention
+}
+
+// Validate checks that the policy makes sense
+func (r BackupRetention) Validate() error {
+	if r.AllHours < 0 || r.HourlyDays < 0 || r.DailyDays < 0 || r.WeeklyWeeks < 0 {
+		return fmt.Errorf("backup retention periods must not be negative")
+	}
+	return nil
+}
+
+// ID identifies a backup among a period's backups. This is the LastUpdateUUID of the
+// backed-up version, or the backup's timestamp for versions saved without one.
+func (b *PeriodBackup) ID() string {
+	if b.Period.LastUpdateUUID != "" {
+		return b.Period.LastUpdateUUID
+	}
+	return b.Timestamp.UTC().Format(time.RFC3339Nano)
+}
+
+// FindBackup returns the backup with the given ID
+func (b *PeriodBackups) FindBackup(backupID string) (*PeriodBackup, bool) {
+	for i := range b.Backups {
+		if b.Backups[i].ID() == backupID {
+			return &b.Backups[i], true
+		}
+	}
+	return nil, false
+}
+
+// BackupSummary describes a period backup without including the whole period
+type BackupSummary struct {
+	ID string `json:"id"`
+	// When the backed-up version was replaced
+	Timestamp time.Time `json:"timestamp"`
+	// When the backed-up version was saved (zero if unknown)
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	// The changes made to the backed-up version by the version which replaced it
+	Summary []string `json:"summary"`
+}
+
+// RestoreRequest is sent by the browser to restore a backup
+type RestoreRequest struct {
+	// The version of the period being replaced, for concurrency control
+	LastUpdateUUID string `json:"lastUpdateUUID"`
+}
+
+const week = 7 * 24 * time.Hour
+
+// Prune removes the backups which the retention policy doesn't keep at the given time,
+// except for tagged versions, which are always kept
+func (b *PeriodBackups) Prune(r BackupRetention, now time.Time) {
+	tagged := make(map[string]bool)
+	for _, tag := range b.Tags {
+		tagged[tag.Version] = true
+	}
+	times := make([]time.Time, len(b.Backups))
+	for i, backup := range b.Backups {
+		times[i] = backup.Timestamp
+	}
+	keep := r.Keep(times, now)
+	result := make([]PeriodBackup, 0, len(b.Backups))
+	for i, backup := range b.Backups {
+		if keep[i] || tagged[backup.ID()] {
+			result = append(result, backup)
+		}
+	}
+	b.Backups = result
+}
+
+// Keep indicates which of a series of times, oldest first, the policy keeps at the given time
+func (r BackupRetention) Keep(times []time.Time, now time.Time) []bool {
+	allUntil := time.Duration(r.AllHours) * time.Hour
+	hourlyUntil := time.Duration(r.HourlyDays) * 24 * time.Hour
+	dailyUntil := time.Duration(r.DailyDays) * 24 * time.Hour
+	weeklyUntil := time.Duration(r.WeeklyWeeks) * week
+
+	keep := make([]bool, len(times))
+	seen := make(map[string]bool)
+	// Newest first, so that the latest time in each interval is the one kept
+	for i := len(times) - 1; i >= 0; i-- {
+		t := times[i].UTC()
+		age := now.Sub(t)
+		var interval string
+		switch {
+		case age < allUntil:
+			keep[i] = true
+			continue
+		case age < hourlyUntil:
+			interval = "hour " + t.Truncate(time.Hour).Format(time.RFC3339)
+		case age < dailyUntil:
+			interval = "day " + t.Format(DateFormat)
+		case r.WeeklyWeeks == 0 || age < weeklyUntil:
+			interval = "week " + t.Truncate(week).Format(DateFormat)
+		default:
+			continue
+		}
+		if !seen[interval] {
+			seen[interval] = true
+			keep[i] = true
+		}
+	}
+	return keep
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPruneBackups(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	ages := []time.Duration{
+		101 * 24 * time.Hour, // Same week (Monday to Sunday) as the next one
+		100 * 24 * time.Hour,
+		60 * 24 * time.Hour,
+		10*24*time.Hour + time.Hour, // Same day as the next one
+		10 * 24 * time.Hour,
+		5*time.Hour + 30*time.Minute, // Same hour as the next one
+		5*time.Hour + 10*time.Minute,
+		50 * time.Minute, // Within the last hour, so all are kept
+		40 * time.Minute,
+		time.Minute,
+	}
+	var backups []PeriodBackup
+	for i, age := range ages {
+		backups = append(backups, PeriodBackup{Timestamp: now.Add(-age), Period: Period{ID: string(rune('a' + i))}})
+	}
+	prune := func(r BackupRetention, backups []PeriodBackup) string {
+		pruned := PeriodBackups{Backups: backups}
+		pruned.Prune(r, now)
+		result := ""
+		for _, b := range pruned.Backups {
+			result += b.Period.ID
+		}
+		return result
+	}
+
+	if kept := prune(DefaultBackupRetention, backups); kept != "bceghij" {
+		t.Errorf("Expected default policy to keep bceghij, found %s", kept)
+	}
+	limited := BackupRetention{AllHours: 1, HourlyDays: 1, DailyDays: 30, WeeklyWeeks: 12}
+	if kept := prune(limited, backups); kept != "ceghij" {
+		t.Errorf("Expected limited policy to keep ceghij, found %s", kept)
+	}
+	if kept := prune(DefaultBackupRetention, nil); len(kept) != 0 {
+		t.Errorf("Expected no backups, found %v", kept)
+	}
+}
+
+func TestPruneTaggedBackups(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	backups := PeriodBackups{
+		Backups: []PeriodBackup{
+			{Timestamp: now.Add(-48 * time.Hour), Period: Period{LastUpdateUUID: "baseline"}},
+			{Timestamp: now.Add(-47 * time.Hour), Period: Period{LastUpdateUUID: "untagged"}},
+			{Timestamp: now.Add(-46 * time.Hour), Period: Period{LastUpdateUUID: "latest"}},
+		},
+		Tags: []VersionTag{{Name: "signed-off", Version: "baseline"}},
+	}
+	// Only the latest backup of the day is kept, apart from the tagged one
+	backups.Prune(BackupRetention{DailyDays: 30}, now)
+	var kept []string
+	for _, b := range backups.Backups {
+		kept = append(kept, b.ID())
+	}
+	if expected := []string{"baseline", "latest"}; !reflect.DeepEqual(expected, kept) {
+		t.Errorf("Expected %v to be kept, found %v", expected, kept)
+	}
+}
+
+func TestGetBackupRetention(t *testing.T) {
+	if retention := (&Settings{}).GetBackupRetention(); !reflect.DeepEqual(DefaultBackupRetention, retention) {
+		t.Errorf("Expected default retention, found %v", retention)
+	}
+	configured := BackupRetention{AllHours: 2}
+	if retention := (&Settings{BackupRetention: &configured}).GetBackupRetention(); retention != configured {
+		t.Errorf("Expected configured retention, found %v", retention)
+	}
+	if err := (BackupRetention{DailyDays: -1}).Validate(); err == nil {
+		t.Errorf("Expected negative retention to be invalid")
+	}
+}
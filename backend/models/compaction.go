@@ -0,0 +1,49 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "fmt"
+
+// VersionCompaction configures which saved versions of a period are kept when
+// the versions are compacted. The latest version is always kept.
+type VersionCompaction struct {
+	// Versions which were the latest at any time in this many hours are kept, since
+	// clients which loaded them may still save changes which need them as a merge base
+	MergeWindowHours int `json:"mergeWindowHours"`
+	// Which of the other versions are kept, by when they were saved
+	Retention BackupRetention `json:"retention"`
+}
+
+// DefaultVersionCompaction applies when no compaction policy is configured in the settings
+var DefaultVersionCompaction = VersionCompaction{
+	MergeWindowHours: 24,
+	Retention:        DefaultBackupRetention,
+}
+
+// GetVersionCompaction returns the configured version compaction policy, or the default
+func (s *Settings) GetVersionCompaction() VersionCompaction {
+	if s.VersionCompaction == nil {
+		return DefaultVersionCompaction
+	}
+	return *s.VersionCompaction
+}
+
+// Validate checks that the policy makes sense
+func (c VersionCompaction) Validate() error {
+	if c.MergeWindowHours < 0 {
+		return fmt.Errorf("merge window must not be negative")
+	}
+	return c.Retention.Validate()
+}
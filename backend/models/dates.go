@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DateFormat is the format of period start and end dates.
+// Dates in this format sort in time order when compared as strings.
+const DateFormat = "2006-01-02"
+
+// HasDates indicates whether the period has a date range
+func (p *Period) HasDates() bool {
+	return p.StartDate != "" && p.EndDate != ""
+}
+
+// ValidateDates checks that the period either has no dates, or a valid range
+func (p *Period) ValidateDates() error {
+	if p.StartDate == "" && p.EndDate == "" {
+		return nil
+	}
+	if !p.HasDates() {
+		return fmt.Errorf("period must have both a start and an end date, or neither")
+	}
+	for _, date := range []string{p.StartDate, p.EndDate} {
+		if _, err := time.Parse(DateFormat, date); err != nil {
+			return fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+		}
+	}
+	if p.EndDate < p.StartDate {
+		return fmt.Errorf("end date %s is before start date %s", p.EndDate, p.StartDate)
+	}
+	return nil
+}
+
+// ContainsDate indicates whether a YYYY-MM-DD date falls within the period
+func (p *Period) ContainsDate(date string) bool {
+	return p.HasDates() && p.StartDate <= date && date <= p.EndDate
+}
+
+// Overlaps indicates whether the date ranges of two periods overlap
+func (p *Period) Overlaps(other *Period) bool {
+	return p.HasDates() && other.HasDates() && p.StartDate <= other.EndDate && other.StartDate <= p.EndDate
+}
+
+// FindPeriodForDate returns the period containing a YYYY-MM-DD date. This can be used to
+// match up the periods of different teams, whose IDs need not follow the same scheme.
+func FindPeriodForDate(periods []Period, date string) (*Period, bool) {
+	for i := range periods {
+		if periods[i].ContainsDate(date) {
+			return &periods[i], true
+		}
+	}
+	return nil, false
+}
+
+// SortPeriods puts periods into time order. Periods without dates come last, in ID order.
+func SortPeriods(periods []Period) {
+	sort.SliceStable(periods, func(i, j int) bool {
+		pi, pj := &periods[i], &periods[j]
+		if pi.HasDates() != pj.HasDates() {
+			return pi.HasDates()
+		}
+		if pi.HasDates() && pi.StartDate != pj.StartDate {
+			return pi.StartDate < pj.StartDate
+		}
+		return pi.ID < pj.ID
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestValidateDates(t *testing.T) {
+	for _, tc := range []struct {
+		start, end string
+		valid      bool
+	}{
+		{"", "", true},
+		{"2026-01-01", "2026-03-31", true},
+		{"2026-01-01", "2026-01-01", true},
+		{"2026-01-01", "", false},
+		{"", "2026-03-31", false},
+		{"2026-03-31", "2026-01-01", false},
+		{"2026-1-1", "2026-03-31", false},
+		{"2026-02-30", "2026-03-31", false},
+	} {
+		p := Period{StartDate: tc.start, EndDate: tc.end}
+		if err := p.ValidateDates(); (err == nil) != tc.valid {
+			t.Errorf("ValidateDates(%q, %q) returned %v, expected valid=%v", tc.start, tc.end, err, tc.valid)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	q1 := Period{ID: "q1", StartDate: "2026-01-01", EndDate: "2026-03-31"}
+	q2 := Period{ID: "q2", StartDate: "2026-04-01", EndDate: "2026-06-30"}
+	h1 := Period{ID: "h1", StartDate: "2026-01-01", EndDate: "2026-06-30"}
+	undated := Period{ID: "undated"}
+	if q1.Overlaps(&q2) || q2.Overlaps(&q1) {
+		t.Errorf("Expected adjacent periods not to overlap")
+	}
+	if !q1.Overlaps(&h1) || !h1.Overlaps(&q2) {
+		t.Errorf("Expected periods to overlap")
+	}
+	if undated.Overlaps(&q1) || q1.Overlaps(&undated) {
+		t.Errorf("Expected undated period not to overlap")
+	}
+}
+
+func TestFindPeriodForDate(t *testing.T) {
+	periods := []Period{
+		{ID: "undated"},
+		{ID: "q1", StartDate: "2026-01-01", EndDate: "2026-03-31"},
+		{ID: "q2", StartDate: "2026-04-01", EndDate: "2026-06-30"},
+	}
+	for date, expected := range map[string]string{
+		"2026-01-01": "q1",
+		"2026-03-31": "q1",
+		"2026-04-01": "q2",
+		"2026-07-01": "",
+	} {
+		p, found := FindPeriodForDate(periods, date)
+		if expected == "" {
+			if found {
+				t.Errorf("Expected no period for %s, found %s", date, p.ID)
+			}
+		} else if !found || p.ID != expected {
+			t.Errorf("Expected period %s for %s, found %v", expected, date, p)
+		}
+	}
+}
+
+func TestSortPeriods(t *testing.T) {
+	periods := []Period{
+		{ID: "b"},
+		{ID: "2026q2", StartDate: "2026-04-01", EndDate: "2026-06-30"},
+		{ID: "a"},
+		{ID: "2025q4", StartDate: "2025-10-01", EndDate: "2025-12-31"},
+	}
+	SortPeriods(periods)
+	var ids []string
+	for _, p := range periods {
+		ids = append(ids, p.ID)
+	}
+	expected := []string{"2025q4", "2026q2", "a", "b"}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Fatalf("Expected order %v, found %v", expected, ids)
+		}
+	}
+}
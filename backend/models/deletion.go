@@ -0,0 +1,48 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// DefaultDeletionGraceDays applies when no grace period is configured in the settings
+const DefaultDeletionGraceDays = 30
+
+// Deletion records who deleted a team or period, and when
+type Deletion struct {
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DeletedItem is a deleted team, or a deleted period if PeriodID is set. It can be
+// restored until it is purged, once the grace period has passed.
+type DeletedItem struct {
+	TeamID      string `json:"teamID"`
+	PeriodID    string `json:"periodID"`
+	DisplayName string `json:"displayName"`
+	Deletion
+	// For deleted teams, the team's permissions when it was deleted
+	Permissions TeamPermissions `json:"-"`
+	// When the item will be purged, filled in from the settings when listing
+	PurgeTime time.Time `json:"purgeTime" datastore:"-"`
+}
+
+// GetDeletionGracePeriod returns how long deleted teams and periods are kept before they are purged
+func (s *Settings) GetDeletionGracePeriod() time.Duration {
+	days := s.DeletionGraceDays
+	if days <= 0 {
+		days = DefaultDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
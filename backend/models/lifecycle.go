@@ -0,0 +1,74 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Period lifecycle states
+const (
+	PeriodStateDraft    = "draft"
+	PeriodStatePlanning = "planning"
+	PeriodStateActive   = "active"
+	PeriodStateClosed   = "closed"
+)
+
+// Permitted state transitions. Periods move forward one step at a time,
+// and can step back, e.g. to reopen a closed period.
+var periodStateTransitions = map[string][]string{
+	PeriodStateDraft:    {PeriodStatePlanning},
+	PeriodStatePlanning: {PeriodStateDraft, PeriodStateActive},
+	PeriodStateActive:   {PeriodStatePlanning, PeriodStateClosed},
+	PeriodStateClosed:   {PeriodStateActive},
+}
+
+// StateTransition records a change to the lifecycle state of a period
+type StateTransition struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	User string    `json:"user"` // Email address of the user who made the change
+	Time time.Time `json:"time"`
+}
+
+// StateChangeRequest is sent by the browser to change the lifecycle state of a period
+type StateChangeRequest struct {
+	State string `json:"state"`
+	// The version of the period which the change was requested from, which must be the latest
+	LastUpdateUUID string `json:"lastUpdateUUID"`
+}
+
+// LifecycleState returns the period's state, treating periods saved before
+// states were introduced as drafts
+func (p *Period) LifecycleState() string {
+	if p.State == "" {
+		return PeriodStateDraft
+	}
+	return p.State
+}
+
+// IsValidPeriodState indicates whether a string is one of the lifecycle states
+func IsValidPeriodState(state string) bool {
+	_, ok := periodStateTransitions[state]
+	return ok
+}
+
+// CanTransitionPeriodState indicates whether a period may move directly between two states
+func CanTransitionPeriodState(from, to string) bool {
+	for _, allowed := range periodStateTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestCanTransitionPeriodState(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		allowed  bool
+	}{
+		{PeriodStateDraft, PeriodStatePlanning, true},
+		{PeriodStatePlanning, PeriodStateActive, true},
+		{PeriodStateActive, PeriodStateClosed, true},
+		{PeriodStateClosed, PeriodStateActive, true},
+		{PeriodStatePlanning, PeriodStateDraft, true},
+		{PeriodStateDraft, PeriodStateClosed, false},
+		{PeriodStateClosed, PeriodStateDraft, false},
+		{PeriodStateActive, PeriodStateActive, false},
+		{"finished", PeriodStateActive, false},
+	} {
+		if CanTransitionPeriodState(tc.from, tc.to) != tc.allowed {
+			t.Errorf("CanTransitionPeriodState(%s, %s): expected %v", tc.from, tc.to, tc.allowed)
+		}
+	}
+}
+
+func TestLifecycleState(t *testing.T) {
+	if state := (&Period{}).LifecycleState(); state != PeriodStateDraft {
+		t.Errorf("Expected period without a state to be a draft, found %s", state)
+	}
+	if state := (&Period{State: PeriodStateActive}).LifecycleState(); state != PeriodStateActive {
+		t.Errorf("Expected active period, found %s", state)
+	}
+}
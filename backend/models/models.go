@@ -1,4 +1,4 @@
-// Copyright 2020-2021, 2023 Google LLC
+// Copyright 2020-2021, 2023, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -22,6 +22,7 @@ import (
 type GeneralPermissions struct {
 	ReadTeamList Permission `json:"readTeamList"` // Whether a user can view the complete list of teams
 	AddTeam      Permission `json:"addTeam"`      // Whether a user can add a completely new team
+	Administer   Permission `json:"administer"`   // Whether a user can view and change the settings
 }
 
 // Team model struct
@@ -29,11 +30,16 @@ type Team struct {
 	ID          string          `json:"id"`
 	DisplayName string          `json:"displayName"`
 	Permissions TeamPermissions `json:"teamPermissions"`
+	// Time of the last change to the team, set by the server (zero if unknown)
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	// Set while the team is deleted, until it is restored or purged
+	Deleted *Deletion `json:"-"`
 }
 
 type TeamPermissions struct {
 	Read  Permission `json:"read"`  // Whether a user can view the team and all of its periods
 	Write Permission `json:"write"` // Whether the user can make changes to the team, i.e. add new periods and make changes to existing ones
+	Admin Permission `json:"admin"` // Whether the user can perform elevated actions, such as editing or reopening closed periods
 }
 
 type Permission struct {
@@ -46,6 +52,14 @@ type UserMatcher struct {
 }
 
 func (matcher UserMatcher) matches(user User) bool {
+	if matcher.Type == UserMatcherTypeGroup {
+		for _, group := range user.Groups {
+			if strings.EqualFold(matcher.ID, group) {
+				return true
+			}
+		}
+		return false
+	}
 	return (matcher.Type == UserMatcherTypeDomain && strings.ToLower(matcher.ID) == strings.ToLower(user.Domain)) ||
 		(matcher.Type == UserMatcherTypeEmail && strings.ToLower(matcher.ID) == strings.ToLower(user.Email))
 }
@@ -62,6 +76,8 @@ func (user User) IsPermitted(permissions []UserMatcher) bool {
 const (
 	UserMatcherTypeEmail  = "Email"
 	UserMatcherTypeDomain = "Domain"
+	// Matches users in a group, for authentication providers which supply groups
+	UserMatcherTypeGroup = "Group"
 )
 
 // This struct is used to pass the list of teams to the frontend,
@@ -83,8 +99,21 @@ type Period struct {
 	Buckets                []Bucket        `json:"buckets"`
 	People                 []Person        `json:"people"`
 	SecondaryUnits         []SecondaryUnit `json:"secondaryUnits"`
+	// Lifecycle state (see PeriodState* constants), empty for draft. Changed only via the state endpoint.
+	State        string            `json:"state"`
+	StateHistory []StateTransition `json:"stateHistory"`
+	// Sign-off of the plan, nil if the period has not been submitted for review.
+	// Changed only via the review endpoints.
+	Review *Review `json:"review"`
+	// Optional date range covered by the period, as YYYY-MM-DD (inclusive)
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
 	// UUID for simple optimistic concurrency control
 	LastUpdateUUID string `json:"lastUpdateUUID"`
+	// Time of the last change to the period, set by the server (zero if unknown)
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	// Set while the period is deleted, until it is restored or purged
+	Deleted *Deletion `json:"-"`
 }
 
 const (
@@ -116,6 +145,16 @@ type Objective struct {
 	Tags             []ObjectiveTag   `json:"tags"`
 	DisplayOptions   DisplayOptions   `json:"displayOptions"`
 	BlockID          string           `json:"blockID"`
+	// Where the objective was copied from when rolling a period forward (nil if not carried over)
+	CarriedOverFrom *ObjectiveOrigin `json:"carriedOverFrom"`
+}
+
+// ObjectiveOrigin identifies the objective in a previous period that another was copied from
+type ObjectiveOrigin struct {
+	PeriodID       string `json:"periodId"`
+	BucketIndex    int    `json:"bucketIndex"`
+	ObjectiveIndex int    `json:"objectiveIndex"`
+	ObjectiveName  string `json:"objectiveName"`
 }
 
 // ObjectiveGroup model struct
@@ -162,13 +201,82 @@ type ObjectUpdateResponse struct {
 
 // Settings holds stored configuration options
 type Settings struct {
-	ImproveURL         string `datastore:"ImproveUrl"` // Field name overridden for backwards compatibility
-	GeneralPermissions GeneralPermissions
+	ImproveURL         string             `json:"improveURL" datastore:"ImproveUrl"` // Field name overridden for backwards compatibility
+	GeneralPermissions GeneralPermissions `json:"generalPermissions"`
+	// Deployment admins, who can read and write every team whatever its permissions
+	Admins Permission `json:"admins"`
+	// Templates available to all teams
+	PeriodTemplates []PeriodTemplate `json:"periodTemplates"`
+	// Which period backups to keep (nil for DefaultBackupRetention)
+	BackupRetention *BackupRetention `json:"backupRetention"`
+	// Which saved period versions to keep when compacting (nil for DefaultVersionCompaction)
+	VersionCompaction *VersionCompaction `json:"versionCompaction"`
+	// Days a deleted team or period can be restored before it is purged (0 for DefaultDeletionGraceDays)
+	DeletionGraceDays int `json:"deletionGraceDays"`
+}
+
+// PeriodTemplate is a named starting point for new periods: a bucket layout,
+// including any standing objectives, plus default period settings
+type PeriodTemplate struct {
+	ID                     string          `json:"id"`
+	DisplayName            string          `json:"displayName"`
+	Unit                   string          `json:"unit"`
+	UnitAbbrev             string          `json:"unitAbbrev"`
+	MaxCommittedPercentage float64         `json:"maxCommittedPercentage"`
+	SecondaryUnits         []SecondaryUnit `json:"secondaryUnits"`
+	Buckets                []Bucket        `json:"buckets"`
+}
+
+// PeriodTemplates holds the templates belonging to a single team
+type PeriodTemplates struct {
+	Templates []PeriodTemplate `json:"templates"`
+}
+
+// ObjectiveRef identifies an objective within a period by position
+type ObjectiveRef struct {
+	BucketIndex    int `json:"bucketIndex"`
+	ObjectiveIndex int `json:"objectiveIndex"`
+}
+
+// ObjectiveSelection selects objectives from a period. An objective is selected if it
+// matches any of the criteria.
+type ObjectiveSelection struct {
+	All       bool     `json:"all"`
+	Committed bool     `json:"committed"`
+	Tags      []string `json:"tags"`
+	// Objectives chosen individually, e.g. those the user considers unfinished
+	Objectives []ObjectiveRef `json:"objectives"`
+}
+
+// RollForwardRequest is sent by the browser to create a new period based on an existing one
+type RollForwardRequest struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	// If set, everybody's availability is set to Availability rather than copied
+	ResetAvailability bool               `json:"resetAvailability"`
+	Availability      float64            `json:"availability"`
+	ClearAssignments  bool               `json:"clearAssignments"`
+	CarryOver         ObjectiveSelection `json:"carryOver"`
 }
 
 type User struct {
 	Email  string
 	Domain string
+	// Whether the user is one of the deployment admins in the settings
+	IsAdmin bool
+	// Groups the user is in, if the authentication provider supplies them
+	Groups []string
+	// The API key which a script authenticated with, nil for users who signed in
+	APIKey *APIKey
+}
+
+// Identity is how the user is recorded as having made a change: their email address,
+// or the name of the API key they used
+func (user User) Identity() string {
+	if user.APIKey != nil {
+		return "apikey:" + user.APIKey.Name
+	}
+	return user.Email
 }
 
 type PeriodBackup struct {
@@ -178,4 +286,17 @@ type PeriodBackup struct {
 
 type PeriodBackups struct {
 	Backups []PeriodBackup `json:"backups"`
+	// Tagged versions of the period, whose backups are never pruned
+	Tags []VersionTag `json:"tags"`
+}
+
+// TeamBackup is a previous version of a team, including its permissions
+type TeamBackup struct {
+	// When this version of the team was replaced
+	Timestamp time.Time `json:"timestamp"`
+	Team      Team      `json:"team"`
+}
+
+type TeamBackups struct {
+	Backups []TeamBackup `json:"backups"`
 }
@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -14,6 +14,8 @@
 
 package models
 
+import "time"
+
 // Period2 is a new version of the Period model struct.
 // It should eventually replace Period as part of https://github.com/google/peoplemath/issues/214.
 type Period2 struct {
@@ -35,6 +37,47 @@ type Period2 struct {
 	// must be the latest version at the time of the update (this allows us to examine only the first
 	// ParentVersion when looking for the merge base of two versions).
 	ParentVersions []string `json:"parentVersion"`
+	// Timestamp is when this version was saved, which the storage sets if the caller
+	// doesn't, e.g. when importing old versions. Author is who saved it, which like
+	// Version must be set by the caller when saving.
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author"`
+}
+
+// PeriodVersionInfo describes a saved version of a period, without its content
+type PeriodVersionInfo struct {
+	Version        string    `json:"version"`
+	ParentVersions []string  `json:"parentVersion"`
+	Timestamp      time.Time `json:"timestamp"`
+	Author         string    `json:"author"`
+	DisplayName    string    `json:"displayName"`
+}
+
+// VersionInfo describes this version of the period
+func (p *Period2) VersionInfo() PeriodVersionInfo {
+	return PeriodVersionInfo{
+		Version:        p.Version,
+		ParentVersions: p.ParentVersions,
+		Timestamp:      p.Timestamp,
+		Author:         p.Author,
+		DisplayName:    p.DisplayName,
+	}
+}
+
+// AsPeriod converts the period to the older Period model, e.g. so that versions can be compared
+func (p *Period2) AsPeriod() *Period {
+	return &Period{
+		ID:                     p.ID,
+		DisplayName:            p.DisplayName,
+		Unit:                   p.Unit,
+		UnitAbbrev:             p.UnitAbbrev,
+		NotesURL:               p.NotesURL,
+		MaxCommittedPercentage: p.MaxCommittedPercentage,
+		Buckets:                p.Buckets,
+		People:                 p.People,
+		SecondaryUnits:         p.SecondaryUnits,
+		LastUpdateUUID:         p.Version,
+	}
 }
 
 type PeriodListItem struct {
@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Types of review event
+const (
+	ReviewEventSubmitted        = "submitted"
+	ReviewEventApproved         = "approved"
+	ReviewEventChangesRequested = "changesRequested"
+	// Recorded when the period is edited after reviewers have responded
+	ReviewEventInvalidated = "invalidated"
+)
+
+// Approval status of a period under review
+const (
+	ApprovalStatusPending          = "pending"
+	ApprovalStatusApproved         = "approved"
+	ApprovalStatusChangesRequested = "changesRequested"
+)
+
+// Review holds the sign-off of a period's plan
+type Review struct {
+	Reviewers []UserMatcher `json:"reviewers"`
+	// See ApprovalStatus* constants. Computed from the events by the server.
+	Status string        `json:"status"`
+	Events []ReviewEvent `json:"events"`
+}
+
+// ReviewEvent records a step of the review
+type ReviewEvent struct {
+	Type    string `json:"type"`
+	User    string `json:"user"` // Email address of the user responsible
+	Comment string `json:"comment"`
+	// LastUpdateUUID of the version of the period which the event applied to
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+	// For approvals and requests for changes, the reviewers the user was acting as
+	Matched []UserMatcher `json:"matched"`
+}
+
+// ReviewRequest is sent by the browser to submit a period for review
+type ReviewRequest struct {
+	Reviewers []UserMatcher `json:"reviewers"`
+}
+
+// ReviewDecision is sent by the browser to approve, or request changes to, a period
+type ReviewDecision struct {
+	Decision string `json:"decision"` // ReviewEventApproved or ReviewEventChangesRequested
+	Comment  string `json:"comment"`
+	// The version of the period being reviewed, which must be the latest
+	LastUpdateUUID string `json:"lastUpdateUUID"`
+}
+
+// IsValidReviewDecision indicates whether a reviewer's decision is one of the permitted types
+func IsValidReviewDecision(decision string) bool {
+	return decision == ReviewEventApproved || decision == ReviewEventChangesRequested
+}
+
+// currentRound returns the events since the period was last submitted, or last edited
+func (r *Review) currentRound() []ReviewEvent {
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		if r.Events[i].Type == ReviewEventSubmitted || r.Events[i].Type == ReviewEventInvalidated {
+			return r.Events[i+1:]
+		}
+	}
+	return r.Events
+}
+
+// UpdateStatus recomputes the status from the events. Only each user's latest decision counts.
+// The period is approved once every reviewer has approved it, and nobody has requested changes.
+func (r *Review) UpdateStatus() {
+	latest := make(map[string]ReviewEvent)
+	for _, event := range r.currentRound() {
+		latest[event.User] = event
+	}
+	approved := make(map[UserMatcher]bool)
+	for _, event := range latest {
+		if event.Type == ReviewEventChangesRequested {
+			r.Status = ApprovalStatusChangesRequested
+			return
+		}
+		for _, matcher := range event.Matched {
+			approved[matcher] = true
+		}
+	}
+	for _, reviewer := range r.Reviewers {
+		if !approved[reviewer] {
+			r.Status = ApprovalStatusPending
+			return
+		}
+	}
+	r.Status = ApprovalStatusApproved
+}
+
+// AddEvent returns a copy of the review with an extra event, and its status updated
+func (r *Review) AddEvent(event ReviewEvent) *Review {
+	result := *r
+	result.Events = append(append([]ReviewEvent{}, r.Events...), event)
+	result.UpdateStatus()
+	return &result
+}
+
+// Invalidate returns a copy of the review with any responses from reviewers discarded,
+// for when the period has been edited
+func (r *Review) Invalidate(user string, version string, t time.Time) *Review {
+	if len(r.currentRound()) == 0 {
+		return r
+	}
+	return r.AddEvent(ReviewEvent{Type: ReviewEventInvalidated, User: user, Version: version, Time: t})
+}
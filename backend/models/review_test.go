@@ -0,0 +1,55 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewStatus(t *testing.T) {
+	director := UserMatcher{Type: UserMatcherTypeEmail, ID: "director@example.com"}
+	finance := UserMatcher{Type: UserMatcherTypeDomain, ID: "finance.example.com"}
+	review := &Review{Reviewers: []UserMatcher{director, finance}}
+	review = review.AddEvent(ReviewEvent{Type: ReviewEventSubmitted, User: "lead@example.com", Version: "v1"})
+	if review.Status != ApprovalStatusPending {
+		t.Errorf("Expected submitted review to be pending, found %s", review.Status)
+	}
+
+	review = review.AddEvent(ReviewEvent{Type: ReviewEventApproved, User: "director@example.com", Version: "v1", Matched: []UserMatcher{director}})
+	if review.Status != ApprovalStatusPending {
+		t.Errorf("Expected review to await the second reviewer, found %s", review.Status)
+	}
+	review = review.AddEvent(ReviewEvent{Type: ReviewEventChangesRequested, User: "bob@finance.example.com", Version: "v1", Matched: []UserMatcher{finance}})
+	if review.Status != ApprovalStatusChangesRequested {
+		t.Errorf("Expected changes to be requested, found %s", review.Status)
+	}
+	// A later decision by the same user replaces their earlier one
+	review = review.AddEvent(ReviewEvent{Type: ReviewEventApproved, User: "bob@finance.example.com", Version: "v1", Matched: []UserMatcher{finance}})
+	if review.Status != ApprovalStatusApproved {
+		t.Errorf("Expected review to be approved, found %s", review.Status)
+	}
+
+	edited := review.Invalidate("lead@example.com", "v2", time.Now())
+	if edited.Status != ApprovalStatusPending {
+		t.Errorf("Expected edit to invalidate approvals, found %s", edited.Status)
+	}
+	if review.Status != ApprovalStatusApproved || len(edited.Events) != len(review.Events)+1 {
+		t.Errorf("Expected invalidation to be recorded in a copy of the review")
+	}
+	if again := edited.Invalidate("lead@example.com", "v3", time.Now()); len(again.Events) != len(edited.Events) {
+		t.Errorf("Expected no further invalidation without new decisions")
+	}
+}
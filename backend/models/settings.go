@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Validate checks that each user matcher has a known type and an ID
+func (p Permission) Validate() error {
+	for _, matcher := range p.Allow {
+		if matcher.Type != UserMatcherTypeEmail && matcher.Type != UserMatcherTypeDomain && matcher.Type != UserMatcherTypeGroup {
+			return fmt.Errorf("unknown user matcher type '%s'", matcher.Type)
+		}
+		if matcher.ID == "" {
+			return fmt.Errorf("%s user matcher has no ID", matcher.Type)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the settings make sense, before they are saved
+func (s *Settings) Validate() error {
+	if s.ImproveURL != "" {
+		u, err := url.Parse(s.ImproveURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("improve URL '%s' must be an absolute http or https URL", s.ImproveURL)
+		}
+	}
+	for name, permission := range map[string]Permission{
+		"readTeamList": s.GeneralPermissions.ReadTeamList,
+		"addTeam":      s.GeneralPermissions.AddTeam,
+		"administer":   s.GeneralPermissions.Administer,
+		"admins":       s.Admins,
+	} {
+		if err := permission.Validate(); err != nil {
+			return fmt.Errorf("invalid %s permission: %v", name, err)
+		}
+	}
+	ids := make(map[string]bool)
+	for _, template := range s.PeriodTemplates {
+		if template.ID == "" {
+			return fmt.Errorf("template ID must be specified")
+		}
+		if ids[template.ID] {
+			return fmt.Errorf("template ID '%s' is used more than once", template.ID)
+		}
+		ids[template.ID] = true
+	}
+	if s.BackupRetention != nil {
+		if err := s.BackupRetention.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.VersionCompaction != nil {
+		if err := s.VersionCompaction.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.DeletionGraceDays < 0 {
+		return fmt.Errorf("deletion grace period must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestValidateSettings(t *testing.T) {
+	admins := GeneralPermissions{Administer: Permission{Allow: []UserMatcher{{Type: UserMatcherTypeDomain, ID: "example.com"}}}}
+	for name, tc := range map[string]struct {
+		settings Settings
+		valid    bool
+	}{
+		"empty":                 {Settings{}, true},
+		"complete":              {Settings{ImproveURL: "https://example.com/improve", GeneralPermissions: admins, DeletionGraceDays: 7}, true},
+		"relative URL":          {Settings{ImproveURL: "/improve"}, false},
+		"unknown matcher":       {Settings{GeneralPermissions: GeneralPermissions{AddTeam: Permission{Allow: []UserMatcher{{Type: "Team", ID: "t"}}}}}, false},
+		"matcher with no ID":    {Settings{GeneralPermissions: GeneralPermissions{ReadTeamList: Permission{Allow: []UserMatcher{{Type: UserMatcherTypeEmail}}}}}, false},
+		"duplicate template":    {Settings{PeriodTemplates: []PeriodTemplate{{ID: "t"}, {ID: "t"}}}, false},
+		"negative retention":    {Settings{BackupRetention: &BackupRetention{DailyDays: -1}}, false},
+		"negative merge window": {Settings{VersionCompaction: &VersionCompaction{MergeWindowHours: -1}}, false},
+		"negative grace period": {Settings{DeletionGraceDays: -1}, false},
+	} {
+		if err := tc.settings.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Validate(%s) returned %v, expected valid=%v", name, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VersionTag names a version of a period, such as the baseline signed off by leadership.
+// Tags are immutable: to point a name at another version, delete the tag and create it again.
+type VersionTag struct {
+	Name string `json:"name"`
+	// The backup ID (for a Period) or version (for a Period2) which is tagged
+	Version   string    `json:"version"`
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TagRequest is sent by the browser to tag a version of a period
+type TagRequest struct {
+	Name string `json:"name"`
+	// The version to tag, or empty for the current version
+	Version string `json:"version"`
+}
+
+// ValidateTagName checks that a tag name can be used in version references such as team/period@name
+func ValidateTagName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tag name must be specified")
+	}
+	if strings.ContainsAny(name, "/@") {
+		return fmt.Errorf("tag name '%s' must not contain '/' or '@'", name)
+	}
+	return nil
+}
+
+// FindTag returns the index of the tag with the given name, or -1 if there is none
+func FindTag(tags []VersionTag, name string) int {
+	for i, tag := range tags {
+		if tag.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResolveTag returns the version which a tag names, or the given version itself if it is not a tag
+func ResolveTag(tags []VersionTag, version string) string {
+	if i := FindTag(tags, version); i >= 0 {
+		return tags[i].Version
+	}
+	return version
+}
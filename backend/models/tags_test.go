@@ -0,0 +1,41 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestValidateTagName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"baseline":    true,
+		"2026 Q1 v2":  true,
+		"":            false,
+		"team/period": false,
+		"v@1":         false,
+	} {
+		if err := ValidateTagName(name); (err == nil) != valid {
+			t.Errorf("ValidateTagName(%q) returned %v, expected valid=%v", name, err, valid)
+		}
+	}
+}
+
+func TestResolveTag(t *testing.T) {
+	tags := []VersionTag{{Name: "baseline", Version: "v1"}}
+	if version := ResolveTag(tags, "baseline"); version != "v1" {
+		t.Errorf("Expected tag to resolve to v1, found %s", version)
+	}
+	if version := ResolveTag(tags, "v2"); version != "v2" {
+		t.Errorf("Expected untagged version to be unchanged, found %s", version)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	codeSpanRegexp    = regexp.MustCompile("`([^`]+)`")
+	linkRegexp        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	linkDefRegexp     = regexp.MustCompile(`\[([^\]]+)\]:[ \t]*(\S*)`)
+	strongRegexp      = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emRegexp          = regexp.MustCompile(`\*([^*]+)\*|(^|[^\w])_([^_]+)_([^\w]|$)`)
+	strikeRegexp      = regexp.MustCompile(`~~(.+?)~~`)
+	placeholderRegexp = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// markdownToHTML renders the subset of Markdown supported by the front end
+// (emphasis, strong, strikethrough, inline code and links) to HTML.
+// All other input is escaped, so the result is safe to embed in a page.
+// Line breaks in the input are preserved as <br> elements.
+func markdownToHTML(src string) template.HTML {
+	// Fragments of generated HTML are swapped out for placeholders while the remaining
+	// rules run, so that (for example) underscores in URLs are not treated as emphasis.
+	var fragments []string
+	placeholder := func(fragment string) string {
+		fragments = append(fragments, fragment)
+		return fmt.Sprintf("\x00%d\x00", len(fragments)-1)
+	}
+
+	s := html.EscapeString(strings.ReplaceAll(src, "\x00", ""))
+	s = codeSpanRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		return placeholder("<code>" + codeSpanRegexp.FindStringSubmatch(m)[1] + "</code>")
+	})
+	s = linkRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := linkRegexp.FindStringSubmatch(m)
+		text, url := parts[1], parts[2]
+		if !isSafeURL(html.UnescapeString(url)) {
+			return text
+		}
+		return placeholder(`<a href="`+url+`" target="_blank" rel="noopener noreferrer">`) + text + placeholder("</a>")
+	})
+	s = strongRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := strongRegexp.FindStringSubmatch(m)
+		return "<strong>" + parts[1] + parts[2] + "</strong>"
+	})
+	s = emRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := emRegexp.FindStringSubmatch(m)
+		if parts[1] != "" {
+			return "<em>" + parts[1] + "</em>"
+		}
+		return parts[2] + "<em>" + parts[3] + "</em>" + parts[4]
+	})
+	s = strikeRegexp.ReplaceAllString(s, "<s>$1</s>")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\n", "<br>\n")
+	s = placeholderRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		idx, _ := strconv.Atoi(placeholderRegexp.FindStringSubmatch(m)[1])
+		return fragments[idx]
+	})
+	return template.HTML(s)
+}
+
+// plainToHTML escapes plain text for HTML, preserving line breaks.
+func plainToHTML(src string) template.HTML {
+	s := html.EscapeString(strings.ReplaceAll(src, "\r\n", "\n"))
+	return template.HTML(strings.ReplaceAll(s, "\n", "<br>\n"))
+}
+
+func isSafeURL(url string) bool {
+	lower := strings.ToLower(strings.TrimSpace(url))
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "mailto:")
+}
+
+var markdownSpecialChars = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`#`, `\#`, `~`, `\~`, `|`, `\|`, `<`, `&lt;`, `>`, `&gt;`)
+
+// escapeMarkdown escapes plain text so that it renders literally in Markdown.
+func escapeMarkdown(s string) string {
+	return markdownSpecialChars.Replace(s)
+}
+
+// sanitizeMarkdown neutralizes raw HTML in user-supplied Markdown, which most
+// Markdown renderers would otherwise pass straight through, and removes links
+// to URLs with schemes other than those allowed by markdownToHTML. That includes
+// link reference definitions such as "[x]: url", which apply to the whole document.
+func sanitizeMarkdown(s string) string {
+	s = strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(s)
+	s = linkRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := linkRegexp.FindStringSubmatch(m)
+		if !isSafeURL(parts[2]) {
+			return parts[1]
+		}
+		return m
+	})
+	return linkDefRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := linkDefRegexp.FindStringSubmatch(m)
+		if !isSafeURL(parts[2]) {
+			return `\[` + parts[1] + `\]`
+		}
+		return m
+	})
+}
+
+// markdownText converts user-supplied text to Markdown which is safe to use within
+// a single line, such as a list item or a table cell. If isMarkdown is false, the
+// text is escaped to render literally.
+func markdownText(s string, isMarkdown bool) string {
+	if isMarkdown {
+		s = strings.ReplaceAll(sanitizeMarkdown(s), "|", `\|`)
+	} else {
+		s = escapeMarkdown(s)
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", " ")
+}
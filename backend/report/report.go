@@ -0,0 +1,391 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report renders periods as standalone planning documents.
+// Output is deterministic for a given team and period, so that it can be
+// pasted into other documents and compared over time.
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"peoplemath/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+type assigneeData struct {
+	PersonID   string
+	Name       string
+	URL        string
+	Commitment float64
+}
+
+type objectiveData struct {
+	Name             string
+	Markdown         bool
+	ResourceEstimate float64
+	Assigned         float64
+	CommitmentType   string
+	Notes            string
+	Assignees        []assigneeData
+	Groups           []string
+	Tags             []string
+}
+
+type bucketData struct {
+	Name       string
+	Allocation string
+	Objectives []objectiveData
+}
+
+type summaryRow struct {
+	Name         string
+	Allocated    float64
+	Estimated    float64
+	Assigned     float64
+	Committed    float64
+	Aspirational float64
+}
+
+type personData struct {
+	ID           string
+	Name         string
+	URL          string
+	Location     string
+	Availability float64
+	Assigned     float64
+}
+
+type reportData struct {
+	Breadcrumbs            []Link
+	TeamName               string
+	PeriodName             string
+	Unit                   string
+	NotesURL               string
+	MaxCommittedPercentage float64
+	CommittedPercentage    float64
+	Buckets                []bucketData
+	Summary                []summaryRow
+	Total                  summaryRow
+	People                 []personData
+}
+
+func personName(p models.Person) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.ID
+}
+
+// bucketAllocations calculates the resources allocated to each bucket, following
+// the front end: percentage allocations apply to whatever is left after absolute ones.
+func bucketAllocations(buckets []models.Bucket, totalAvailable float64) []float64 {
+	forPercent := totalAvailable
+	for _, b := range buckets {
+		if b.AllocationType == models.AllocationTypeAbsolute {
+			forPercent -= b.AllocationAbsolute
+		}
+	}
+	result := make([]float64, len(buckets))
+	for i, b := range buckets {
+		if b.AllocationType == models.AllocationTypeAbsolute {
+			result[i] = b.AllocationAbsolute
+		} else {
+			result[i] = forPercent * b.AllocationPercentage / 100
+		}
+	}
+	return result
+}
+
+func makeReportData(team models.Team, period *models.Period) reportData {
+	unit := period.UnitAbbrev
+	if unit == "" {
+		unit = period.Unit
+	}
+	data := reportData{
+		TeamName:               team.DisplayName,
+		PeriodName:             period.DisplayName,
+		Unit:                   unit,
+		NotesURL:               period.NotesURL,
+		MaxCommittedPercentage: period.MaxCommittedPercentage,
+	}
+	if data.TeamName == "" {
+		data.TeamName = team.ID
+	}
+	if data.PeriodName == "" {
+		data.PeriodName = period.ID
+	}
+
+	people := make(map[string]models.Person)
+	assignedByPerson := make(map[string]float64)
+	totalAvailable := 0.0
+	for _, p := range period.People {
+		people[p.ID] = p
+		totalAvailable += p.Availability
+	}
+
+	allocations := bucketAllocations(period.Buckets, totalAvailable)
+	data.Total.Name = "Total"
+	for i, bucket := range period.Buckets {
+		bd := bucketData{Name: bucket.DisplayName}
+		if bucket.AllocationType == models.AllocationTypeAbsolute {
+			bd.Allocation = fmt.Sprintf("%s %s", FormatNumber(bucket.AllocationAbsolute), unit)
+		} else {
+			bd.Allocation = fmt.Sprintf("%s%% (%s %s)", FormatNumber(bucket.AllocationPercentage), FormatNumber(allocations[i]), unit)
+		}
+		row := summaryRow{Name: bucket.DisplayName, Allocated: allocations[i]}
+		for _, objective := range bucket.Objectives {
+			od := objectiveData{
+				Name:             objective.Name,
+				Markdown:         objective.DisplayOptions.EnableMarkdown,
+				ResourceEstimate: objective.ResourceEstimate,
+				CommitmentType:   objective.CommitmentType,
+				Notes:            objective.Notes,
+			}
+			if od.CommitmentType == "" {
+				od.CommitmentType = models.CommitmentTypeAspirational
+			}
+			for _, a := range objective.Assignments {
+				name := a.PersonID
+				if p, ok := people[a.PersonID]; ok {
+					name = personName(p)
+				}
+				od.Assignees = append(od.Assignees, assigneeData{PersonID: a.PersonID, Name: name, Commitment: a.Commitment})
+				od.Assigned += a.Commitment
+				assignedByPerson[a.PersonID] += a.Commitment
+			}
+			for _, g := range objective.Groups {
+				od.Groups = append(od.Groups, g.GroupType+": "+g.GroupName)
+			}
+			for _, t := range objective.Tags {
+				od.Tags = append(od.Tags, t.Name)
+			}
+			bd.Objectives = append(bd.Objectives, od)
+
+			row.Estimated += objective.ResourceEstimate
+			row.Assigned += od.Assigned
+			if od.CommitmentType == models.CommitmentTypeCommitted {
+				row.Committed += od.Assigned
+			} else {
+				row.Aspirational += od.Assigned
+			}
+		}
+		data.Buckets = append(data.Buckets, bd)
+		data.Summary = append(data.Summary, row)
+		data.Total.Allocated += row.Allocated
+		data.Total.Estimated += row.Estimated
+		data.Total.Assigned += row.Assigned
+		data.Total.Committed += row.Committed
+		data.Total.Aspirational += row.Aspirational
+	}
+	if totalAvailable > 0 {
+		data.CommittedPercentage = 100 * data.Total.Committed / totalAvailable
+	}
+
+	for _, p := range period.People {
+		data.People = append(data.People, personData{
+			ID:           p.ID,
+			Name:         personName(p),
+			Location:     p.Location,
+			Availability: p.Availability,
+			Assigned:     assignedByPerson[p.ID],
+		})
+	}
+	return data
+}
+
+// FormatNumber formats a resource quantity for display, to at most two decimal places.
+func FormatNumber(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// RenderText renders user-supplied text as safe HTML, treating it as Markdown if isMarkdown is set.
+func RenderText(s string, isMarkdown bool) template.HTML {
+	if isMarkdown {
+		return markdownToHTML(s)
+	}
+	return plainToHTML(s)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"number":     FormatNumber,
+	"renderText": RenderText,
+	"join":       strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.TeamName}}: {{.PeriodName}}</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+td.number { text-align: right; }
+.committed { font-weight: bold; }
+.notes { color: #444; font-size: 90%; }
+</style>
+</head>
+<body>
+{{- if .Breadcrumbs}}
+<nav>{{range $i, $l := .Breadcrumbs}}{{if $i}} &gt; {{end}}<a href="{{$l.URL}}">{{$l.Text}}</a>{{end}}</nav>
+{{- end}}
+<h1>{{.TeamName}}: {{.PeriodName}}</h1>
+{{- if .NotesURL}}
+<p>Notes: <a href="{{.NotesURL}}">{{.NotesURL}}</a></p>
+{{- end}}
+<h2>Summary</h2>
+<table>
+<tr><th>Bucket</th><th>Allocated ({{.Unit}})</th><th>Estimated ({{.Unit}})</th><th>Assigned ({{.Unit}})</th><th>Committed ({{.Unit}})</th><th>Aspirational ({{.Unit}})</th></tr>
+{{- range .Summary}}
+<tr><td>{{.Name}}</td><td class="number">{{number .Allocated}}</td><td class="number">{{number .Estimated}}</td><td class="number">{{number .Assigned}}</td><td class="number">{{number .Committed}}</td><td class="number">{{number .Aspirational}}</td></tr>
+{{- end}}
+{{- with .Total}}
+<tr><th>{{.Name}}</th><th class="number">{{number .Allocated}}</th><th class="number">{{number .Estimated}}</th><th class="number">{{number .Assigned}}</th><th class="number">{{number .Committed}}</th><th class="number">{{number .Aspirational}}</th></tr>
+{{- end}}
+</table>
+<p>Committed: {{number .CommittedPercentage}}% of available resources (target maximum {{number .MaxCommittedPercentage}}%)</p>
+{{- range .Buckets}}
+<h2>{{.Name}}</h2>
+<p>Allocation: {{.Allocation}}</p>
+<table>
+<tr><th>Objective</th><th>Commitment</th><th>Estimate ({{$.Unit}})</th><th>Assigned ({{$.Unit}})</th><th>Assignees</th><th>Groups</th><th>Tags</th></tr>
+{{- range .Objectives}}
+<tr{{if eq .CommitmentType "Committed"}} class="committed"{{end}}><td>{{renderText .Name .Markdown}}{{if .Notes}}<div class="notes">{{renderText .Notes .Markdown}}</div>{{end}}</td><td>{{.CommitmentType}}</td><td class="number">{{number .ResourceEstimate}}</td><td class="number">{{number .Assigned}}</td><td>{{range $i, $a := .Assignees}}{{if $i}}, {{end}}{{if $a.URL}}<a href="{{$a.URL}}">{{$a.Name}}</a>{{else}}{{$a.Name}}{{end}} ({{number $a.Commitment}}){{end}}</td><td>{{join .Groups ", "}}</td><td>{{join .Tags ", "}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>People</h2>
+<table>
+<tr><th>Person</th><th>Location</th><th>Available ({{.Unit}})</th><th>Assigned ({{.Unit}})</th></tr>
+{{- range .People}}
+<tr><td>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{.Location}}</td><td class="number">{{number .Availability}}</td><td class="number">{{number .Assigned}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// Link is a hyperlink to be included in a rendered page.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// HTMLOptions customizes the HTML rendering of a period, e.g. to link it into a larger site.
+type HTMLOptions struct {
+	// Breadcrumbs are shown as navigation links at the top of the page.
+	Breadcrumbs []Link
+	// PersonURL, if set, returns the URL to link each person to.
+	PersonURL func(personID string) string
+}
+
+// RenderHTML writes a period as a self-contained HTML document.
+// Markdown in objectives with Markdown enabled is rendered; all other
+// user-supplied text is escaped.
+func RenderHTML(w io.Writer, team models.Team, period *models.Period) error {
+	return RenderHTMLWithOptions(w, team, period, HTMLOptions{})
+}
+
+// RenderHTMLWithOptions is like RenderHTML, but with customizations to the generated page.
+func RenderHTMLWithOptions(w io.Writer, team models.Team, period *models.Period, options HTMLOptions) error {
+	data := makeReportData(team, period)
+	data.Breadcrumbs = options.Breadcrumbs
+	if options.PersonURL != nil {
+		for i := range data.Buckets {
+			for j := range data.Buckets[i].Objectives {
+				for k := range data.Buckets[i].Objectives[j].Assignees {
+					assignee := &data.Buckets[i].Objectives[j].Assignees[k]
+					assignee.URL = options.PersonURL(assignee.PersonID)
+				}
+			}
+		}
+		for i := range data.People {
+			data.People[i].URL = options.PersonURL(data.People[i].ID)
+		}
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+// RenderMarkdown writes a period as a Markdown document.
+func RenderMarkdown(w io.Writer, team models.Team, period *models.Period) error {
+	data := makeReportData(team, period)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s: %s\n\n", escapeMarkdown(data.TeamName), escapeMarkdown(data.PeriodName))
+	if data.NotesURL != "" && isSafeURL(data.NotesURL) {
+		fmt.Fprintf(bw, "Notes: <%s>\n\n", data.NotesURL)
+	}
+
+	unit := escapeMarkdown(data.Unit)
+	fmt.Fprintf(bw, "## Summary\n\n")
+	fmt.Fprintf(bw, "| Bucket | Allocated (%[1]s) | Estimated (%[1]s) | Assigned (%[1]s) | Committed (%[1]s) | Aspirational (%[1]s) |\n", unit)
+	fmt.Fprintf(bw, "| --- | ---: | ---: | ---: | ---: | ---: |\n")
+	writeRow := func(name string, row summaryRow) {
+		fmt.Fprintf(bw, "| %s | %s | %s | %s | %s | %s |\n", name, FormatNumber(row.Allocated), FormatNumber(row.Estimated),
+			FormatNumber(row.Assigned), FormatNumber(row.Committed), FormatNumber(row.Aspirational))
+	}
+	for _, row := range data.Summary {
+		writeRow(markdownText(row.Name, false), row)
+	}
+	writeRow("**"+data.Total.Name+"**", data.Total)
+	fmt.Fprintf(bw, "\nCommitted: %s%% of available resources (target maximum %s%%)\n",
+		FormatNumber(data.CommittedPercentage), FormatNumber(data.MaxCommittedPercentage))
+
+	for _, bucket := range data.Buckets {
+		fmt.Fprintf(bw, "\n## %s\n\n", markdownText(bucket.Name, false))
+		fmt.Fprintf(bw, "Allocation: %s\n", escapeMarkdown(bucket.Allocation))
+		for _, o := range bucket.Objectives {
+			fmt.Fprintf(bw, "\n### %s\n\n", markdownText(o.Name, o.Markdown))
+			fmt.Fprintf(bw, "- Commitment: %s\n", escapeMarkdown(o.CommitmentType))
+			fmt.Fprintf(bw, "- Estimate: %s %s\n", FormatNumber(o.ResourceEstimate), unit)
+			fmt.Fprintf(bw, "- Assigned: %s %s\n", FormatNumber(o.Assigned), unit)
+			if len(o.Assignees) > 0 {
+				assignees := make([]string, len(o.Assignees))
+				for i, a := range o.Assignees {
+					assignees[i] = fmt.Sprintf("%s (%s)", markdownText(a.Name, false), FormatNumber(a.Commitment))
+				}
+				fmt.Fprintf(bw, "- Assignees: %s\n", strings.Join(assignees, ", "))
+			}
+			if len(o.Groups) > 0 {
+				fmt.Fprintf(bw, "- Groups: %s\n", markdownText(strings.Join(o.Groups, ", "), false))
+			}
+			if len(o.Tags) > 0 {
+				fmt.Fprintf(bw, "- Tags: %s\n", markdownText(strings.Join(o.Tags, ", "), false))
+			}
+			if o.Notes != "" {
+				fmt.Fprintf(bw, "\n")
+				for _, line := range strings.Split(strings.ReplaceAll(o.Notes, "\r\n", "\n"), "\n") {
+					fmt.Fprintf(bw, "> %s\n", markdownText(line, o.Markdown))
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(bw, "\n## People\n\n")
+	fmt.Fprintf(bw, "| Person | Location | Available (%[1]s) | Assigned (%[1]s) |\n", unit)
+	fmt.Fprintf(bw, "| --- | --- | ---: | ---: |\n")
+	for _, p := range data.People {
+		fmt.Fprintf(bw, "| %s | %s | %s | %s |\n", markdownText(p.Name, false), markdownText(p.Location, false),
+			FormatNumber(p.Availability), FormatNumber(p.Assigned))
+	}
+	return bw.Flush()
+}
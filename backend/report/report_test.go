@@ -0,0 +1,185 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"peoplemath/models"
+	"strings"
+	"testing"
+)
+
+func makeTestPeriod() *models.Period {
+	return &models.Period{
+		ID:                     "2026q1",
+		DisplayName:            "2026 Q1",
+		Unit:                   "person weeks",
+		UnitAbbrev:             "pw",
+		MaxCommittedPercentage: 50,
+		Buckets: []models.Bucket{
+			{
+				DisplayName:          "Roadmap",
+				AllocationPercentage: 60,
+				Objectives: []models.Objective{
+					{
+						Name:             "Ship **the** thing",
+						ResourceEstimate: 8,
+						CommitmentType:   models.CommitmentTypeCommitted,
+						Notes:            "See [the doc](https://example.com/doc) and [this](javascript:alert)",
+						Assignments:      []models.Assignment{{PersonID: "alice", Commitment: 5}},
+						Groups:           []models.ObjectiveGroup{{GroupType: "Project", GroupName: "Thing"}},
+						Tags:             []models.ObjectiveTag{{Name: "launch"}},
+						DisplayOptions:   models.DisplayOptions{EnableMarkdown: true},
+					},
+					{
+						Name:             "<script>alert('x')</script> *not markdown*",
+						ResourceEstimate: 3,
+						Assignments:      []models.Assignment{{PersonID: "bob", Commitment: 3}},
+					},
+				},
+			},
+			{
+				DisplayName:        "Fixed",
+				AllocationType:     models.AllocationTypeAbsolute,
+				AllocationAbsolute: 2,
+			},
+		},
+		People: []models.Person{
+			{ID: "alice", DisplayName: "Alice Atkins", Location: "LON", Availability: 6},
+			{ID: "bob", Location: "SVL", Availability: 6},
+		},
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var b strings.Builder
+	err := RenderHTML(&b, models.Team{ID: "myteam", DisplayName: "My team"}, makeTestPeriod())
+	if err != nil {
+		t.Fatalf("RenderHTML returned error: %v", err)
+	}
+	out := b.String()
+	for _, expected := range []string{
+		"<title>My team: 2026 Q1</title>",
+		"Ship <strong>the</strong> thing",
+		`<a href="https://example.com/doc" target="_blank" rel="noopener noreferrer">the doc</a>`,
+		"&lt;script&gt;",
+		"*not markdown*",
+		"Alice Atkins (5)",
+		"bob (3)",
+		"Project: Thing",
+		"launch",
+		"60% (6 pw)",
+		"<td>Fixed</td><td class=\"number\">2</td>",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, out)
+		}
+	}
+	for _, unexpected := range []string{"<script>", "javascript:"} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("Unexpected %q in output:\n%s", unexpected, out)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var b strings.Builder
+	err := RenderMarkdown(&b, models.Team{ID: "myteam", DisplayName: "My team"}, makeTestPeriod())
+	if err != nil {
+		t.Fatalf("RenderMarkdown returned error: %v", err)
+	}
+	out := b.String()
+	for _, expected := range []string{
+		"# My team: 2026 Q1\n",
+		"### Ship **the** thing\n",
+		"> See [the doc](https://example.com/doc) and this\n",
+		"### &lt;script&gt;alert('x')&lt;/script&gt; \\*not markdown\\*\n",
+		"- Assignees: Alice Atkins (5)\n",
+		"| Roadmap | 6 | 11 | 8 | 5 | 3 |\n",
+		"| **Total** | 8 | 11 | 8 | 5 | 3 |\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, out)
+		}
+	}
+}
+
+func TestRenderIsStable(t *testing.T) {
+	team := models.Team{ID: "myteam"}
+	for _, render := range []func(*strings.Builder) error{
+		func(b *strings.Builder) error { return RenderHTML(b, team, makeTestPeriod()) },
+		func(b *strings.Builder) error { return RenderMarkdown(b, team, makeTestPeriod()) },
+	} {
+		var b1, b2 strings.Builder
+		if err := render(&b1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := render(&b2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b1.String() != b2.String() {
+			t.Errorf("Output differs between renderings:\n%s\n---\n%s", b1.String(), b2.String())
+		}
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	for _, tc := range []struct {
+		in, expected string
+	}{
+		{"plain", "plain"},
+		{"**bold** and *em*", "<strong>bold</strong> and <em>em</em>"},
+		{"snake_case_name", "snake_case_name"},
+		{"an _emphasised_ word", "an <em>emphasised</em> word"},
+		{"`**code**`", "<code>**code**</code>"},
+		{"~~gone~~", "<s>gone</s>"},
+		{"[x](https://a.com/b_c_d)", `<a href="https://a.com/b_c_d" target="_blank" rel="noopener noreferrer">x</a>`},
+		{"[x](data:text/html,hi)", "x"},
+		{`<img src=x onerror="y">`, "&lt;img src=x onerror=&#34;y&#34;&gt;"},
+		{"line1\nline2", "line1<br>\nline2"},
+	} {
+		if got := string(markdownToHTML(tc.in)); got != tc.expected {
+			t.Errorf("markdownToHTML(%q) = %q, expected %q", tc.in, got, tc.expected)
+		}
+	}
+}
+
+func TestSanitizeMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		in, expected string
+	}{
+		{"[x](https://a.com)", "[x](https://a.com)"},
+		{"[x](javascript:alert)", "x"},
+		{"[x]: https://a.com", "[x]: https://a.com"},
+		{"[x]: javascript:alert(1)", `\[x\]`},
+		{"  [x]:\tdata:text/html,hi", `  \[x\]`},
+		{"[x]: <javascript:alert(1)>", `\[x\]`},
+		{"[x]:", `\[x\]`},
+	} {
+		if got := sanitizeMarkdown(tc.in); got != tc.expected {
+			t.Errorf("sanitizeMarkdown(%q) = %q, expected %q", tc.in, got, tc.expected)
+		}
+	}
+
+	// Each line of an objective's notes is quoted, so a definition on its own line takes effect
+	var b strings.Builder
+	period := makeTestPeriod()
+	period.Buckets[0].Objectives[0].Notes = "See [this][x]\n[x]: javascript:alert(1)"
+	if err := RenderMarkdown(&b, models.Team{ID: "myteam"}, period); err != nil {
+		t.Fatalf("RenderMarkdown returned error: %v", err)
+	}
+	if out := b.String(); strings.Contains(out, "javascript:") || !strings.Contains(out, "> \\[x\\]\n") {
+		t.Errorf("Expected the link reference definition to be removed, found:\n%s", out)
+	}
+}
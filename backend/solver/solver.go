@@ -0,0 +1,213 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package solver proposes assignments of people to objectives.
+package solver
+
+import (
+	"peoplemath/models"
+	"sort"
+)
+
+// Tolerance for floating point comparisons of resources
+const epsilon = 1e-9
+
+// Options controls how assignments are proposed.
+type Options struct {
+	// ClearExisting discards all existing assignments before proposing new ones.
+	// By default, existing assignments are kept and only the shortfall is filled.
+	ClearExisting bool `json:"clearExisting"`
+}
+
+// AssignmentChange describes a change to a single person's commitment to an objective.
+// Objectives are identified by position, as they have no IDs.
+type AssignmentChange struct {
+	BucketIndex    int     `json:"bucketIndex"`
+	ObjectiveIndex int     `json:"objectiveIndex"`
+	ObjectiveName  string  `json:"objectiveName"`
+	PersonID       string  `json:"personId"`
+	Before         float64 `json:"before"`
+	After          float64 `json:"after"`
+}
+
+// Proposal is the result of Propose. Nothing is saved: the caller may accept
+// the proposal by saving Period, which keeps the LastUpdateUUID of the input.
+type Proposal struct {
+	Changes []AssignmentChange `json:"changes"`
+	Period  *models.Period     `json:"period"`
+}
+
+type objectiveRef struct {
+	bucket, objective int
+}
+
+// Propose assigns people with spare availability to objectives which are not fully resourced.
+// Committed objectives are resourced before aspirational ones, and otherwise objectives are
+// taken in bucket order and then stack rank order. To minimize fragmentation, each objective is
+// topped up first from people already working on it, then from the person who can cover the
+// remaining shortfall with the least time to spare, and only split across several people if no
+// one person can cover it. The result depends only on the input, not on any randomness or map order.
+func Propose(period *models.Period, options Options) Proposal {
+	result := copyPeriod(period)
+	if options.ClearExisting {
+		for b := range result.Buckets {
+			for o := range result.Buckets[b].Objectives {
+				result.Buckets[b].Objectives[o].Assignments = nil
+			}
+		}
+	}
+
+	remaining := make(map[string]float64)
+	for _, person := range result.People {
+		remaining[person.ID] += person.Availability
+	}
+	for _, bucket := range result.Buckets {
+		for _, objective := range bucket.Objectives {
+			for _, a := range objective.Assignments {
+				remaining[a.PersonID] -= a.Commitment
+			}
+		}
+	}
+
+	for _, ref := range objectivesInPriorityOrder(&result) {
+		objective := &result.Buckets[ref.bucket].Objectives[ref.objective]
+		fillObjective(objective, result.People, remaining)
+	}
+
+	return Proposal{Changes: diffAssignments(period, &result), Period: &result}
+}
+
+func objectivesInPriorityOrder(period *models.Period) []objectiveRef {
+	var committed, aspirational []objectiveRef
+	for b, bucket := range period.Buckets {
+		for o, objective := range bucket.Objectives {
+			ref := objectiveRef{bucket: b, objective: o}
+			if objective.CommitmentType == models.CommitmentTypeCommitted {
+				committed = append(committed, ref)
+			} else {
+				aspirational = append(aspirational, ref)
+			}
+		}
+	}
+	return append(committed, aspirational...)
+}
+
+func fillObjective(objective *models.Objective, people []models.Person, remaining map[string]float64) {
+	shortfall := objective.ResourceEstimate
+	for _, a := range objective.Assignments {
+		shortfall -= a.Commitment
+	}
+
+	// Top up existing assignees first, in their existing order
+	for i := range objective.Assignments {
+		if shortfall <= epsilon {
+			return
+		}
+		a := &objective.Assignments[i]
+		if extra := min(shortfall, remaining[a.PersonID]); extra > epsilon {
+			a.Commitment += extra
+			remaining[a.PersonID] -= extra
+			shortfall -= extra
+		}
+	}
+
+	for shortfall > epsilon {
+		personID, ok := pickPerson(people, remaining, shortfall)
+		if !ok {
+			return
+		}
+		commitment := min(shortfall, remaining[personID])
+		objective.Assignments = append(objective.Assignments, models.Assignment{PersonID: personID, Commitment: commitment})
+		remaining[personID] -= commitment
+		shortfall -= commitment
+	}
+}
+
+// pickPerson chooses the person who can cover the shortfall with the least time left over,
+// or if nobody can cover it, the person with the most time available.
+// Ties go to the person listed first in the period.
+func pickPerson(people []models.Person, remaining map[string]float64, shortfall float64) (string, bool) {
+	bestFit, largest := "", ""
+	for _, person := range people {
+		r := remaining[person.ID]
+		if r <= epsilon {
+			continue
+		}
+		if r >= shortfall-epsilon && (bestFit == "" || r < remaining[bestFit]-epsilon) {
+			bestFit = person.ID
+		}
+		if largest == "" || r > remaining[largest]+epsilon {
+			largest = person.ID
+		}
+	}
+	if bestFit != "" {
+		return bestFit, true
+	}
+	return largest, largest != ""
+}
+
+func copyPeriod(period *models.Period) models.Period {
+	result := *period
+	result.Buckets = make([]models.Bucket, len(period.Buckets))
+	for b, bucket := range period.Buckets {
+		result.Buckets[b] = bucket
+		result.Buckets[b].Objectives = make([]models.Objective, len(bucket.Objectives))
+		for o, objective := range bucket.Objectives {
+			result.Buckets[b].Objectives[o] = objective
+			result.Buckets[b].Objectives[o].Assignments = append([]models.Assignment(nil), objective.Assignments...)
+		}
+	}
+	return result
+}
+
+func commitmentsByPerson(assignments []models.Assignment) map[string]float64 {
+	result := make(map[string]float64)
+	for _, a := range assignments {
+		result[a.PersonID] += a.Commitment
+	}
+	return result
+}
+
+func diffAssignments(before, after *models.Period) []AssignmentChange {
+	changes := []AssignmentChange{}
+	for b, bucket := range after.Buckets {
+		for o, objective := range bucket.Objectives {
+			oldCommitments := commitmentsByPerson(before.Buckets[b].Objectives[o].Assignments)
+			newCommitments := commitmentsByPerson(objective.Assignments)
+			var personIDs []string
+			for id := range oldCommitments {
+				personIDs = append(personIDs, id)
+			}
+			for id := range newCommitments {
+				if _, ok := oldCommitments[id]; !ok {
+					personIDs = append(personIDs, id)
+				}
+			}
+			sort.Strings(personIDs)
+			for _, id := range personIDs {
+				if oldCommitments[id] != newCommitments[id] {
+					changes = append(changes, AssignmentChange{
+						BucketIndex:    b,
+						ObjectiveIndex: o,
+						ObjectiveName:  objective.Name,
+						PersonID:       id,
+						Before:         oldCommitments[id],
+						After:          newCommitments[id],
+					})
+				}
+			}
+		}
+	}
+	return changes
+}
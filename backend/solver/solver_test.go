@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solver
+
+import (
+	"peoplemath/models"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func makeTestPeriod() *models.Period {
+	return &models.Period{
+		ID:             "2026q1",
+		LastUpdateUUID: "uuid1",
+		Buckets: []models.Bucket{
+			{
+				DisplayName: "Roadmap",
+				Objectives: []models.Objective{
+					{Name: "Aspirational", ResourceEstimate: 4},
+					{
+						Name:             "Committed",
+						ResourceEstimate: 6,
+						CommitmentType:   models.CommitmentTypeCommitted,
+						Assignments:      []models.Assignment{{PersonID: "bob", Commitment: 2}},
+					},
+				},
+			},
+			{
+				DisplayName: "Other",
+				Objectives: []models.Objective{
+					{Name: "Small", ResourceEstimate: 3},
+				},
+			},
+		},
+		People: []models.Person{
+			{ID: "alice", Availability: 5},
+			{ID: "bob", Availability: 3},
+			{ID: "charlie", Availability: 3},
+		},
+	}
+}
+
+func TestPropose(t *testing.T) {
+	period := makeTestPeriod()
+	proposal := Propose(period, Options{})
+
+	// "Committed" goes first: bob is topped up by 1, then charlie fits the remaining 3 exactly.
+	// Then "Aspirational" needs 4, which only alice can cover.
+	// Finally "Small" needs 3, but only alice has any time left, so it is part-filled.
+	expected := []AssignmentChange{
+		{BucketIndex: 0, ObjectiveIndex: 0, ObjectiveName: "Aspirational", PersonID: "alice", After: 4},
+		{BucketIndex: 0, ObjectiveIndex: 1, ObjectiveName: "Committed", PersonID: "bob", Before: 2, After: 3},
+		{BucketIndex: 0, ObjectiveIndex: 1, ObjectiveName: "Committed", PersonID: "charlie", After: 3},
+		{BucketIndex: 1, ObjectiveIndex: 0, ObjectiveName: "Small", PersonID: "alice", After: 1},
+	}
+	if diff := cmp.Diff(expected, proposal.Changes); diff != "" {
+		t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+	}
+	if proposal.Period.LastUpdateUUID != "uuid1" {
+		t.Errorf("Expected proposal to keep LastUpdateUUID, found %s", proposal.Period.LastUpdateUUID)
+	}
+	if diff := cmp.Diff(makeTestPeriod(), period); diff != "" {
+		t.Errorf("Input period was modified (-want +got):\n%s", diff)
+	}
+}
+
+func TestProposeClearExisting(t *testing.T) {
+	proposal := Propose(makeTestPeriod(), Options{ClearExisting: true})
+	expected := []models.Assignment{{PersonID: "alice", Commitment: 5}, {PersonID: "bob", Commitment: 1}}
+	if diff := cmp.Diff(expected, proposal.Period.Buckets[0].Objectives[1].Assignments); diff != "" {
+		t.Errorf("Unexpected assignments for committed objective (-want +got):\n%s", diff)
+	}
+}
+
+func TestProposeIsDeterministic(t *testing.T) {
+	first := Propose(makeTestPeriod(), Options{})
+	for i := 0; i < 10; i++ {
+		if diff := cmp.Diff(first, Propose(makeTestPeriod(), Options{})); diff != "" {
+			t.Fatalf("Proposal differs between runs (-first +later):\n%s", diff)
+		}
+	}
+}
+
+func TestProposeFullyResourced(t *testing.T) {
+	period := makeTestPeriod()
+	period.Buckets[0].Objectives[1].Assignments[0].Commitment = 6
+	period.People[1].Availability = 6
+	period.Buckets = period.Buckets[:1]
+	period.Buckets[0].Objectives = period.Buckets[0].Objectives[1:]
+	proposal := Propose(period, Options{})
+	if len(proposal.Changes) != 0 {
+		t.Errorf("Expected no changes, found %v", proposal.Changes)
+	}
+}
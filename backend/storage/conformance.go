@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -19,8 +19,10 @@ import (
 	"peoplemath/models"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 const (
@@ -105,6 +107,12 @@ func testPeriods(ctx context.Context, s StorageService2, t *testing.T) {
 	}
 
 	period.Version = "v1"
+	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &period)
+	if err == nil {
+		t.Error("Expected error saving period without author")
+	}
+
+	period.Author = "alice@domain.com"
 	savedPeriod, err := s.UpsertPeriodLatestVersion(ctx, teamID, &period)
 	if err != nil {
 		t.Errorf("UpsertPeriodLatestVersion gave error: %v", err)
@@ -120,11 +128,18 @@ func testPeriods(ctx context.Context, s StorageService2, t *testing.T) {
 	if savedPeriod.Version != "v1" {
 		t.Errorf("Expected v1 to be latest, found '%v'", savedPeriod.Version)
 	}
+	if savedPeriod.Author != "alice@domain.com" {
+		t.Errorf("Expected saved period to have author alice@domain.com, found '%v'", savedPeriod.Author)
+	}
+	if savedPeriod.Timestamp.IsZero() {
+		t.Error("Expected the storage to set the timestamp of the saved period")
+	}
 
 	updatedPeriod := models.Period2{
 		ID:          periodID,
 		DisplayName: "My updated test period",
 		Version:     "v2",
+		Author:      "bob@domain.com",
 	}
 	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &updatedPeriod)
 	if err == nil {
@@ -150,13 +165,16 @@ func testPeriods(ctx context.Context, s StorageService2, t *testing.T) {
 	if savedPeriod.Version != "v2" {
 		t.Errorf("Saved period version expected v2, got '%v'", savedPeriod.Version)
 	}
+	if savedPeriod.Author != "bob@domain.com" || savedPeriod.Timestamp.IsZero() {
+		t.Errorf("Expected saved period to have author bob@domain.com and a timestamp, found '%v' at %v", savedPeriod.Author, savedPeriod.Timestamp)
+	}
 
 	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &updatedPeriod)
 	if err == nil {
 		t.Error("UpsertPeriodLatestVersion succeeded with existing version")
 	}
 
-	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &models.Period2{ID: "mynew", Version: "v1", ParentVersions: []string{"foo"}})
+	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &models.Period2{ID: "mynew", Version: "v1", ParentVersions: []string{"foo"}, Author: "alice@domain.com"})
 	if err == nil {
 		t.Error("UpsertPeriodLatestVersion succeeded with new period with parent versions")
 	}
@@ -171,6 +189,7 @@ func testPeriodMerging(ctx context.Context, s StorageService2, t *testing.T) {
 		Unit:           "Updated unit",
 		Version:        "v3",
 		ParentVersions: []string{"v1"},
+		Author:         "alice@domain.com",
 	}
 	// Should be safe as it updates an unrelated field
 	savedPeriod, err := s.UpsertPeriodLatestVersion(ctx, teamID, &updatedPeriod)
@@ -183,16 +202,23 @@ func testPeriodMerging(ctx context.Context, s StorageService2, t *testing.T) {
 		Unit:           "Updated unit",
 		Version:        "v3",
 		ParentVersions: []string{"v2", "v1"},
+		Author:         "alice@domain.com",
 	}
-	if diff := cmp.Diff(expectedPeriod, savedPeriod); diff != "" {
+	// The timestamp is set by the storage, so is only checked to have been set
+	ignoreTimestamp := cmpopts.IgnoreFields(models.Period2{}, "Timestamp")
+	if diff := cmp.Diff(expectedPeriod, savedPeriod, ignoreTimestamp); diff != "" {
 		t.Errorf("unexpected saved period (-want +got):\n%s", diff)
 	}
+	if savedPeriod.Timestamp.IsZero() {
+		t.Error("Expected the storage to set the timestamp of the merged period")
+	}
 
 	conflictingUpdate := models.Period2{
 		ID:             periodID,
 		DisplayName:    "My conflicting display name",
 		Version:        "v4",
 		ParentVersions: []string{"v1"},
+		Author:         "bob@domain.com",
 	}
 	_, err = s.UpsertPeriodLatestVersion(ctx, teamID, &conflictingUpdate)
 	if _, ok := err.(ConcurrentModificationError); !ok {
@@ -206,13 +232,270 @@ func testPeriodMerging(ctx context.Context, s StorageService2, t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected get failure: %v", err)
 	}
-	if diff := cmp.Diff(expectedPeriod, savedPeriod); diff != "" {
+	if diff := cmp.Diff(expectedPeriod, savedPeriod, ignoreTimestamp); diff != "" {
 		t.Errorf("unexpected saved period (-want +got):\n%s", diff)
 	}
 }
 
+func testPeriodVersions(ctx context.Context, s StorageService2, t *testing.T) {
+	_, err := s.GetPeriodVersions(ctx, teamID, "doesnotexist")
+	if _, ok := err.(PeriodNotFoundError); !ok {
+		t.Errorf("Expected PeriodNotFoundError on GetPeriodVersions for non-existent period, found %v", err)
+	}
+	_, err = s.GetPeriodVersion(ctx, teamID, periodID, "doesnotexist")
+	if _, ok := err.(PeriodVersionNotFoundError); !ok {
+		t.Errorf("Expected PeriodVersionNotFoundError on GetPeriodVersion for non-existent version, found %v", err)
+	}
+
+	// The versions saved by testPeriods, including the merged version, but not the failed update
+	versions, err := s.GetPeriodVersions(ctx, teamID, periodID)
+	if err != nil {
+		t.Errorf("GetPeriodVersions returned error: %v", err)
+		return
+	}
+	expected := []models.PeriodVersionInfo{
+		{Version: "v3", ParentVersions: []string{"v2", "v1"}, Author: "alice@domain.com", DisplayName: "My updated test period"},
+		{Version: "v2", ParentVersions: []string{"v1"}, Author: "bob@domain.com", DisplayName: "My updated test period"},
+		{Version: "v1", Author: "alice@domain.com", DisplayName: "My test period"},
+	}
+	ignoreTimestamp := cmpopts.IgnoreFields(models.PeriodVersionInfo{}, "Timestamp")
+	if diff := cmp.Diff(expected, versions, ignoreTimestamp); diff != "" {
+		t.Errorf("unexpected period versions (-want +got):\n%s", diff)
+	}
+
+	period, err := s.GetPeriodVersion(ctx, teamID, periodID, "v1")
+	if err != nil {
+		t.Errorf("GetPeriodVersion returned error: %v", err)
+		return
+	}
+	if period.Version != "v1" || period.DisplayName != "My test period" {
+		t.Errorf("Unexpected period version: %v", period)
+	}
+
+	ancestry, err := GetPeriodAncestry(ctx, s, teamID, periodID, "v3", 0)
+	if err != nil {
+		t.Errorf("GetPeriodAncestry returned error: %v", err)
+		return
+	}
+	if diff := cmp.Diff(expected, ancestry, ignoreTimestamp); diff != "" {
+		t.Errorf("unexpected ancestry (-want +got):\n%s", diff)
+	}
+}
+
+func testPeriodCompaction(ctx context.Context, s StorageService2, t *testing.T) {
+	_, err := s.CompactPeriodVersions(ctx, teamID, "doesnotexist", models.DefaultVersionCompaction, time.Now())
+	if _, ok := err.(PeriodNotFoundError); !ok {
+		t.Errorf("Expected PeriodNotFoundError on CompactPeriodVersions for non-existent period, found %v", err)
+	}
+
+	// The versions saved by testPeriods were all saved just now, so they fall into the same week,
+	// and only the latest version and the latest of the others (v2) are kept
+	removed, err := s.CompactPeriodVersions(ctx, teamID, periodID, models.VersionCompaction{}, time.Now())
+	if err != nil {
+		t.Errorf("CompactPeriodVersions returned error: %v", err)
+		return
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 version to be removed, found %d", removed)
+	}
+	versions, err := s.GetPeriodVersions(ctx, teamID, periodID)
+	if err != nil {
+		t.Errorf("GetPeriodVersions returned error: %v", err)
+		return
+	}
+	expected := []models.PeriodVersionInfo{
+		{Version: "v3", ParentVersions: []string{"v2"}, Author: "alice@domain.com", DisplayName: "My updated test period"},
+		{Version: "v2", Author: "bob@domain.com", DisplayName: "My updated test period"},
+	}
+	ignoreTimestamp := cmpopts.IgnoreFields(models.PeriodVersionInfo{}, "Timestamp")
+	if diff := cmp.Diff(expected, versions, cmpopts.EquateEmpty(), ignoreTimestamp); diff != "" {
+		t.Errorf("unexpected period versions after compaction (-want +got):\n%s", diff)
+	}
+
+	// Updates based on a kept version can still be merged
+	update := models.Period2{
+		ID:             periodID,
+		DisplayName:    "My period after compaction",
+		Version:        "v5",
+		ParentVersions: []string{"v2"},
+		Author:         "alice@domain.com",
+	}
+	if _, err := s.UpsertPeriodLatestVersion(ctx, teamID, &update); err != nil {
+		t.Errorf("UpsertPeriodLatestVersion after compaction returned error: %v", err)
+	}
+}
+
+func testPeriodTags(ctx context.Context, s StorageService2, t *testing.T) {
+	// After testPeriodCompaction, the versions are v5, v3 and v2
+	tags, err := s.GetPeriodTags(ctx, teamID, periodID)
+	if err != nil {
+		t.Errorf("GetPeriodTags returned error: %v", err)
+		return
+	}
+	if len(tags) != 0 {
+		t.Errorf("Expected no tags, found %v", tags)
+	}
+	_, err = s.GetPeriodTags(ctx, teamID, "doesnotexist")
+	if _, ok := err.(PeriodNotFoundError); !ok {
+		t.Errorf("Expected PeriodNotFoundError on GetPeriodTags for non-existent period, found %v", err)
+	}
+
+	err = s.AddPeriodTag(ctx, teamID, periodID, models.VersionTag{Name: "baseline", Version: "doesnotexist"})
+	if _, ok := err.(PeriodVersionNotFoundError); !ok {
+		t.Errorf("Expected PeriodVersionNotFoundError on AddPeriodTag for non-existent version, found %v", err)
+	}
+	for _, tag := range []models.VersionTag{{Name: "baseline", Version: "v2"}, {Name: "temporary", Version: "v3"}} {
+		if err := s.AddPeriodTag(ctx, teamID, periodID, tag); err != nil {
+			t.Errorf("AddPeriodTag returned error: %v", err)
+		}
+	}
+	err = s.AddPeriodTag(ctx, teamID, periodID, models.VersionTag{Name: "baseline", Version: "v3"})
+	if _, ok := err.(TagExistsError); !ok {
+		t.Errorf("Expected TagExistsError on AddPeriodTag for existing tag, found %v", err)
+	}
+	if err := s.DeletePeriodTag(ctx, teamID, periodID, "temporary"); err != nil {
+		t.Errorf("DeletePeriodTag returned error: %v", err)
+	}
+	err = s.DeletePeriodTag(ctx, teamID, periodID, "temporary")
+	if _, ok := err.(TagNotFoundError); !ok {
+		t.Errorf("Expected TagNotFoundError on DeletePeriodTag for non-existent tag, found %v", err)
+	}
+	tags, err = s.GetPeriodTags(ctx, teamID, periodID)
+	if err != nil {
+		t.Errorf("GetPeriodTags returned error: %v", err)
+		return
+	}
+	if diff := cmp.Diff([]models.VersionTag{{Name: "baseline", Version: "v2"}}, tags); diff != "" {
+		t.Errorf("unexpected tags (-want +got):\n%s", diff)
+	}
+
+	// Without the tag, v2 would be removed in favour of v3, the latest of the other versions
+	removed, err := s.CompactPeriodVersions(ctx, teamID, periodID, models.VersionCompaction{}, time.Now())
+	if err != nil {
+		t.Errorf("CompactPeriodVersions returned error: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("Expected tagged version to be kept, but %d versions were removed", removed)
+	}
+}
+
+func testRenaming(ctx context.Context, s StorageService2, t *testing.T) {
+	// After testPeriodTags, the period has versions v5, v3 and v2, and v2 is tagged
+	err := s.RenamePeriod(ctx, teamID, "doesnotexist", "pd2")
+	if _, ok := err.(PeriodNotFoundError); !ok {
+		t.Errorf("Expected PeriodNotFoundError on RenamePeriod for non-existent period, found %v", err)
+	}
+	other := models.Period2{ID: "other", DisplayName: "Another period", Version: "o1", Author: "alice@domain.com"}
+	if _, err := s.UpsertPeriodLatestVersion(ctx, teamID, &other); err != nil {
+		t.Errorf("UpsertPeriodLatestVersion returned error: %v", err)
+		return
+	}
+	err = s.RenamePeriod(ctx, teamID, periodID, "other")
+	if _, ok := err.(PeriodExistsError); !ok {
+		t.Errorf("Expected PeriodExistsError on RenamePeriod to an existing period, found %v", err)
+	}
+	if err := s.RenamePeriod(ctx, teamID, periodID, "pd2"); err != nil {
+		t.Errorf("RenamePeriod returned error: %v", err)
+		return
+	}
+	_, err = s.GetPeriodLatestVersion(ctx, teamID, periodID)
+	if _, ok := err.(PeriodNotFoundError); !ok {
+		t.Errorf("Expected PeriodNotFoundError for renamed period, found %v", err)
+	}
+
+	err = s.RenameTeam(ctx, "doesnotexist", "newteam")
+	if _, ok := err.(TeamNotFoundError); !ok {
+		t.Errorf("Expected TeamNotFoundError on RenameTeam for non-existent team, found %v", err)
+	}
+	if err := s.CreateTeam(ctx, models.Team{ID: "takenteam", DisplayName: "Taken"}); err != nil {
+		t.Errorf("CreateTeam returned error: %v", err)
+		return
+	}
+	err = s.RenameTeam(ctx, teamID, "takenteam")
+	if _, ok := err.(TeamExistsError); !ok {
+		t.Errorf("Expected TeamExistsError on RenameTeam to an existing team, found %v", err)
+	}
+	if err := s.RenameTeam(ctx, teamID, "newteam"); err != nil {
+		t.Errorf("RenameTeam returned error: %v", err)
+		return
+	}
+	_, err = s.GetTeam(ctx, teamID)
+	if _, ok := err.(TeamNotFoundError); !ok {
+		t.Errorf("Expected TeamNotFoundError for renamed team, found %v", err)
+	}
+	team, err := s.GetTeam(ctx, "newteam")
+	if err != nil {
+		t.Errorf("GetTeam returned error: %v", err)
+		return
+	}
+	if team.ID != "newteam" || team.DisplayName != "My team" {
+		t.Errorf("Unexpected renamed team: %v", team)
+	}
+
+	// The period's versions and tags move with it
+	period, err := s.GetPeriodLatestVersion(ctx, "newteam", "pd2")
+	if err != nil {
+		t.Errorf("GetPeriodLatestVersion returned error: %v", err)
+		return
+	}
+	if period.ID != "pd2" || period.Version != "v5" {
+		t.Errorf("Unexpected renamed period: %v", period)
+	}
+	old, err := s.GetPeriodVersion(ctx, "newteam", "pd2", "v2")
+	if err != nil {
+		t.Errorf("GetPeriodVersion returned error: %v", err)
+		return
+	}
+	if old.ID != "pd2" {
+		t.Errorf("Expected old version to have the new ID, found %v", old.ID)
+	}
+	versions, err := s.GetPeriodVersions(ctx, "newteam", "pd2")
+	if err != nil {
+		t.Errorf("GetPeriodVersions returned error: %v", err)
+		return
+	}
+	if len(versions) != 3 {
+		t.Errorf("Expected 3 versions, found %v", versions)
+	}
+	tags, err := s.GetPeriodTags(ctx, "newteam", "pd2")
+	if err != nil {
+		t.Errorf("GetPeriodTags returned error: %v", err)
+		return
+	}
+	if diff := cmp.Diff([]models.VersionTag{{Name: "baseline", Version: "v2"}}, tags); diff != "" {
+		t.Errorf("unexpected tags after renaming (-want +got):\n%s", diff)
+	}
+}
+
+func testSettings(ctx context.Context, s StorageService2, t *testing.T) {
+	settings := models.Settings{
+		ImproveURL:        "https://example.com/improve",
+		DeletionGraceDays: 7,
+		GeneralPermissions: models.GeneralPermissions{
+			Administer: models.Permission{Allow: []models.UserMatcher{{Type: models.UserMatcherTypeEmail, ID: "admin@example.com"}}},
+		},
+	}
+	if err := s.UpdateSettings(ctx, settings); err != nil {
+		t.Errorf("UpdateSettings returned error: %v", err)
+		return
+	}
+	loaded, err := s.GetSettings(ctx)
+	if err != nil {
+		t.Errorf("GetSettings returned error: %v", err)
+		return
+	}
+	if diff := cmp.Diff(settings, loaded, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("unexpected settings (-want +got):\n%s", diff)
+	}
+}
+
 func TestStorageConformance(s StorageService2, t *testing.T) {
 	ctx := context.Background()
 	testTeams(ctx, s, t)
 	testPeriods(ctx, s, t)
+	testPeriodVersions(ctx, s, t)
+	testPeriodCompaction(ctx, s, t)
+	testPeriodTags(ctx, s, t)
+	testRenaming(ctx, s, t)
+	testSettings(ctx, s, t)
 }
@@ -1,4 +1,4 @@
-// Copyright 2020-21, 2024 Google LLC
+// Copyright 2020-21, 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -16,7 +16,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"peoplemath/models"
+	"time"
 )
 
 // StorageService to represent the persistent store
@@ -29,12 +32,115 @@ type StorageService interface {
 	GetPeriod(ctx context.Context, teamID, periodID string) (*models.Period, bool, error)
 	CreatePeriod(ctx context.Context, teamID string, period *models.Period) error
 	UpdatePeriod(ctx context.Context, teamID string, period *models.Period) error
+	// ModifyPeriod reads a period, changes it in place with modify and saves it, all in one
+	// transaction. If the period has been edited since lastUpdateUUID, it fails with a
+	// ConcurrentModificationError. Any error from modify is returned as is, and nothing is
+	// saved. The modify function may be called more than once.
+	ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error
 	GetPeriodBackups(ctx context.Context, teamID, periodID string) (models.PeriodBackups, bool, error)
+	// UpsertPeriodBackups replaces a period's backups, keeping its saved tags, which are only
+	// changed by UpdatePeriodTags
 	UpsertPeriodBackups(ctx context.Context, teamID, periodID string, backups models.PeriodBackups) error
+	// UpdatePeriodTags replaces a period's tags with the result of update, which is given the
+	// saved backups and tags, leaving the backups themselves alone, all in one transaction.
+	// Any error from update is returned as is. The update may be called more than once.
+	UpdatePeriodTags(ctx context.Context, teamID, periodID string, update func(backups models.PeriodBackups) ([]models.VersionTag, error)) error
+	// GetTeamBackups returns previous versions of a team, oldest first
+	GetTeamBackups(ctx context.Context, teamID string) (models.TeamBackups, bool, error)
+	UpsertTeamBackups(ctx context.Context, teamID string, backups models.TeamBackups) error
+	GetTeamTemplates(ctx context.Context, teamID string) (models.PeriodTemplates, bool, error)
+	UpsertTeamTemplates(ctx context.Context, teamID string, templates models.PeriodTemplates) error
 	GetSettings(ctx context.Context) (models.Settings, error)
+	UpdateSettings(ctx context.Context, settings models.Settings) error
+	// UpdateOrgTemplates replaces the org-wide period templates, leaving the rest of the settings alone
+	UpdateOrgTemplates(ctx context.Context, templates []models.PeriodTemplate) error
+	AddAuditEntry(ctx context.Context, entry models.AuditEntry) error
+	// GetAuditEntries returns a page of a team's audit entries, newest first. If periodID is
+	// empty, entries for the team and all its periods are returned. An empty page token
+	// gets the first page. Changes to the settings are recorded with an empty team ID.
+	GetAuditEntries(ctx context.Context, teamID, periodID, pageToken string, pageSize int) (models.AuditPage, error)
+	// DeleteTeam and DeletePeriod mark a team or period as deleted. It is then left out of
+	// everything else until it is restored or purged, and creating another with the same ID
+	// fails with a DeletedError.
+	DeleteTeam(ctx context.Context, teamID string, deletion models.Deletion) error
+	DeletePeriod(ctx context.Context, teamID, periodID string, deletion models.Deletion) error
+	// GetDeletedItems lists every deleted team and period, oldest deletion first
+	GetDeletedItems(ctx context.Context) ([]models.DeletedItem, error)
+	// RestoreDeletedItem restores a deleted team, or a deleted period if periodID is not empty
+	RestoreDeletedItem(ctx context.Context, teamID, periodID string) error
+	// PurgeDeletedItem permanently removes a deleted period, or a deleted team along with
+	// all of its periods, backups, templates and audit entries
+	PurgeDeletedItem(ctx context.Context, teamID, periodID string) error
+	// RenameTeam changes the ID of a team, moving everything belonging to it, and leaves
+	// an alias at the old ID. If the new ID is taken, it fails with a TeamExistsError.
+	// This may not be atomic, but if it fails part way, renaming the team to the same
+	// new ID again finishes the rename. Until the rename has finished, changes to the team's
+	// periods and templates may fail with a TeamRenamingError.
+	RenameTeam(ctx context.Context, teamID, newTeamID string, alias models.Alias) error
+	// RenamePeriod changes the ID of a period, moving its backups, and leaves an alias at
+	// the old ID. If the new ID is taken, it fails with a PeriodExistsError.
+	RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string, alias models.Alias) error
+	// GetTeamAlias and GetPeriodAlias find where a renamed team or period went
+	GetTeamAlias(ctx context.Context, teamID string) (models.Alias, bool, error)
+	GetPeriodAlias(ctx context.Context, teamID, periodID string) (models.Alias, bool, error)
+	// GetRenamedItems lists the aliases of every renamed team and period, oldest rename first.
+	// Renamed periods are listed under their team's current ID.
+	GetRenamedItems(ctx context.Context) ([]models.RenamedItem, error)
+	// GetAPIKeys lists every API key, oldest first
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error)
+	CreateAPIKey(ctx context.Context, key models.APIKey) error
+	// DeleteAPIKey revokes an API key. Deleting a key which doesn't exist is not an error.
+	DeleteAPIKey(ctx context.Context, keyID string) error
 	Close() error
 }
 
+// DeletedError is returned when creating a team or period with the ID of a deleted one
+type DeletedError string
+
+func (e DeletedError) Error() string {
+	return fmt.Sprintf("'%s' was deleted, and has not yet been purged", string(e))
+}
+
+// TeamRenamingError is returned by a change to a team which is part way through being renamed,
+// which can be made again once the rename has finished
+type TeamRenamingError string
+
+func (e TeamRenamingError) Error() string {
+	return fmt.Sprintf("Team '%s' is being renamed, and can't be changed until the rename has finished", string(e))
+}
+
+// DeletedItemID identifies a deleted team, or a deleted period if periodID is not empty
+func DeletedItemID(teamID, periodID string) string {
+	return teamID + "/" + periodID
+}
+
+// PurgeExpiredDeletedItems purges every deleted team and period whose grace period,
+// from the settings, has passed, and returns how many were purged
+func PurgeExpiredDeletedItems(ctx context.Context, s StorageService, now time.Time) (int, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve settings: %v", err)
+	}
+	grace := settings.GetDeletionGracePeriod()
+	items, err := s.GetDeletedItems(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve deleted items: %v", err)
+	}
+	purged := 0
+	for _, item := range items {
+		if now.Before(item.Timestamp.Add(grace)) {
+			continue
+		}
+		if err := s.PurgeDeletedItem(ctx, item.TeamID, item.PeriodID); err != nil {
+			return purged, fmt.Errorf("could not purge '%s': %v", DeletedItemID(item.TeamID, item.PeriodID), err)
+		}
+		log.Printf("Purged '%s', deleted by %s at %s", DeletedItemID(item.TeamID, item.PeriodID), item.User, item.Timestamp.Format(time.RFC3339))
+		purged++
+	}
+	return purged, nil
+}
+
 // scrubbingStorage is a wrapper for a StorageService which performs certain
 // scrubbing on the results, to avoid clients having to deal with quirks of
 // individual storage systems, such as Cloud Datastore not saving zero-length
@@ -68,6 +174,17 @@ func (s *scrubbingStorage) GetTeam(ctx context.Context, teamID string) (models.T
 	return team, ok, err
 }
 
+func (s *scrubbingStorage) GetTeamBackups(ctx context.Context, teamID string) (models.TeamBackups, bool, error) {
+	backups, ok, err := s.StorageService.GetTeamBackups(ctx, teamID)
+	if !ok || err != nil {
+		return backups, ok, err
+	}
+	for i := range backups.Backups {
+		scrubLoadedTeam(&backups.Backups[i].Team)
+	}
+	return backups, ok, err
+}
+
 func (s *scrubbingStorage) GetAllPeriods(ctx context.Context, teamID string) ([]models.Period, bool, error) {
 	periods, ok, err := s.StorageService.GetAllPeriods(ctx, teamID)
 	if !ok || err != nil {
@@ -88,6 +205,93 @@ func (s *scrubbingStorage) GetPeriod(ctx context.Context, teamID, periodID strin
 	return period, ok, err
 }
 
+func (s *scrubbingStorage) GetTeamTemplates(ctx context.Context, teamID string) (models.PeriodTemplates, bool, error) {
+	templates, ok, err := s.StorageService.GetTeamTemplates(ctx, teamID)
+	if err != nil {
+		return templates, ok, err
+	}
+	scrubLoadedTemplates(templates.Templates)
+	if templates.Templates == nil {
+		templates.Templates = []models.PeriodTemplate{}
+	}
+	return templates, ok, err
+}
+
+func (s *scrubbingStorage) GetAuditEntries(ctx context.Context, teamID, periodID, pageToken string, pageSize int) (models.AuditPage, error) {
+	page, err := s.StorageService.GetAuditEntries(ctx, teamID, periodID, pageToken, pageSize)
+	if err != nil {
+		return page, err
+	}
+	if page.Entries == nil {
+		page.Entries = []models.AuditEntry{}
+	}
+	for i := range page.Entries {
+		if page.Entries[i].Summary == nil {
+			page.Entries[i].Summary = []string{}
+		}
+	}
+	return page, err
+}
+
+func (s *scrubbingStorage) GetDeletedItems(ctx context.Context) ([]models.DeletedItem, error) {
+	items, err := s.StorageService.GetDeletedItems(ctx)
+	if err != nil {
+		return items, err
+	}
+	if items == nil {
+		items = []models.DeletedItem{}
+	}
+	for i := range items {
+		scrubLoadedPermissions(&items[i].Permissions)
+	}
+	return items, err
+}
+
+func (s *scrubbingStorage) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.StorageService.GetAPIKeys(ctx)
+	if err != nil {
+		return keys, err
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	for i := range keys {
+		scrubLoadedAPIKey(&keys[i])
+	}
+	return keys, err
+}
+
+func (s *scrubbingStorage) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error) {
+	key, found, err := s.StorageService.GetAPIKey(ctx, keyID)
+	if err != nil || !found {
+		return key, found, err
+	}
+	scrubLoadedAPIKey(&key)
+	return key, found, err
+}
+
+func scrubLoadedAPIKey(key *models.APIKey) {
+	if key.Teams == nil {
+		key.Teams = []models.APIKeyTeam{}
+	}
+}
+
+func (s *scrubbingStorage) GetSettings(ctx context.Context) (models.Settings, error) {
+	settings, err := s.StorageService.GetSettings(ctx)
+	if err != nil {
+		return settings, err
+	}
+	scrubLoadedTemplates(settings.PeriodTemplates)
+	if settings.PeriodTemplates == nil {
+		settings.PeriodTemplates = []models.PeriodTemplate{}
+	}
+	scrubLoadedGeneralPermissions(&settings.GeneralPermissions)
+	if settings.Admins.Allow == nil {
+		settings.Admins.Allow = []models.UserMatcher{}
+	}
+	return settings, err
+}
+
 func scrubLoadedPeriod(period *models.Period) {
 	if period.People == nil {
 		period.People = []models.Person{}
@@ -98,29 +302,75 @@ func scrubLoadedPeriod(period *models.Period) {
 	if period.SecondaryUnits == nil {
 		period.SecondaryUnits = []models.SecondaryUnit{}
 	}
-	for i := range period.Buckets {
-		if period.Buckets[i].Objectives == nil {
-			period.Buckets[i].Objectives = []models.Objective{}
+	if period.StateHistory == nil {
+		period.StateHistory = []models.StateTransition{}
+	}
+	if period.Review != nil {
+		if period.Review.Reviewers == nil {
+			period.Review.Reviewers = []models.UserMatcher{}
+		}
+		if period.Review.Events == nil {
+			period.Review.Events = []models.ReviewEvent{}
+		}
+	}
+	scrubLoadedBuckets(period.Buckets)
+}
+
+func scrubLoadedTemplates(templates []models.PeriodTemplate) {
+	for i := range templates {
+		if templates[i].Buckets == nil {
+			templates[i].Buckets = []models.Bucket{}
+		}
+		if templates[i].SecondaryUnits == nil {
+			templates[i].SecondaryUnits = []models.SecondaryUnit{}
 		}
-		for j := range period.Buckets[i].Objectives {
-			if period.Buckets[i].Objectives[j].Assignments == nil {
-				period.Buckets[i].Objectives[j].Assignments = []models.Assignment{}
+		scrubLoadedBuckets(templates[i].Buckets)
+	}
+}
+
+func scrubLoadedBuckets(buckets []models.Bucket) {
+	for i := range buckets {
+		if buckets[i].Objectives == nil {
+			buckets[i].Objectives = []models.Objective{}
+		}
+		for j := range buckets[i].Objectives {
+			if buckets[i].Objectives[j].Assignments == nil {
+				buckets[i].Objectives[j].Assignments = []models.Assignment{}
 			}
-			if period.Buckets[i].Objectives[j].Groups == nil {
-				period.Buckets[i].Objectives[j].Groups = []models.ObjectiveGroup{}
+			if buckets[i].Objectives[j].Groups == nil {
+				buckets[i].Objectives[j].Groups = []models.ObjectiveGroup{}
 			}
-			if period.Buckets[i].Objectives[j].Tags == nil {
-				period.Buckets[i].Objectives[j].Tags = []models.ObjectiveTag{}
+			if buckets[i].Objectives[j].Tags == nil {
+				buckets[i].Objectives[j].Tags = []models.ObjectiveTag{}
 			}
 		}
 	}
 }
 
 func scrubLoadedTeam(team *models.Team) {
-	if team.Permissions.Read.Allow == nil {
-		team.Permissions.Read.Allow = []models.UserMatcher{}
+	scrubLoadedPermissions(&team.Permissions)
+}
+
+func scrubLoadedGeneralPermissions(permissions *models.GeneralPermissions) {
+	if permissions.ReadTeamList.Allow == nil {
+		permissions.ReadTeamList.Allow = []models.UserMatcher{}
+	}
+	if permissions.AddTeam.Allow == nil {
+		permissions.AddTeam.Allow = []models.UserMatcher{}
+	}
+	if permissions.Administer.Allow == nil {
+		permissions.Administer.Allow = []models.UserMatcher{}
+	}
+}
+
+func scrubLoadedPermissions(permissions *models.TeamPermissions) {
+	if permissions.Read.Allow == nil {
+		permissions.Read.Allow = []models.UserMatcher{}
+	}
+	if permissions.Write.Allow == nil {
+		permissions.Write.Allow = []models.UserMatcher{}
 	}
-	if team.Permissions.Write.Allow == nil {
-		team.Permissions.Write.Allow = []models.UserMatcher{}
+	if permissions.Admin.Allow == nil {
+		permissions.Admin.Allow = []models.UserMatcher{}
 	}
 }
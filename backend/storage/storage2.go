@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,7 +17,9 @@ package storage
 import (
 	"context"
 	"fmt"
+	"log"
 	"peoplemath/models"
+	"time"
 )
 
 // StorageService2 to represent the persistent store.
@@ -44,7 +46,8 @@ type StorageService2 interface {
 	// If the period does exist, and the latest existing version is the parent of the provided version,
 	// then there have been no concurrent changes, and the provided value should be saved as the new latest.
 	// (It is assumed that the Version of the provided period has been set to a new unique value,
-	// and that the ParentVersion has been set to the Version from which it was derived, by the caller.)
+	// and that the ParentVersion has been set to the Version from which it was derived, by the caller.
+	// The caller must also set the Author. If the Timestamp isn't set, the time it was saved is used.)
 	// If the period exists and the latest existing version is *not* the parent of the provided version,
 	// then a concurrent change has taken place. A merge should be performed to combine the user's changes
 	// with the concurrent changes.
@@ -52,8 +55,37 @@ type StorageService2 interface {
 	// the previous latest. If unsuccessful, a ConcurrentModificationError should be returned.
 	// These updates should be performed with a transaction isolation level sufficient to prevent lost updates.
 	UpsertPeriodLatestVersion(ctx context.Context, teamID string, period *models.Period2) (*models.Period2, error)
+	// GetPeriodVersions lists all saved versions of a period, newest first.
+	// (If the period does not exist then PeriodNotFoundError should be returned.)
+	GetPeriodVersions(ctx context.Context, teamID, periodID string) ([]models.PeriodVersionInfo, error)
+	// GetPeriodVersion retrieves a specific saved version of a period.
+	// (If the version does not exist then PeriodVersionNotFoundError should be returned.)
+	GetPeriodVersion(ctx context.Context, teamID, periodID, version string) (*models.Period2, error)
+	// CompactPeriodVersions removes the versions of a period which the policy doesn't keep at the given time,
+	// as worked out by merge.CompactVersions, and returns how many were removed.
+	// (If the period does not exist then PeriodNotFoundError should be returned.)
+	CompactPeriodVersions(ctx context.Context, teamID, periodID string, policy models.VersionCompaction, now time.Time) (int, error)
+	// GetPeriodTags lists the tags on versions of a period.
+	// (If the period does not exist then PeriodNotFoundError should be returned.)
+	GetPeriodTags(ctx context.Context, teamID, periodID string) ([]models.VersionTag, error)
+	// AddPeriodTag tags a version of a period. Tagged versions are always kept by CompactPeriodVersions.
+	// (If the version does not exist then PeriodVersionNotFoundError should be returned,
+	// and if there is already a tag with the same name then TagExistsError.)
+	AddPeriodTag(ctx context.Context, teamID, periodID string, tag models.VersionTag) error
+	// DeletePeriodTag removes a tag from a period.
+	// (If the tag does not exist then TagNotFoundError should be returned.)
+	DeletePeriodTag(ctx context.Context, teamID, periodID, name string) error
+	// RenameTeam changes the ID of a team, moving all of its periods.
+	// (If the team does not exist then TeamNotFoundError should be returned,
+	// and if the new ID is taken then TeamExistsError.)
+	RenameTeam(ctx context.Context, teamID, newTeamID string) error
+	// RenamePeriod changes the ID of a period, moving all of its versions and tags.
+	// (If the period does not exist then PeriodNotFoundError should be returned,
+	// and if the new ID is taken then PeriodExistsError.)
+	RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string) error
 
 	GetSettings(ctx context.Context) (models.Settings, error)
+	UpdateSettings(ctx context.Context, settings models.Settings) error
 	Close() error
 }
 
@@ -69,6 +101,36 @@ func (e PeriodNotFoundError) Error() string {
 	return fmt.Sprintf("Period not found: %s", string(e))
 }
 
+type TeamExistsError string
+
+func (e TeamExistsError) Error() string {
+	return fmt.Sprintf("Team already exists: %s", string(e))
+}
+
+type PeriodExistsError string
+
+func (e PeriodExistsError) Error() string {
+	return fmt.Sprintf("Period already exists: %s", string(e))
+}
+
+type PeriodVersionNotFoundError string
+
+func (e PeriodVersionNotFoundError) Error() string {
+	return fmt.Sprintf("Period version not found: %s", string(e))
+}
+
+type TagExistsError string
+
+func (e TagExistsError) Error() string {
+	return fmt.Sprintf("Tag already exists: %s", string(e))
+}
+
+type TagNotFoundError string
+
+func (e TagNotFoundError) Error() string {
+	return fmt.Sprintf("Tag not found: %s", string(e))
+}
+
 type ConcurrentModificationError string
 
 func (e ConcurrentModificationError) Error() string {
@@ -126,6 +188,85 @@ func (s *scrubbingStorage2) UpsertPeriodLatestVersion(ctx context.Context, teamI
 	return period, err
 }
 
+func (s *scrubbingStorage2) GetPeriodVersion(ctx context.Context, teamID, periodID, version string) (*models.Period2, error) {
+	period, err := s.StorageService2.GetPeriodVersion(ctx, teamID, periodID, version)
+	if err != nil {
+		return period, err
+	}
+	scrubLoadedPeriod2(period)
+	return period, err
+}
+
+func (s *scrubbingStorage2) GetPeriodTags(ctx context.Context, teamID, periodID string) ([]models.VersionTag, error) {
+	tags, err := s.StorageService2.GetPeriodTags(ctx, teamID, periodID)
+	if err != nil {
+		return tags, err
+	}
+	if tags == nil {
+		tags = []models.VersionTag{}
+	}
+	return tags, err
+}
+
+// GetPeriodAncestry walks back through the parents of a version of a period, breadth first.
+// It returns the version itself followed by its ancestors, up to limit versions in all
+// (or all of them, if limit is 0).
+func GetPeriodAncestry(ctx context.Context, s StorageService2, teamID, periodID, version string, limit int) ([]models.PeriodVersionInfo, error) {
+	result := []models.PeriodVersionInfo{}
+	seen := map[string]bool{version: true}
+	queue := []string{version}
+	for len(queue) > 0 && (limit == 0 || len(result) < limit) {
+		period, err := s.GetPeriodVersion(ctx, teamID, periodID, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+		result = append(result, period.VersionInfo())
+		for _, parent := range period.ParentVersions {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return result, nil
+}
+
+// CompactAllPeriodVersions compacts the versions of every period of every team,
+// under the policy in the settings, and returns how many versions were removed
+func CompactAllPeriodVersions(ctx context.Context, s StorageService2, now time.Time) (int, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve settings: %v", err)
+	}
+	policy := settings.GetVersionCompaction()
+	if err := policy.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid version compaction policy: %v", err)
+	}
+	teams, err := s.GetAllTeams(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve teams: %v", err)
+	}
+	removed := 0
+	for _, team := range teams {
+		periods, err := s.GetAllPeriods(ctx, team.ID)
+		if err != nil {
+			return removed, fmt.Errorf("could not retrieve periods for team '%s': %v", team.ID, err)
+		}
+		for _, period := range periods.Periods {
+			n, err := s.CompactPeriodVersions(ctx, team.ID, period.ID, policy, now)
+			if err != nil {
+				return removed, fmt.Errorf("could not compact period '%s' for team '%s': %v", period.ID, team.ID, err)
+			}
+			if n > 0 {
+				log.Printf("Removed %d versions of period '%s' for team '%s'", n, period.ID, team.ID)
+			}
+			removed += n
+		}
+	}
+	return removed, nil
+}
+
 func scrubLoadedPeriod2(period *models.Period2) {
 	if period.People == nil {
 		period.People = []models.Person{}
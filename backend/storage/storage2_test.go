@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,7 +17,9 @@ package storage
 import (
 	"context"
 	"peoplemath/models"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type testStore2 struct {
@@ -53,6 +55,42 @@ func (s *testStore2) UpsertPeriodLatestVersion(ctx context.Context, teamID strin
 	return period, nil
 }
 
+func (s *testStore2) GetPeriodVersions(ctx context.Context, teamID, periodID string) ([]models.PeriodVersionInfo, error) {
+	panic("not implemented")
+}
+
+func (s *testStore2) GetPeriodVersion(ctx context.Context, teamID, periodID, version string) (*models.Period2, error) {
+	period, ok := s.periods[version]
+	if !ok {
+		return nil, PeriodVersionNotFoundError(version)
+	}
+	return &period, nil
+}
+
+func (s *testStore2) CompactPeriodVersions(ctx context.Context, teamID, periodID string, policy models.VersionCompaction, now time.Time) (int, error) {
+	panic("not implemented")
+}
+
+func (s *testStore2) GetPeriodTags(ctx context.Context, teamID, periodID string) ([]models.VersionTag, error) {
+	return nil, nil
+}
+
+func (s *testStore2) AddPeriodTag(ctx context.Context, teamID, periodID string, tag models.VersionTag) error {
+	panic("not implemented")
+}
+
+func (s *testStore2) DeletePeriodTag(ctx context.Context, teamID, periodID, name string) error {
+	panic("not implemented")
+}
+
+func (s *testStore2) RenameTeam(ctx context.Context, teamID, newTeamID string) error {
+	panic("not implemented")
+}
+
+func (s *testStore2) RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string) error {
+	panic("not implemented")
+}
+
 func (s *testStore2) CreateTeam(ctx context.Context, team models.Team) error {
 	panic("not implemented")
 }
@@ -69,6 +107,10 @@ func (s *testStore2) GetSettings(ctx context.Context) (models.Settings, error) {
 	panic("not implemented")
 }
 
+func (s *testStore2) UpdateSettings(ctx context.Context, settings models.Settings) error {
+	panic("not implemented")
+}
+
 func (s *testStore2) Close() error {
 	panic("not implemented")
 }
@@ -163,3 +205,62 @@ func TestUpsertPeriodLatestVersion(t *testing.T) {
 		t.Errorf("period.SecondaryUnits was nil")
 	}
 }
+
+func TestGetPeriodVersionScrubbing(t *testing.T) {
+	ctx := context.Background()
+	s := MakeScrubbingWrapper2(&testStore2{periods: map[string]models.Period2{
+		"v1": {ID: "p1", Version: "v1", Buckets: []models.Bucket{{DisplayName: "Bucket 1"}}}}})
+	period, err := s.GetPeriodVersion(ctx, "myteam", "p1", "v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if period.People == nil {
+		t.Errorf("period.People was nil")
+	}
+	if period.Buckets[0].Objectives == nil {
+		t.Errorf("Objectives was nil")
+	}
+}
+
+func TestGetPeriodAncestry(t *testing.T) {
+	ctx := context.Background()
+	s := &testStore2{periods: map[string]models.Period2{
+		"v1": {ID: "p1", Version: "v1"},
+		"v2": {ID: "p1", Version: "v2", ParentVersions: []string{"v1"}},
+		"v3": {ID: "p1", Version: "v3", ParentVersions: []string{"v1"}},
+		"v4": {ID: "p1", Version: "v4", ParentVersions: []string{"v3", "v2"}},
+	}}
+	for _, tc := range []struct {
+		limit    int
+		expected []string
+	}{
+		{0, []string{"v4", "v3", "v2", "v1"}},
+		{2, []string{"v4", "v3"}},
+	} {
+		ancestry, err := GetPeriodAncestry(ctx, s, "myteam", "p1", "v4", tc.limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var versions []string
+		for _, v := range ancestry {
+			versions = append(versions, v.Version)
+		}
+		if !reflect.DeepEqual(tc.expected, versions) {
+			t.Errorf("expected ancestry %v with limit %d, found %v", tc.expected, tc.limit, versions)
+		}
+	}
+	if _, err := GetPeriodAncestry(ctx, s, "myteam", "p1", "v5", 0); err == nil {
+		t.Errorf("expected error for non-existent version")
+	}
+}
+
+func TestGetPeriodTagsScrubbing(t *testing.T) {
+	s := MakeScrubbingWrapper2(&testStore2{})
+	tags, err := s.GetPeriodTags(context.Background(), "myteam", "p1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tags == nil {
+		t.Errorf("tags was nil")
+	}
+}
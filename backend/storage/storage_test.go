@@ -1,4 +1,4 @@
-// Copyright 2024 Google LLC
+// Copyright 2024, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -67,6 +67,14 @@ func (s *testStore) UpdatePeriod(ctx context.Context, teamID string, period *mod
 	panic("not implemented")
 }
 
+func (s *testStore) ModifyPeriod(ctx context.Context, teamID, periodID, lastUpdateUUID string, modify func(period *models.Period) error) error {
+	panic("not implemented")
+}
+
+func (s *testStore) UpdatePeriodTags(ctx context.Context, teamID, periodID string, update func(backups models.PeriodBackups) ([]models.VersionTag, error)) error {
+	panic("not implemented")
+}
+
 func (s *testStore) GetPeriodBackups(ctx context.Context, teamID string, periodID string) (models.PeriodBackups, bool, error) {
 	panic("not implemented")
 }
@@ -75,10 +83,98 @@ func (s *testStore) UpsertPeriodBackups(ctx context.Context, teamID string, peri
 	panic("not implemented")
 }
 
+func (s *testStore) GetTeamBackups(ctx context.Context, teamID string) (models.TeamBackups, bool, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) UpsertTeamBackups(ctx context.Context, teamID string, backups models.TeamBackups) error {
+	panic("not implemented")
+}
+
+func (s *testStore) DeleteTeam(ctx context.Context, teamID string, deletion models.Deletion) error {
+	panic("not implemented")
+}
+
+func (s *testStore) DeletePeriod(ctx context.Context, teamID, periodID string, deletion models.Deletion) error {
+	panic("not implemented")
+}
+
+func (s *testStore) GetDeletedItems(ctx context.Context) ([]models.DeletedItem, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) RestoreDeletedItem(ctx context.Context, teamID, periodID string) error {
+	panic("not implemented")
+}
+
+func (s *testStore) PurgeDeletedItem(ctx context.Context, teamID, periodID string) error {
+	panic("not implemented")
+}
+
+func (s *testStore) RenameTeam(ctx context.Context, teamID, newTeamID string, alias models.Alias) error {
+	panic("not implemented")
+}
+
+func (s *testStore) RenamePeriod(ctx context.Context, teamID, periodID, newPeriodID string, alias models.Alias) error {
+	panic("not implemented")
+}
+
+func (s *testStore) GetTeamAlias(ctx context.Context, teamID string) (models.Alias, bool, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) GetPeriodAlias(ctx context.Context, teamID, periodID string) (models.Alias, bool, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) GetRenamedItems(ctx context.Context) ([]models.RenamedItem, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, bool, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	panic("not implemented")
+}
+
+func (s *testStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	panic("not implemented")
+}
+
+func (s *testStore) GetTeamTemplates(ctx context.Context, teamID string) (models.PeriodTemplates, bool, error) {
+	panic("not implemented")
+}
+
+func (s *testStore) UpsertTeamTemplates(ctx context.Context, teamID string, templates models.PeriodTemplates) error {
+	panic("not implemented")
+}
+
 func (s *testStore) GetSettings(ctx context.Context) (models.Settings, error) {
 	panic("not implemented")
 }
 
+func (s *testStore) UpdateSettings(ctx context.Context, settings models.Settings) error {
+	panic("not implemented")
+}
+
+func (s *testStore) UpdateOrgTemplates(ctx context.Context, templates []models.PeriodTemplate) error {
+	panic("not implemented")
+}
+
+func (s *testStore) AddAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	panic("not implemented")
+}
+
+func (s *testStore) GetAuditEntries(ctx context.Context, teamID, periodID, pageToken string, pageSize int) (models.AuditPage, error) {
+	panic("not implemented")
+}
+
 func (s *testStore) Close() error {
 	panic("not implemented")
 }
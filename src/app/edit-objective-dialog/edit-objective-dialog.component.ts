@@ -1,4 +1,4 @@
-// Copyright 2019-2023, 2025 Google LLC
+// Copyright 2019-2023, 2025-2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -31,6 +31,7 @@ import {
   ObjectiveTag,
   ImmutableObjective,
   DisplayOptions,
+  ObjectiveOrigin,
 } from '../objective';
 import { ImmutableBucket } from '../bucket';
 import { Assignment } from '../assignment';
@@ -57,6 +58,7 @@ export interface EditedObjective {
   assignments: Assignment[];
   displayOptions: DisplayOptions;
   blockID?: string;
+  carriedOverFrom?: ObjectiveOrigin;
 }
 
 export enum SaveAction {
@@ -97,6 +99,7 @@ export const makeEditedObjective = (
       enableMarkdown: false,
     },
     blockID: objective.blockID,
+    carriedOverFrom: objective.carriedOverFrom,
   };
 };
 
@@ -139,6 +142,7 @@ const makeObjective = (edited: EditedObjective): ImmutableObjective =>
     assignments: edited.assignments,
     displayOptions: edited.displayOptions,
     blockID: edited.blockID,
+    carriedOverFrom: edited.carriedOverFrom,
   });
 
 @Component({
@@ -1,4 +1,4 @@
-// Copyright 2019-2023 Google LLC
+// Copyright 2019-2023, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -95,6 +95,14 @@ export class ImmutableDisplayOptions {
   }
 }
 
+// Where an objective was copied from when rolling a period forward
+export interface ObjectiveOrigin {
+  periodId: string;
+  bucketIndex: number;
+  objectiveIndex: number;
+  objectiveName: string;
+}
+
 export interface Objective {
   name: string;
   resourceEstimate: number;
@@ -105,6 +113,7 @@ export interface Objective {
   assignments: Assignment[];
   displayOptions?: DisplayOptions;
   blockID?: string;
+  carriedOverFrom?: ObjectiveOrigin;
 }
 
 // Boilerplate avoidance device
@@ -118,6 +127,7 @@ interface ImmutableObjectiveIF {
   readonly assignments: readonly ImmutableAssignment[];
   readonly displayOptions?: ImmutableDisplayOptions;
   readonly blockID?: string;
+  readonly carriedOverFrom?: Readonly<ObjectiveOrigin>;
 }
 
 export class ImmutableObjective {
@@ -132,6 +142,7 @@ export class ImmutableObjective {
   readonly assignments: readonly ImmutableAssignment[];
   readonly displayOptions?: ImmutableDisplayOptions;
   readonly blockID?: string;
+  readonly carriedOverFrom?: Readonly<ObjectiveOrigin>;
 
   private constructor(o: ImmutableObjectiveIF) {
     this.name = o.name;
@@ -143,6 +154,7 @@ export class ImmutableObjective {
     this.assignments = o.assignments;
     this.displayOptions = o.displayOptions;
     this.blockID = o.blockID;
+    this.carriedOverFrom = o.carriedOverFrom;
   }
 
   static fromObjective(objective: Objective): ImmutableObjective {
@@ -165,6 +177,9 @@ export class ImmutableObjective {
           ? undefined
           : new ImmutableDisplayOptions(objective.displayOptions),
       blockID: objective.blockID,
+      carriedOverFrom: objective.carriedOverFrom
+        ? { ...objective.carriedOverFrom }
+        : undefined,
     });
   }
 
@@ -182,6 +197,9 @@ export class ImmutableObjective {
     if (this.blockID) {
       result.blockID = this.blockID;
     }
+    if (this.carriedOverFrom) {
+      result.carriedOverFrom = { ...this.carriedOverFrom };
+    }
     return result;
   }
 
@@ -1,4 +1,4 @@
-// Copyright 2019-2023 Google LLC
+// Copyright 2019-2023, 2026 Google LLC
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -53,6 +53,9 @@ export interface Period {
   people: Person[];
   secondaryUnits: SecondaryUnit[];
   lastUpdateUUID: string;
+  // Optional date range, as YYYY-MM-DD (inclusive)
+  startDate?: string;
+  endDate?: string;
 }
 
 // Boilerplate-reduction device
@@ -67,6 +70,8 @@ interface ImmutablePeriodIF {
   readonly people: readonly ImmutablePerson[];
   readonly secondaryUnits: readonly ImmutableSecondaryUnit[];
   readonly lastUpdateUUID: string;
+  readonly startDate?: string;
+  readonly endDate?: string;
 }
 
 export class ImmutablePeriod implements ImmutablePeriodIF {
@@ -81,6 +86,8 @@ export class ImmutablePeriod implements ImmutablePeriodIF {
   readonly people: readonly ImmutablePerson[];
   readonly secondaryUnits: readonly ImmutableSecondaryUnit[];
   readonly lastUpdateUUID: string;
+  readonly startDate?: string;
+  readonly endDate?: string;
 
   private constructor(from: ImmutablePeriodIF) {
     this.id = from.id;
@@ -93,6 +100,8 @@ export class ImmutablePeriod implements ImmutablePeriodIF {
     this.people = from.people;
     this.secondaryUnits = from.secondaryUnits;
     this.lastUpdateUUID = from.lastUpdateUUID;
+    this.startDate = from.startDate;
+    this.endDate = from.endDate;
   }
 
   static fromPeriod(period: Period): ImmutablePeriod {
@@ -109,11 +118,13 @@ export class ImmutablePeriod implements ImmutablePeriodIF {
         (su) => new ImmutableSecondaryUnit(su)
       ),
       lastUpdateUUID: period.lastUpdateUUID,
+      startDate: period.startDate,
+      endDate: period.endDate,
     });
   }
 
   toOriginal(): Period {
-    return {
+    const result: Period = {
       id: this.id,
       displayName: this.displayName,
       unit: this.unit,
@@ -125,6 +136,13 @@ export class ImmutablePeriod implements ImmutablePeriodIF {
       secondaryUnits: this.secondaryUnits.map((su) => su.toOriginal()),
       lastUpdateUUID: this.lastUpdateUUID,
     };
+    if (this.startDate) {
+      result.startDate = this.startDate;
+    }
+    if (this.endDate) {
+      result.endDate = this.endDate;
+    }
+    return result;
   }
 
   withNewLastUpdateUUID(lastUpdateUUID: string): ImmutablePeriod {